@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.25.0-devel
+// 	protoc-gen-go v1.36.6
 // 	protoc        v3.14.0
 // source: api/grpc/kubeaegis.proto
 
@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -22,21 +23,19 @@ const (
 
 // 메시지 정의
 type PolicyRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	PolicyName      string `protobuf:"bytes,1,opt,name=policyName,proto3" json:"policyName,omitempty"`           // 정책 이름
-	PolicyNamespace string `protobuf:"bytes,2,opt,name=policyNamespace,proto3" json:"policyNamespace,omitempty"` // 정책 네임스페이스
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PolicyName      string                 `protobuf:"bytes,1,opt,name=policyName,proto3" json:"policyName,omitempty"`            // 정책 이름
+	PolicyNamespace string                 `protobuf:"bytes,2,opt,name=policyNamespace,proto3" json:"policyNamespace,omitempty"`  // 정책 네임스페이스
+	ProtocolVersion uint32                 `protobuf:"varint,3,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"` // 컨트롤러 프로토콜 버전
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *PolicyRequest) Reset() {
 	*x = PolicyRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_api_grpc_kubeaegis_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *PolicyRequest) String() string {
@@ -47,7 +46,7 @@ func (*PolicyRequest) ProtoMessage() {}
 
 func (x *PolicyRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_grpc_kubeaegis_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -76,23 +75,27 @@ func (x *PolicyRequest) GetPolicyNamespace() string {
 	return ""
 }
 
-type PolicyResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
+func (x *PolicyRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
 
-	Success           bool   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message           string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                     // 성공/실패 메시지
-	AdapterPolicyName string `protobuf:"bytes,3,opt,name=adapterPolicyName,proto3" json:"adapterPolicyName,omitempty"` // 실제 적용된 정책 이름
+type PolicyResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Success           bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message           string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                     // 성공/실패 메시지
+	AdapterPolicyName string                 `protobuf:"bytes,3,opt,name=adapterPolicyName,proto3" json:"adapterPolicyName,omitempty"` // 실제 적용된 정책 이름
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *PolicyResponse) Reset() {
 	*x = PolicyResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_api_grpc_kubeaegis_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *PolicyResponse) String() string {
@@ -103,7 +106,7 @@ func (*PolicyResponse) ProtoMessage() {}
 
 func (x *PolicyResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_grpc_kubeaegis_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -140,22 +143,20 @@ func (x *PolicyResponse) GetAdapterPolicyName() string {
 }
 
 type PolicyDeletionRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	PolicyName      string   `protobuf:"bytes,1,opt,name=policyName,proto3" json:"policyName,omitempty"`           // 정책 이름
-	PolicyNamespace string   `protobuf:"bytes,2,opt,name=policyNamespace,proto3" json:"policyNamespace,omitempty"` // 정책 네임스페이스
-	KspNames        []string `protobuf:"bytes,3,rep,name=kspNames,proto3" json:"kspNames,omitempty"`               // 삭제된 KSP 이름 목록
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PolicyName      string                 `protobuf:"bytes,1,opt,name=policyName,proto3" json:"policyName,omitempty"`            // 정책 이름
+	PolicyNamespace string                 `protobuf:"bytes,2,opt,name=policyNamespace,proto3" json:"policyNamespace,omitempty"`  // 정책 네임스페이스
+	KspNames        []string               `protobuf:"bytes,3,rep,name=kspNames,proto3" json:"kspNames,omitempty"`                // 삭제된 KSP 이름 목록
+	ProtocolVersion uint32                 `protobuf:"varint,4,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"` // 컨트롤러 프로토콜 버전
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *PolicyDeletionRequest) Reset() {
 	*x = PolicyDeletionRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_api_grpc_kubeaegis_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *PolicyDeletionRequest) String() string {
@@ -166,7 +167,7 @@ func (*PolicyDeletionRequest) ProtoMessage() {}
 
 func (x *PolicyDeletionRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_grpc_kubeaegis_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -202,22 +203,26 @@ func (x *PolicyDeletionRequest) GetKspNames() []string {
 	return nil
 }
 
+func (x *PolicyDeletionRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
 type PolicyDeletionResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"` // 성공/실패 메시지
 	unknownFields protoimpl.UnknownFields
-
-	Success bool   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"` // 성공/실패 메시지
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PolicyDeletionResponse) Reset() {
 	*x = PolicyDeletionResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_api_grpc_kubeaegis_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *PolicyDeletionResponse) String() string {
@@ -228,7 +233,7 @@ func (*PolicyDeletionResponse) ProtoMessage() {}
 
 func (x *PolicyDeletionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_grpc_kubeaegis_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -257,83 +262,250 @@ func (x *PolicyDeletionResponse) GetMessage() string {
 	return ""
 }
 
-var File_api_grpc_kubeaegis_proto protoreflect.FileDescriptor
+type InfoRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion uint32                 `protobuf:"varint,1,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"` // 컨트롤러 프로토콜 버전
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_kubeaegis_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InfoRequest) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+type SubTypes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SubTypes      []string               `protobuf:"bytes,1,rep,name=subTypes,proto3" json:"subTypes,omitempty"` // 지원하는 subType 목록
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubTypes) Reset() {
+	*x = SubTypes{}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubTypes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubTypes) ProtoMessage() {}
+
+func (x *SubTypes) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubTypes.ProtoReflect.Descriptor instead.
+func (*SubTypes) Descriptor() ([]byte, []int) {
+	return file_api_grpc_kubeaegis_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SubTypes) GetSubTypes() []string {
+	if x != nil {
+		return x.SubTypes
+	}
+	return nil
+}
+
+type InfoResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AdapterName        string                 `protobuf:"bytes,1,opt,name=adapterName,proto3" json:"adapterName,omitempty"`                                                                                 // 어댑터 이름
+	BuildVersion       string                 `protobuf:"bytes,2,opt,name=buildVersion,proto3" json:"buildVersion,omitempty"`                                                                               // 어댑터 빌드 버전
+	ProtocolVersion    uint32                 `protobuf:"varint,3,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"`                                                                        // 어댑터 프로토콜 버전
+	MinProtocolVersion uint32                 `protobuf:"varint,4,opt,name=minProtocolVersion,proto3" json:"minProtocolVersion,omitempty"`                                                                  // 어댑터가 지원하는 최소 프로토콜 버전
+	SupportedTypes     map[string]*SubTypes   `protobuf:"bytes,5,rep,name=supportedTypes,proto3" json:"supportedTypes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // type 별 지원 subType
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_kubeaegis_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_kubeaegis_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *InfoResponse) GetAdapterName() string {
+	if x != nil {
+		return x.AdapterName
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetBuildVersion() string {
+	if x != nil {
+		return x.BuildVersion
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
 
-var file_api_grpc_kubeaegis_proto_rawDesc = []byte{
-	0x0a, 0x18, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x6b, 0x75, 0x62, 0x65, 0x61,
-	0x65, 0x67, 0x69, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x6b, 0x75, 0x62, 0x65,
-	0x61, 0x65, 0x67, 0x69, 0x73, 0x22, 0x59, 0x0a, 0x0d, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
-	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6f, 0x6c, 0x69,
-	0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
-	0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65,
-	0x22, 0x72, 0x0a, 0x0e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2c, 0x0a, 0x11, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65,
-	0x72, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x11, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
-	0x4e, 0x61, 0x6d, 0x65, 0x22, 0x7d, 0x0a, 0x15, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x44, 0x65,
-	0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a,
-	0x0a, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0a, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28, 0x0a,
-	0x0f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4e, 0x61,
-	0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6b, 0x73, 0x70, 0x4e, 0x61,
-	0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x6b, 0x73, 0x70, 0x4e, 0x61,
-	0x6d, 0x65, 0x73, 0x22, 0x4c, 0x0a, 0x16, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a,
-	0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
-	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x32, 0xb7, 0x01, 0x0a, 0x0d, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x53, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x0e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x50,
-	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x18, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x65, 0x67, 0x69,
-	0x73, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x19, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x65, 0x67, 0x69, 0x73, 0x2e, 0x50, 0x6f, 0x6c, 0x69,
-	0x63, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x14,
-	0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x65, 0x67, 0x69, 0x73,
-	0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x61, 0x65, 0x67,
-	0x69, 0x73, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x69, 0x6f,
-	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x29, 0x5a, 0x27, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x63, 0x6c, 0x61, 0x62, 0x2d,
-	0x69, 0x6e, 0x75, 0x2f, 0x4b, 0x75, 0x62, 0x65, 0x41, 0x65, 0x67, 0x69, 0x73, 0x2f, 0x61, 0x70,
-	0x69, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *InfoResponse) GetMinProtocolVersion() uint32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *InfoResponse) GetSupportedTypes() map[string]*SubTypes {
+	if x != nil {
+		return x.SupportedTypes
+	}
+	return nil
 }
 
+var File_api_grpc_kubeaegis_proto protoreflect.FileDescriptor
+
+const file_api_grpc_kubeaegis_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/grpc/kubeaegis.proto\x12\tkubeaegis\"\x83\x01\n" +
+	"\rPolicyRequest\x12\x1e\n" +
+	"\n" +
+	"policyName\x18\x01 \x01(\tR\n" +
+	"policyName\x12(\n" +
+	"\x0fpolicyNamespace\x18\x02 \x01(\tR\x0fpolicyNamespace\x12(\n" +
+	"\x0fprotocolVersion\x18\x03 \x01(\rR\x0fprotocolVersion\"r\n" +
+	"\x0ePolicyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12,\n" +
+	"\x11adapterPolicyName\x18\x03 \x01(\tR\x11adapterPolicyName\"\xa7\x01\n" +
+	"\x15PolicyDeletionRequest\x12\x1e\n" +
+	"\n" +
+	"policyName\x18\x01 \x01(\tR\n" +
+	"policyName\x12(\n" +
+	"\x0fpolicyNamespace\x18\x02 \x01(\tR\x0fpolicyNamespace\x12\x1a\n" +
+	"\bkspNames\x18\x03 \x03(\tR\bkspNames\x12(\n" +
+	"\x0fprotocolVersion\x18\x04 \x01(\rR\x0fprotocolVersion\"L\n" +
+	"\x16PolicyDeletionResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"7\n" +
+	"\vInfoRequest\x12(\n" +
+	"\x0fprotocolVersion\x18\x01 \x01(\rR\x0fprotocolVersion\"&\n" +
+	"\bSubTypes\x12\x1a\n" +
+	"\bsubTypes\x18\x01 \x03(\tR\bsubTypes\"\xdb\x02\n" +
+	"\fInfoResponse\x12 \n" +
+	"\vadapterName\x18\x01 \x01(\tR\vadapterName\x12\"\n" +
+	"\fbuildVersion\x18\x02 \x01(\tR\fbuildVersion\x12(\n" +
+	"\x0fprotocolVersion\x18\x03 \x01(\rR\x0fprotocolVersion\x12.\n" +
+	"\x12minProtocolVersion\x18\x04 \x01(\rR\x12minProtocolVersion\x12S\n" +
+	"\x0esupportedTypes\x18\x05 \x03(\v2+.kubeaegis.InfoResponse.SupportedTypesEntryR\x0esupportedTypes\x1aV\n" +
+	"\x13SupportedTypesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x05value\x18\x02 \x01(\v2\x13.kubeaegis.SubTypesR\x05value:\x028\x012\xf5\x01\n" +
+	"\rPolicyService\x12G\n" +
+	"\x0eDispatchPolicy\x12\x18.kubeaegis.PolicyRequest\x1a\x19.kubeaegis.PolicyResponse\"\x00\x12]\n" +
+	"\x14NotifyPolicyDeletion\x12 .kubeaegis.PolicyDeletionRequest\x1a!.kubeaegis.PolicyDeletionResponse\"\x00\x12<\n" +
+	"\aGetInfo\x12\x16.kubeaegis.InfoRequest\x1a\x17.kubeaegis.InfoResponse\"\x00B)Z'github.com/cclab-inu/KubeAegis/api/grpcb\x06proto3"
+
 var (
 	file_api_grpc_kubeaegis_proto_rawDescOnce sync.Once
-	file_api_grpc_kubeaegis_proto_rawDescData = file_api_grpc_kubeaegis_proto_rawDesc
+	file_api_grpc_kubeaegis_proto_rawDescData []byte
 )
 
 func file_api_grpc_kubeaegis_proto_rawDescGZIP() []byte {
 	file_api_grpc_kubeaegis_proto_rawDescOnce.Do(func() {
-		file_api_grpc_kubeaegis_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_grpc_kubeaegis_proto_rawDescData)
+		file_api_grpc_kubeaegis_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_grpc_kubeaegis_proto_rawDesc), len(file_api_grpc_kubeaegis_proto_rawDesc)))
 	})
 	return file_api_grpc_kubeaegis_proto_rawDescData
 }
 
-var file_api_grpc_kubeaegis_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
-var file_api_grpc_kubeaegis_proto_goTypes = []interface{}{
+var file_api_grpc_kubeaegis_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_api_grpc_kubeaegis_proto_goTypes = []any{
 	(*PolicyRequest)(nil),          // 0: kubeaegis.PolicyRequest
 	(*PolicyResponse)(nil),         // 1: kubeaegis.PolicyResponse
 	(*PolicyDeletionRequest)(nil),  // 2: kubeaegis.PolicyDeletionRequest
 	(*PolicyDeletionResponse)(nil), // 3: kubeaegis.PolicyDeletionResponse
+	(*InfoRequest)(nil),            // 4: kubeaegis.InfoRequest
+	(*SubTypes)(nil),               // 5: kubeaegis.SubTypes
+	(*InfoResponse)(nil),           // 6: kubeaegis.InfoResponse
+	nil,                            // 7: kubeaegis.InfoResponse.SupportedTypesEntry
 }
 var file_api_grpc_kubeaegis_proto_depIdxs = []int32{
-	0, // 0: kubeaegis.PolicyService.DispatchPolicy:input_type -> kubeaegis.PolicyRequest
-	2, // 1: kubeaegis.PolicyService.NotifyPolicyDeletion:input_type -> kubeaegis.PolicyDeletionRequest
-	1, // 2: kubeaegis.PolicyService.DispatchPolicy:output_type -> kubeaegis.PolicyResponse
-	3, // 3: kubeaegis.PolicyService.NotifyPolicyDeletion:output_type -> kubeaegis.PolicyDeletionResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	7, // 0: kubeaegis.InfoResponse.supportedTypes:type_name -> kubeaegis.InfoResponse.SupportedTypesEntry
+	5, // 1: kubeaegis.InfoResponse.SupportedTypesEntry.value:type_name -> kubeaegis.SubTypes
+	0, // 2: kubeaegis.PolicyService.DispatchPolicy:input_type -> kubeaegis.PolicyRequest
+	2, // 3: kubeaegis.PolicyService.NotifyPolicyDeletion:input_type -> kubeaegis.PolicyDeletionRequest
+	4, // 4: kubeaegis.PolicyService.GetInfo:input_type -> kubeaegis.InfoRequest
+	1, // 5: kubeaegis.PolicyService.DispatchPolicy:output_type -> kubeaegis.PolicyResponse
+	3, // 6: kubeaegis.PolicyService.NotifyPolicyDeletion:output_type -> kubeaegis.PolicyDeletionResponse
+	6, // 7: kubeaegis.PolicyService.GetInfo:output_type -> kubeaegis.InfoResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_api_grpc_kubeaegis_proto_init() }
@@ -341,63 +513,13 @@ func file_api_grpc_kubeaegis_proto_init() {
 	if File_api_grpc_kubeaegis_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_api_grpc_kubeaegis_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PolicyRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_grpc_kubeaegis_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PolicyResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_grpc_kubeaegis_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PolicyDeletionRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_api_grpc_kubeaegis_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PolicyDeletionResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_api_grpc_kubeaegis_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_kubeaegis_proto_rawDesc), len(file_api_grpc_kubeaegis_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -406,7 +528,6 @@ func file_api_grpc_kubeaegis_proto_init() {
 		MessageInfos:      file_api_grpc_kubeaegis_proto_msgTypes,
 	}.Build()
 	File_api_grpc_kubeaegis_proto = out.File
-	file_api_grpc_kubeaegis_proto_rawDesc = nil
 	file_api_grpc_kubeaegis_proto_goTypes = nil
 	file_api_grpc_kubeaegis_proto_depIdxs = nil
 }
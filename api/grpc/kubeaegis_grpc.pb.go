@@ -22,6 +22,8 @@ type PolicyServiceClient interface {
 	DispatchPolicy(ctx context.Context, in *PolicyRequest, opts ...grpc.CallOption) (*PolicyResponse, error)
 	// KubeAegis 정책 삭제를 어댑터에 전송
 	NotifyPolicyDeletion(ctx context.Context, in *PolicyDeletionRequest, opts ...grpc.CallOption) (*PolicyDeletionResponse, error)
+	// 어댑터의 빌드/프로토콜 버전과 지원 타입 조회
+	GetInfo(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
 }
 
 type policyServiceClient struct {
@@ -50,6 +52,15 @@ func (c *policyServiceClient) NotifyPolicyDeletion(ctx context.Context, in *Poli
 	return out, nil
 }
 
+func (c *policyServiceClient) GetInfo(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, "/kubeaegis.PolicyService/GetInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PolicyServiceServer is the server API for PolicyService service.
 // All implementations must embed UnimplementedPolicyServiceServer
 // for forward compatibility
@@ -58,6 +69,8 @@ type PolicyServiceServer interface {
 	DispatchPolicy(context.Context, *PolicyRequest) (*PolicyResponse, error)
 	// KubeAegis 정책 삭제를 어댑터에 전송
 	NotifyPolicyDeletion(context.Context, *PolicyDeletionRequest) (*PolicyDeletionResponse, error)
+	// 어댑터의 빌드/프로토콜 버전과 지원 타입 조회
+	GetInfo(context.Context, *InfoRequest) (*InfoResponse, error)
 	mustEmbedUnimplementedPolicyServiceServer()
 }
 
@@ -71,6 +84,9 @@ func (UnimplementedPolicyServiceServer) DispatchPolicy(context.Context, *PolicyR
 func (UnimplementedPolicyServiceServer) NotifyPolicyDeletion(context.Context, *PolicyDeletionRequest) (*PolicyDeletionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NotifyPolicyDeletion not implemented")
 }
+func (UnimplementedPolicyServiceServer) GetInfo(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
 func (UnimplementedPolicyServiceServer) mustEmbedUnimplementedPolicyServiceServer() {}
 
 // UnsafePolicyServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +136,24 @@ func _PolicyService_NotifyPolicyDeletion_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PolicyService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServiceServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kubeaegis.PolicyService/GetInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServiceServer).GetInfo(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PolicyService_ServiceDesc is the grpc.ServiceDesc for PolicyService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -135,6 +169,10 @@ var PolicyService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NotifyPolicyDeletion",
 			Handler:    _PolicyService_NotifyPolicyDeletion_Handler,
 		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _PolicyService_GetInfo_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/grpc/kubeaegis.proto",
@@ -0,0 +1,19 @@
+package grpc
+
+const (
+	// ProtocolVersion is the PolicyService protocol version spoken by this build.
+	// Bump it whenever kubeaegis.proto changes in a way an older peer cannot
+	// safely ignore (new required fields, changed field semantics, new RPCs that
+	// are called unconditionally).
+	ProtocolVersion uint32 = 1
+
+	// MinProtocolVersion is the oldest peer protocol version this build still
+	// accepts.
+	MinProtocolVersion uint32 = 1
+)
+
+// IsCompatible reports whether a peer speaking peerVersion, and accepting
+// nothing older than peerMinVersion, can talk to this build.
+func IsCompatible(peerVersion, peerMinVersion uint32) bool {
+	return peerVersion >= MinProtocolVersion && ProtocolVersion >= peerMinVersion
+}
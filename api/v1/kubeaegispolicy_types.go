@@ -23,10 +23,32 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// Scopes of a KubeAegisPolicy.
+const (
+	ScopeNamespace = "Namespace"
+	ScopeCluster   = "Cluster"
+	ScopeBaseline  = "Baseline"
+)
+
 // KubeAegisPolicySpec defines the desired state of KubeAegisPolicy.
 type KubeAegisPolicySpec struct {
-	EnableReporting bool            `json:"enableReport,omitempty"`
-	IntentRequest   []IntentRequest `json:"intentRequest"`
+	EnableReporting bool `json:"enableReport,omitempty"`
+
+	// Scope is Namespace, the default, Cluster or Baseline. The network
+	// intents of Cluster and Baseline policies select pods in every namespace.
+	// Baseline policies rank below the namespaces' own policies, Cluster
+	// policies above them.
+	// +kubebuilder:validation:Enum=Namespace;Cluster;Baseline
+	// +optional
+	Scope string `json:"scope,omitempty"`
+
+	// Priority orders the policy against other policies of the same scope.
+	// Lower values take precedence.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	IntentRequest []IntentRequest `json:"intentRequest"`
 }
 
 type IntentRequest struct {
@@ -38,14 +60,19 @@ type IntentRequest struct {
 type Selector struct {
 	Match []Match  `json:"match,omitempty"`
 	CEL   []string `json:"cel,omitempty"`
+
+	// NamespaceSelector selects the namespaces whose pods the policy applies to.
+	// When unset, only the policy's own namespace is selected.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 type Match struct {
-	Kind        string            `json:"kind,omitempty"`
-	Condition   string            `json:"condition,omitempty"`
-	Namespace   string            `json:"namespace,omitempty"`
-	Name        string            `json:"name,omitempty"`
-	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	Kind             string                            `json:"kind,omitempty"`
+	Condition        string                            `json:"condition,omitempty"`
+	Namespace        string                            `json:"namespace,omitempty"`
+	Name             string                            `json:"name,omitempty"`
+	MatchLabels      map[string]string                 `json:"matchLabels,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
 }
 
 type Rule struct {
@@ -62,10 +89,17 @@ type NetPolDetail struct {
 	Kind string `json:"kind"`
 	// Namespace string `json:"namespace,omitempty"`
 	//Endpoint  string            `json:"endpoint,omitempty"`
-	Labels   map[string]string `json:"labels,omitempty"`
-	Args     []string          `json:"args,omitempty"`
-	Port     string            `json:"port,omitempty"`
-	Protocol string            `json:"protocol,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Args   []string          `json:"args,omitempty"`
+
+	// Except lists ranges inside the CIDRs of a cidr rule that the rule does
+	// not apply to.
+	Except []string `json:"except,omitempty"`
+
+	// Port is a port number, a container port name, a range such as
+	// 8000-8080, or a comma-separated list of these.
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
 }
 
 type ActionPoint struct {
@@ -93,12 +127,24 @@ type EventMatchResource struct {
 	Event     string `json:"event,omitempty"`
 	Symbol    string `json:"symbol,omitempty"`
 
-	Dir       string   `json:"dir,omitempty"`
-	Pattern   []string `json:"pattern,omitempty"`
-	Args      []string `json:"args,omitempty"`
-	Protocol  string   `json:"protocol,omitempty"`
-	ReadOnly  bool     `json:"readOnly,omitempty"`
-	Recursive bool     `json:"recursive,omitempty"`
+	Dir      string   `json:"dir,omitempty"`
+	Pattern  []string `json:"pattern,omitempty"`
+	Args     []string `json:"args,omitempty"`
+	Protocol string   `json:"protocol,omitempty"`
+
+	// ReadOnly lets file rules permit reads: Block rules only block writes,
+	// and Allow rules only allow reads.
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// Recursive extends a dir to its subdirectories.
+	Recursive bool `json:"recursive,omitempty"`
+
+	// OwnerOnly limits file and process rules to the owner of the file.
+	OwnerOnly bool `json:"ownerOnly,omitempty"`
+
+	// FromSource limits file, process and network rules to processes
+	// started from these executables or directories.
+	FromSource []FromSource `json:"fromSource,omitempty"`
 
 	// clusterpol
 	Kind      string `json:"kind,omitempty"`
@@ -146,6 +192,66 @@ type KubeAegisPolicyStatus struct {
 	ListofAPs         []string    `json:"listOfAPs,omitempty"`
 	NumberOfResources int32       `json:"numberOfResources,omitempty"`
 	ListofResources   []string    `json:"listOfResources,omitempty"`
+
+	// Conditions report adapter-facing state such as PolicyService protocol
+	// compatibility.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// IncompatibleAdapters lists the adapters whose PolicyService protocol
+	// the controller does not understand. The AdapterCompatible condition
+	// summarizes it.
+	// +listType=map
+	// +listMapKey=adapter
+	// +optional
+	IncompatibleAdapters []AdapterIncompatibility `json:"incompatibleAdapters,omitempty"`
+
+	// ValidationResults lists the problems the validators found in the spec.
+	// +optional
+	ValidationResults []ValidationResult `json:"validationResults,omitempty"`
+
+	// PolicyDiffs lists, for each generated policy, what the last enforcement
+	// changed in the live object.
+	// +optional
+	PolicyDiffs []PolicyDiff `json:"policyDiffs,omitempty"`
+}
+
+// AdapterIncompatibility is why the controller does not dispatch policies
+// to an adapter.
+type AdapterIncompatibility struct {
+	Adapter string `json:"adapter"`
+	Reason  string `json:"reason"`
+}
+
+// PolicyDiff is the semantic difference between a live generated policy and
+// the policy an adapter replaced it with.
+type PolicyDiff struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// Changes are the changed fields, as "+ path: value", "- path: value" or
+	// "~ path: old -> new".
+	Changes []string `json:"changes,omitempty"`
+
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// ValidationResult is one problem found by the validators.
+type ValidationResult struct {
+	// Field is the path of the offending field, such as
+	// spec.intentRequest[1].rule.to[0].port.
+	Field string `json:"field"`
+
+	// Severity is Error or Warning. Errors keep the policy from being
+	// dispatched.
+	Severity string `json:"severity"`
+
+	// Code is a stable identifier of the problem.
+	Code string `json:"code"`
+
+	Message string `json:"message"`
 }
 
 // +kubebuilder:object:root=true
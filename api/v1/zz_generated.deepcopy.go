@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -59,6 +60,21 @@ func (in *ActionPoint) DeepCopy() *ActionPoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdapterIncompatibility) DeepCopyInto(out *AdapterIncompatibility) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdapterIncompatibility.
+func (in *AdapterIncompatibility) DeepCopy() *AdapterIncompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(AdapterIncompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventFilter) DeepCopyInto(out *EventFilter) {
 	*out = *in
@@ -117,6 +133,11 @@ func (in *EventMatchResource) DeepCopyInto(out *EventMatchResource) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.FromSource != nil {
+		in, out := &in.FromSource, &out.FromSource
+		*out = make([]FromSource, len(*in))
+		copy(*out, *in)
+	}
 	if in.Details != nil {
 		in, out := &in.Details, &out.Details
 		*out = make([]map[string]string, len(*in))
@@ -268,6 +289,11 @@ func (in *KubeAegisPolicyList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeAegisPolicySpec) DeepCopyInto(out *KubeAegisPolicySpec) {
 	*out = *in
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
 	if in.IntentRequest != nil {
 		in, out := &in.IntentRequest, &out.IntentRequest
 		*out = make([]IntentRequest, len(*in))
@@ -301,6 +327,30 @@ func (in *KubeAegisPolicyStatus) DeepCopyInto(out *KubeAegisPolicyStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IncompatibleAdapters != nil {
+		in, out := &in.IncompatibleAdapters, &out.IncompatibleAdapters
+		*out = make([]AdapterIncompatibility, len(*in))
+		copy(*out, *in)
+	}
+	if in.ValidationResults != nil {
+		in, out := &in.ValidationResults, &out.ValidationResults
+		*out = make([]ValidationResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.PolicyDiffs != nil {
+		in, out := &in.PolicyDiffs, &out.PolicyDiffs
+		*out = make([]PolicyDiff, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAegisPolicyStatus.
@@ -323,6 +373,13 @@ func (in *Match) DeepCopyInto(out *Match) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Match.
@@ -350,6 +407,11 @@ func (in *NetPolDetail) DeepCopyInto(out *NetPolDetail) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Except != nil {
+		in, out := &in.Except, &out.Except
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetPolDetail.
@@ -362,6 +424,27 @@ func (in *NetPolDetail) DeepCopy() *NetPolDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyDiff) DeepCopyInto(out *PolicyDiff) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyDiff.
+func (in *PolicyDiff) DeepCopy() *PolicyDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
@@ -413,6 +496,11 @@ func (in *Selector) DeepCopyInto(out *Selector) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Selector.
@@ -424,3 +512,18 @@ func (in *Selector) DeepCopy() *Selector {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationResult) DeepCopyInto(out *ValidationResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationResult.
+func (in *ValidationResult) DeepCopy() *ValidationResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationResult)
+	in.DeepCopyInto(out)
+	return out
+}
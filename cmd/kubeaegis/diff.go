@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/policydiff"
+)
+
+// objectDiff is the difference between a rendered engine policy and its live
+// counterpart. missing is set when the policy does not exist yet.
+type objectDiff struct {
+	rendered renderedObject
+	kind     string
+	missing  bool
+	changes  []policydiff.Change
+}
+
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeaegis diff [flags] [file or directory]...")
+		fmt.Fprintln(flags.Output(), "\nRenders KubeAegisPolicies against the current cluster and compares the result with the live engine policies.")
+		fmt.Fprintln(flags.Output(), "Without files, the KubeAegisPolicies of the cluster are compared.")
+		flags.PrintDefaults()
+	}
+	var namespace, engineList string
+	var allNamespaces bool
+	flags.StringVar(&namespace, "namespace", "default", "The namespace of the policies to compare, and of policy files that do not set one.")
+	flags.BoolVar(&allNamespaces, "all-namespaces", false, "Compare the KubeAegisPolicies of every namespace. Ignored with files.")
+	flags.StringVar(&engineList, "engines", "cilium,kubearmor,kyverno", "Comma-separated engines to compare: "+
+		strings.Join(engineNames(), ", ")+".")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	selected, err := parseEngines(engineList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	k8sClient, err := client.New(cfg, client.Options{Scheme: engineScheme})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx := context.Background()
+	var policies []loadedPolicy
+	if flags.NArg() > 0 {
+		snap, err := loadSnapshot(flags.Args(), "", namespace)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		policies = snap.policies
+	} else {
+		if allNamespaces {
+			namespace = ""
+		}
+		if policies, err = livePolicies(ctx, k8sClient, namespace); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+
+	diffs, failed := diffPolicies(ctx, k8sClient, policies, selected)
+	if err := writeDiffs(os.Stdout, len(policies), diffs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if failed {
+		return exitUsage
+	}
+	for _, diff := range diffs {
+		if diff.missing || len(diff.changes) > 0 {
+			return exitFindings
+		}
+	}
+	return exitOK
+}
+
+// livePolicies lists the KubeAegisPolicies of the namespace, or of every
+// namespace when it is empty.
+func livePolicies(ctx context.Context, k8sClient client.Client, namespace string) ([]loadedPolicy, error) {
+	var list v1.KubeAegisPolicyList
+	if err := k8sClient.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list KubeAegisPolicies")
+	}
+
+	policies := make([]loadedPolicy, 0, len(list.Items))
+	for i := range list.Items {
+		kap := &list.Items[i]
+		policies = append(policies, loadedPolicy{
+			file:      "cluster",
+			namespace: kap.Namespace,
+			name:      kap.Name,
+			kap:       kap,
+		})
+	}
+	return policies, nil
+}
+
+// diffPolicies renders the policies for the selected engines and compares
+// every rendered policy with the live one. Errors are reported on standard
+// error, and failed is set.
+func diffPolicies(ctx context.Context, k8sClient client.Client, policies []loadedPolicy, selected []string) (diffs []objectDiff, failed bool) {
+	for _, policy := range policies {
+		if policy.kap == nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s/%s cannot be rendered:\n%s", policy.file, policy.line, policy.namespace, policy.name, policy.decodeResults)
+			failed = true
+			continue
+		}
+
+		for _, name := range selected {
+			if !dispatchedTo(policy.kap, engines[name]) {
+				continue
+			}
+			converted, err := engines[name].convert(ctx, k8sClient, logr.Discard(), policy.kap.DeepCopy())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s/%s: %s: %v\n", policy.namespace, policy.name, name, err)
+				failed = true
+				continue
+			}
+			for _, object := range converted {
+				rendered := renderedObject{policy: policy, engine: name, object: object}
+				diff, err := diffObject(ctx, k8sClient, rendered)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s/%s: %s: %v\n", policy.namespace, policy.name, name, err)
+					failed = true
+					continue
+				}
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+	return diffs, failed
+}
+
+// diffObject compares a rendered policy with the live policy of the same kind,
+// namespace and name. The rendered policy is first sent as a server-side dry
+// run, so that fields the API server defaults are not reported as removed.
+func diffObject(ctx context.Context, k8sClient client.Client, rendered renderedObject) (objectDiff, error) {
+	gvk, err := apiutil.GVKForObject(rendered.object, engineScheme)
+	if err != nil {
+		return objectDiff{}, err
+	}
+	diff := objectDiff{rendered: rendered, kind: gvk.Kind}
+
+	typed, err := engineScheme.New(gvk)
+	if err != nil {
+		return objectDiff{}, err
+	}
+	live, ok := typed.(client.Object)
+	if !ok {
+		return objectDiff{}, errors.Errorf("%s is not an object", gvk.Kind)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(rendered.object), live); err != nil {
+		if apierrors.IsNotFound(err) {
+			diff.missing = true
+			return diff, nil
+		}
+		return objectDiff{}, errors.Wrapf(err, "failed to get %s %s", gvk.Kind, rendered.object.GetName())
+	}
+
+	candidate, ok := rendered.object.DeepCopyObject().(client.Object)
+	if !ok {
+		return objectDiff{}, errors.Errorf("%s is not an object", gvk.Kind)
+	}
+	candidate.SetResourceVersion(live.GetResourceVersion())
+	candidate.SetOwnerReferences(live.GetOwnerReferences())
+	if err := k8sClient.Update(ctx, candidate, client.DryRunAll); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s/%s: dry run failed, comparing without defaults: %v\n",
+			gvk.Kind, rendered.object.GetNamespace(), rendered.object.GetName(), err)
+		candidate = rendered.object
+	}
+
+	if diff.changes, err = policydiff.Objects(live, candidate); err != nil {
+		return objectDiff{}, err
+	}
+	return diff, nil
+}
+
+// writeDiffs prints the changes of each rendered policy under a line naming it
+// and the KubeAegisPolicy it comes from, and a summary.
+func writeDiffs(w io.Writer, checked int, diffs []objectDiff) error {
+	changed := 0
+	for _, diff := range diffs {
+		object := diff.rendered.object
+		status := "unchanged"
+		switch {
+		case diff.missing:
+			status = "would be created"
+		case len(diff.changes) > 0:
+			status = fmt.Sprintf("%d changes", len(diff.changes))
+		}
+		if status != "unchanged" {
+			changed++
+		}
+
+		if _, err := fmt.Fprintf(w, "%s/%s: %s %s: %s\n", diff.rendered.policy.namespace, diff.rendered.policy.name,
+			diff.kind, qualifiedName(object), status); err != nil {
+			return err
+		}
+		for _, change := range diff.changes {
+			if _, err := fmt.Fprintf(w, "  %s\n", change); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d policies compared, %d of %d engine policies would change\n", checked, changed, len(diffs))
+	return err
+}
+
+func qualifiedName(object client.Object) string {
+	if object.GetNamespace() == "" {
+		return object.GetName()
+	}
+	return object.GetNamespace() + "/" + object.GetName()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	anpconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-anp/converter"
+	calicoconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-calico/converter"
+	ciliumconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-cilium/converter"
+	netpolconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-k8s-netpol/converter"
+	kubearmorconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-kubearmor/converter"
+	kyvernoconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-kyverno/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+	kyvernov1 "github.com/kyverno/kyverno/api/kyverno/v1"
+	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+// engineScheme knows the kinds of the engine policies, so that rendered
+// objects carry their apiVersion and kind. It also serves the client diff uses
+// to resolve selectors and fetch the live policies.
+var engineScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(engineScheme))
+	utilruntime.Must(v1.AddToScheme(engineScheme))
+	utilruntime.Must(ciliumv2.AddToScheme(engineScheme))
+	utilruntime.Must(karmorv1.AddToScheme(engineScheme))
+	utilruntime.Must(kyvernov1.AddToScheme(engineScheme))
+	utilruntime.Must(calico.AddToScheme(engineScheme))
+	utilruntime.Must(anpv1alpha1.AddToScheme(engineScheme))
+}
+
+// engine converts KubeAegisPolicies with the converter of one adapter.
+type engine struct {
+	// supportedTypes are the intent types and subtypes the adapter advertises
+	// to the controller.
+	supportedTypes map[string][]string
+	// scopes are the policy scopes the adapter renders.
+	scopes []string
+
+	convert func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error)
+
+	// policyKind is the kind import reads, and importPolicy converts a policy
+	// of that kind back into KubeAegisPolicies in namespace. importPolicy is nil
+	// for engines without an importer.
+	policyKind   schema.GroupVersionKind
+	importPolicy func(object client.Object, namespace string) ([]*v1.KubeAegisPolicy, validator.ResultList, error)
+}
+
+// engines are keyed by adapter name without the kubeaegis- prefix.
+var engines = map[string]engine{
+	"cilium": {
+		supportedTypes: ciliumconverter.SupportedTypes,
+		scopes:         ciliumconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			cnp, ccnp, err := ciliumconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			var objects []client.Object
+			if cnp != nil {
+				objects = append(objects, cnp)
+			}
+			if ccnp != nil {
+				objects = append(objects, ccnp)
+			}
+			return objects, nil
+		},
+		policyKind: ciliumv2.SchemeGroupVersion.WithKind("CiliumNetworkPolicy"),
+		importPolicy: func(object client.Object, namespace string) ([]*v1.KubeAegisPolicy, validator.ResultList, error) {
+			return ciliumconverter.Import(object.(*ciliumv2.CiliumNetworkPolicy))
+		},
+	},
+	"kubearmor": {
+		supportedTypes: kubearmorconverter.SupportedTypes,
+		scopes:         kubearmorconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			ksps, err := kubearmorconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			objects := make([]client.Object, 0, len(ksps))
+			for _, ksp := range ksps {
+				objects = append(objects, ksp)
+			}
+			return objects, nil
+		},
+		policyKind: karmorv1.GroupVersion.WithKind("KubeArmorPolicy"),
+		importPolicy: func(object client.Object, namespace string) ([]*v1.KubeAegisPolicy, validator.ResultList, error) {
+			kap, results, err := kubearmorconverter.Import(object.(*karmorv1.KubeArmorPolicy))
+			if kap == nil {
+				return nil, results, err
+			}
+			return []*v1.KubeAegisPolicy{kap}, results, err
+		},
+	},
+	"kyverno": {
+		supportedTypes: kyvernoconverter.SupportedTypes,
+		scopes:         kyvernoconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			policy, err := kyvernoconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			return []client.Object{policy}, nil
+		},
+		policyKind: kyvernov1.SchemeGroupVersion.WithKind("ClusterPolicy"),
+		importPolicy: func(object client.Object, namespace string) ([]*v1.KubeAegisPolicy, validator.ResultList, error) {
+			kap, results, err := kyvernoconverter.Import(object.(*kyvernov1.ClusterPolicy), namespace)
+			if kap == nil {
+				return nil, results, err
+			}
+			return []*v1.KubeAegisPolicy{kap}, results, err
+		},
+	},
+	"calico": {
+		supportedTypes: calicoconverter.SupportedTypes,
+		scopes:         calicoconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			policies, globalPolicies, err := calicoconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			objects := make([]client.Object, 0, len(policies)+len(globalPolicies))
+			for _, policy := range policies {
+				objects = append(objects, policy)
+			}
+			for _, policy := range globalPolicies {
+				objects = append(objects, policy)
+			}
+			return objects, nil
+		},
+	},
+	"k8s-netpol": {
+		supportedTypes: netpolconverter.SupportedTypes,
+		scopes:         netpolconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			policies, err := netpolconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			objects := make([]client.Object, 0, len(policies))
+			for _, policy := range policies {
+				objects = append(objects, policy)
+			}
+			return objects, nil
+		},
+	},
+	"anp": {
+		supportedTypes: anpconverter.SupportedTypes,
+		scopes:         anpconverter.Scopes,
+		convert: func(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]client.Object, error) {
+			anps, banp, err := anpconverter.Converter(ctx, k8sClient, logger, kap)
+			if err != nil {
+				return nil, err
+			}
+			objects := make([]client.Object, 0, len(anps)+1)
+			for _, anp := range anps {
+				objects = append(objects, anp)
+			}
+			if banp != nil {
+				objects = append(objects, banp)
+			}
+			return objects, nil
+		},
+	},
+}
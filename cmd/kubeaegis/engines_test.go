@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cclab-inu/KubeAegis/pkg/exporter"
+)
+
+// TestEnginesMatchAdapterConfig checks that the adapter ConfigMap the
+// controller reads lists what the adapters advertise and render.
+func TestEnginesMatchAdapterConfig(t *testing.T) {
+	data, err := os.ReadFile("../../pkg/exporter/adapterconfig.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var configMap corev1.ConfigMap
+	if err := yaml.Unmarshal(data, &configMap); err != nil {
+		t.Fatal(err)
+	}
+	var configs map[string]exporter.AdapterConfig
+	if err := json.Unmarshal([]byte(configMap.Data["config"]), &configs); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, e := range engines {
+		t.Run(name, func(t *testing.T) {
+			config, ok := configs["kubeaegis-"+name]
+			if !ok {
+				t.Fatalf("adapterconfig.yaml has no kubeaegis-%s", name)
+			}
+			if len(e.scopes) == 0 {
+				t.Errorf("engine %s renders no scopes", name)
+			}
+			if !reflect.DeepEqual(config.SupportedTypes, e.supportedTypes) {
+				t.Errorf("adapterconfig.yaml supportedTypes = %v, want %v", config.SupportedTypes, e.supportedTypes)
+			}
+			if !reflect.DeepEqual(config.Scopes, e.scopes) {
+				t.Errorf("adapterconfig.yaml scopes = %v, want %v", config.Scopes, e.scopes)
+			}
+		})
+	}
+}
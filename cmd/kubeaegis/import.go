@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	kubearmorconverter "github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-kubearmor/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+// importedPolicy is an engine policy converted back into KubeAegisPolicies.
+type importedPolicy struct {
+	source  document
+	kaps    []*v1.KubeAegisPolicy
+	results validator.ResultList
+}
+
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeaegis import [flags] [file or directory]...")
+		fmt.Fprintln(flags.Output(), "\nConverts engine policies into KubeAegisPolicies and reports the fields that have no equivalent.")
+		fmt.Fprintln(flags.Output(), "Without files, the policies are read from the cluster.")
+		flags.PrintDefaults()
+	}
+	var namespace, engineList, outputDir string
+	var allNamespaces bool
+	flags.StringVar(&namespace, "namespace", "default", "The namespace of the policies to read from the cluster, and of the "+
+		"KubeAegisPolicies made from cluster-scoped policies or from files that set none.")
+	flags.BoolVar(&allNamespaces, "all-namespaces", false, "Read the policies of every namespace from the cluster. Ignored with files.")
+	flags.StringVar(&engineList, "engines", strings.Join(importEngineNames(), ","), "Comma-separated engines to import from: "+
+		strings.Join(importEngineNames(), ", ")+".")
+	flags.StringVar(&outputDir, "output-dir", "", "Write one file per KubeAegisPolicy to <dir>/<namespace>/ instead of standard output.")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	selected, err := parseEngines(engineList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	for _, name := range selected {
+		if engines[name].importPolicy == nil {
+			fmt.Fprintf(os.Stderr, "engine %q has no importer; must be one of %s\n", name, strings.Join(importEngineNames(), ", "))
+			return exitUsage
+		}
+	}
+
+	var docs []document
+	if flags.NArg() > 0 {
+		docs, err = readDocuments(flags.Args())
+	} else {
+		listNamespace := namespace
+		if allNamespaces {
+			listNamespace = ""
+		}
+		docs, err = livePolicyDocuments(context.Background(), selected, listNamespace)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	imported, err := importDocuments(docs, selected, namespace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if outputDir != "" {
+		err = writeImportedFiles(outputDir, imported)
+	} else {
+		err = writeImported(os.Stdout, imported)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	incomplete := writeImportResults(os.Stderr, imported)
+	if incomplete {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// livePolicyDocuments lists the policies of the selected engines in the
+// namespace, or in every namespace when it is empty.
+func livePolicyDocuments(ctx context.Context, selected []string, namespace string) ([]document, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	k8sClient, err := client.New(cfg, client.Options{Scheme: engineScheme})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []document
+	for _, name := range selected {
+		kind := engines[name].policyKind
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+		if err := k8sClient.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s", kind.Kind)
+		}
+		for i := range list.Items {
+			docs = append(docs, document{file: "cluster", object: &list.Items[i]})
+		}
+	}
+	return docs, nil
+}
+
+// importDocuments converts the engine policies among the documents. Other
+// kinds, and policies that KubeAegis generated itself, are skipped with a note.
+func importDocuments(docs []document, selected []string, namespace string) ([]importedPolicy, error) {
+	var imported []importedPolicy
+	for _, doc := range docs {
+		e, ok := importEngine(doc.object, selected)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: skipping %s %s, which is not imported\n", doc.location(), doc.object.GetKind(), doc.object.GetName())
+			continue
+		}
+		if generatedByKubeAegis(doc.object) {
+			fmt.Fprintf(os.Stderr, "%s: skipping %s %s, which KubeAegis generated\n", doc.location(), doc.object.GetKind(), doc.object.GetName())
+			continue
+		}
+
+		typed, err := engineScheme.New(e.policyKind)
+		if err != nil {
+			return nil, err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(doc.object.Object, typed); err != nil {
+			return nil, fmt.Errorf("%s: %v", doc.location(), err)
+		}
+		object, ok := typed.(client.Object)
+		if !ok {
+			return nil, errors.Errorf("%s is not an object", e.policyKind.Kind)
+		}
+		if object.GetNamespace() == "" {
+			object.SetNamespace(namespace)
+		}
+
+		kaps, results, err := e.importPolicy(object, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", doc.location(), err)
+		}
+		imported = append(imported, importedPolicy{source: doc, kaps: kaps, results: results})
+	}
+	return imported, nil
+}
+
+func importEngine(object *unstructured.Unstructured, selected []string) (engine, bool) {
+	for _, name := range selected {
+		if engines[name].policyKind == object.GroupVersionKind() {
+			return engines[name], true
+		}
+	}
+	return engine{}, false
+}
+
+// generatedByKubeAegis reports whether the policy is owned by a
+// KubeAegisPolicy, or is a KubeArmorPolicy fanned out to another namespace.
+func generatedByKubeAegis(object *unstructured.Unstructured) bool {
+	for _, owner := range object.GetOwnerReferences() {
+		if owner.Kind == "KubeAegisPolicy" {
+			return true
+		}
+	}
+	_, ok := object.GetLabels()[kubearmorconverter.OriginPolicyLabel]
+	return ok
+}
+
+// writeImported prints the KubeAegisPolicies as a multi-document YAML stream,
+// each with a comment naming the policy it was imported from.
+func writeImported(w io.Writer, imported []importedPolicy) error {
+	first := true
+	for _, policy := range imported {
+		for _, kap := range policy.kaps {
+			data, err := marshalObject(kap)
+			if err != nil {
+				return errors.Wrapf(err, "failed to write %s", kap.Name)
+			}
+			if !first {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := fmt.Fprintf(w, "# Imported from: %s (%s %s)\n%s", policy.source.file,
+				policy.source.object.GetKind(), qualifiedName(policy.source.object), data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeImportedFiles writes each KubeAegisPolicy to <dir>/<namespace>/<name>.yaml.
+func writeImportedFiles(dir string, imported []importedPolicy) error {
+	for _, policy := range imported {
+		for _, kap := range policy.kaps {
+			path := filepath.Join(dir, kap.Namespace, kap.Name+".yaml")
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			data, err := marshalObject(kap)
+			if err != nil {
+				return errors.Wrapf(err, "failed to write %s", kap.Name)
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeImportResults prints the fields that were not imported, prefixed with
+// the engine policy they belong to, and a summary. It reports whether any
+// policy was imported incompletely.
+func writeImportResults(w io.Writer, imported []importedPolicy) (incomplete bool) {
+	written, fields := 0, 0
+	for _, policy := range imported {
+		prefix := fmt.Sprintf("%s: %s %s", policy.source.location(), policy.source.object.GetKind(), qualifiedName(policy.source.object))
+		if len(policy.kaps) == 0 {
+			fmt.Fprintf(w, "%s: nothing could be imported\n", prefix)
+			incomplete = true
+		}
+		for _, result := range policy.results.Sorted() {
+			fmt.Fprintf(w, "%s: %s\n", prefix, result)
+			incomplete = true
+		}
+		written += len(policy.kaps)
+		fields += len(policy.results)
+	}
+	fmt.Fprintf(w, "%d policies read, %d KubeAegisPolicies written, %d fields not imported\n", len(imported), written, fields)
+	return incomplete
+}
+
+// location is where the document was read from: its file and line, or the
+// cluster.
+func (d document) location() string {
+	if d.line == 0 {
+		return d.file
+	}
+	return fmt.Sprintf("%s:%d", d.file, d.line)
+}
+
+func importEngineNames() []string {
+	var names []string
+	for _, name := range engineNames() {
+		if engines[name].importPolicy != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+// clusterStateCodes are the codes that only report that the cluster lacks an
+// object or a property. Without any manifests there is nothing to compare the
+// policies with, so they are left out.
+var clusterStateCodes = map[validator.Code]bool{
+	validator.CodeSelectorNoMatch:    true,
+	validator.CodeNamespaceNotFound:  true,
+	validator.CodeNamespaceInactive:  true,
+	validator.CodeWorkloadNotFound:   true,
+	validator.CodePortNotListening:   true,
+	validator.CodePortsNotDeclared:   true,
+	validator.CodeLabelMissing:       true,
+	validator.CodeLabelMismatch:      true,
+	validator.CodeAnnotationMismatch: true,
+}
+
+// policyResult is the outcome of linting one KubeAegisPolicy.
+type policyResult struct {
+	loadedPolicy
+	results validator.ResultList
+}
+
+func runLint(args []string) int {
+	return lint(args, os.Stdout, os.Stderr)
+}
+
+// lint runs the lint command, writing the report to stdout and problems with
+// the input to stderr.
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeaegis lint [flags] <file or directory>...")
+		fmt.Fprintln(flags.Output(), "\nValidates KubeAegisPolicy files against the workloads of a manifests directory.")
+		flags.PrintDefaults()
+	}
+	var manifests, output, namespace, insecureRegistries string
+	var verifyImages, failOnWarnings bool
+	flags.StringVar(&manifests, "manifests", "", "A file or directory of Pods, Deployments, Services, Namespaces and other "+
+		"objects the policies are checked against. Without it, only checks that need no cluster state are reported.")
+	flags.StringVar(&output, "output", "text", "Output format: text, json or sarif.")
+	flags.StringVar(&namespace, "namespace", "default", "The namespace of policies and objects that do not set one.")
+	flags.BoolVar(&verifyImages, "verify-images", false, "Look up the images of verifyImage points in their registries.")
+	flags.StringVar(&insecureRegistries, "insecure-registries", "", "Comma-separated registry hosts that --verify-images reaches over plain HTTP.")
+	flags.BoolVar(&failOnWarnings, "fail-on-warnings", false, "Exit with 1 when there are warnings but no errors.")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	write, ok := reporters[output]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown output format %q\n", output)
+		return exitUsage
+	}
+
+	snap, err := loadSnapshot(flags.Args(), manifests, namespace)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	offline := len(snap.objects) == 0
+	k8sClient := snap.client()
+	validator.SetRegistryLookups(verifyImages)
+	if insecureRegistries != "" {
+		validator.SetInsecureRegistries(strings.Split(insecureRegistries, ","))
+	}
+
+	ctx := context.Background()
+	policies := make([]policyResult, 0, len(snap.policies))
+	for _, policy := range snap.policies {
+		result := policyResult{loadedPolicy: policy, results: policy.decodeResults}
+		if policy.kap != nil {
+			results, err := validator.KapValidator(ctx, k8sClient, logr.Discard(), policy.kap)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s:%d: %v\n", policy.file, policy.line, err)
+				return exitUsage
+			}
+			for _, r := range results {
+				if offline && clusterStateCodes[r.Code] {
+					continue
+				}
+				result.results = append(result.results, r)
+			}
+		}
+		policies = append(policies, result)
+	}
+
+	// Conflicts are only visible across the policies being linted.
+	var kaps []*v1.KubeAegisPolicy
+	var indexes []int
+	for i, policy := range policies {
+		if policy.kap != nil {
+			kaps = append(kaps, policy.kap)
+			indexes = append(indexes, i)
+		}
+	}
+	for k, results := range validator.ValidateConflicts(kaps) {
+		policies[indexes[k]].results = append(policies[indexes[k]].results, results...)
+	}
+
+	if err := write(stdout, policies); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	for _, policy := range policies {
+		if policy.results.HasErrors() || (failOnWarnings && len(policy.results) > 0) {
+			return exitFindings
+		}
+	}
+	return exitOK
+}
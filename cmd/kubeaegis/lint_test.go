@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestLintOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		golden string
+	}{
+		{output: "text", golden: "invalid.txt"},
+		{output: "json", golden: "invalid.json"},
+		{output: "sarif", golden: "invalid.sarif"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := lint([]string{"-output", tt.output, "testdata/lint/invalid.yaml", "testdata/lint/warnings.yaml"}, &stdout, &stderr)
+			if code != exitFindings {
+				t.Errorf("lint() = %d, want %d; stderr: %s", code, exitFindings, stderr.String())
+			}
+
+			golden := filepath.Join("testdata", "lint", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, stdout.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stdout.String(); got != string(want) {
+				t.Errorf("lint -output %s wrote\n%s\nwant\n%s", tt.output, got, want)
+			}
+		})
+	}
+}
+
+func TestLintExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantOutput string
+	}{
+		{
+			name: "selectors that match nothing offline",
+			args: []string{"testdata/lint/offline.yaml"},
+			want: exitOK,
+		},
+		{
+			name:       "selectors that match nothing in the manifests",
+			args:       []string{"-manifests", "testdata/lint/manifests.yaml", "testdata/lint/offline.yaml"},
+			want:       exitFindings,
+			wantOutput: "SelectorNoMatch",
+		},
+		{
+			name:       "warnings",
+			args:       []string{"testdata/lint/warnings.yaml"},
+			want:       exitOK,
+			wantOutput: "CIDRNotCanonical",
+		},
+		{
+			name: "warnings with -fail-on-warnings",
+			args: []string{"-fail-on-warnings", "testdata/lint/warnings.yaml"},
+			want: exitFindings,
+		},
+		{
+			name:       "errors",
+			args:       []string{"testdata/lint/invalid.yaml"},
+			want:       exitFindings,
+			wantOutput: "ExceptInvalid",
+		},
+		{
+			name: "no files",
+			want: exitUsage,
+		},
+		{
+			name: "unknown output format",
+			args: []string{"-output", "xml", "testdata/lint/warnings.yaml"},
+			want: exitUsage,
+		},
+		{
+			name: "missing file",
+			args: []string{"testdata/lint/missing.yaml"},
+			want: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := lint(tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("lint() = %d, want %d; stdout: %s; stderr: %s", got, tt.want, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("lint() wrote %q, want it to mention %s", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1.AddToScheme(scheme))
+}
+
+// manifestKinds are the kinds the validators and converters look up, and
+// whether they are namespaced. Manifests of other kinds are skipped.
+var manifestKinds = map[schema.GroupKind]bool{
+	{Kind: "Pod"}:                                     true,
+	{Kind: "Service"}:                                 true,
+	{Kind: "ServiceAccount"}:                          true,
+	{Kind: "Secret"}:                                  true,
+	{Kind: "ConfigMap"}:                               true,
+	{Kind: "Namespace"}:                               false,
+	{Kind: "Node"}:                                    false,
+	{Group: "apps", Kind: "Deployment"}:               true,
+	{Group: "apps", Kind: "StatefulSet"}:              true,
+	{Group: "apps", Kind: "DaemonSet"}:                true,
+	{Group: "apps", Kind: "ReplicaSet"}:               true,
+	{Group: "batch", Kind: "Job"}:                     true,
+	{Group: "networking.k8s.io", Kind: "ServiceCIDR"}: false,
+}
+
+// unknownField extracts the path from the unknown field errors of the
+// unstructured converter.
+var unknownField = regexp.MustCompile(`^unknown field "(.*)"$`)
+
+// document is one object read from an input file, together with the line its
+// YAML document starts on.
+type document struct {
+	file   string
+	line   int
+	object *unstructured.Unstructured
+}
+
+// loadedPolicy is a KubeAegisPolicy read from a file. kap is nil when the
+// document cannot be converted, and decodeResults tells why.
+type loadedPolicy struct {
+	file          string
+	line          int
+	namespace     string
+	name          string
+	kap           *v1.KubeAegisPolicy
+	decodeResults validator.ResultList
+}
+
+// snapshot is the policies and cluster objects read from the input files. It
+// stands in for the API server.
+type snapshot struct {
+	namespace string
+	policies  []loadedPolicy
+	objects   []client.Object
+}
+
+// loadSnapshot reads the policies and objects of the given files and of the
+// manifests file or directory. Policies and namespaced objects without a
+// namespace are put in namespace.
+func loadSnapshot(paths []string, manifests, namespace string) (*snapshot, error) {
+	docs, err := readDocuments(paths)
+	if err != nil {
+		return nil, err
+	}
+	if manifests != "" {
+		manifestDocs, err := readDocuments([]string{manifests})
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, manifestDocs...)
+	}
+
+	s := &snapshot{namespace: namespace}
+	for _, doc := range docs {
+		if doc.object.GroupVersionKind() != v1.GroupVersion.WithKind("KubeAegisPolicy") {
+			object, err := toTyped(doc, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if object != nil {
+				s.objects = append(s.objects, object)
+			}
+			continue
+		}
+
+		if doc.object.GetNamespace() == "" {
+			doc.object.SetNamespace(namespace)
+		}
+		kap, results := decodePolicy(doc)
+		s.policies = append(s.policies, loadedPolicy{
+			file:          doc.file,
+			line:          doc.line,
+			namespace:     doc.object.GetNamespace(),
+			name:          doc.object.GetName(),
+			kap:           kap,
+			decodeResults: results,
+		})
+	}
+	if len(s.policies) == 0 {
+		return nil, errors.New("no KubeAegisPolicy found")
+	}
+	return s, nil
+}
+
+// client returns a fake client serving the objects of the snapshot. The
+// namespaces of the policies and objects are created as active unless a
+// manifest declares them.
+func (s *snapshot) client() client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(append([]client.Object(nil), s.objects...), s.implicitNamespaces()...)...).
+		Build()
+}
+
+func (s *snapshot) implicitNamespaces() []client.Object {
+	declared := map[string]bool{}
+	for _, object := range s.objects {
+		if _, ok := object.(*corev1.Namespace); ok {
+			declared[object.GetName()] = true
+		}
+	}
+
+	names := []string{s.namespace}
+	for _, policy := range s.policies {
+		names = append(names, policy.namespace)
+	}
+	for _, object := range s.objects {
+		names = append(names, object.GetNamespace())
+	}
+
+	var namespaces []client.Object
+	for _, name := range names {
+		if name == "" || declared[name] {
+			continue
+		}
+		declared[name] = true
+		namespaces = append(namespaces, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		})
+	}
+	return namespaces
+}
+
+// readDocuments reads every object of the given files. Directories are walked
+// for .yaml, .yml and .json files, and "-" reads standard input. Lists are
+// expanded into their items.
+func readDocuments(paths []string) ([]document, error) {
+	files, err := expandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []document
+	for _, file := range files {
+		var data []byte
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", file)
+		}
+
+		fileDocs, err := parseDocuments(file, data)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+	}
+	return docs, nil
+}
+
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if path == "-" {
+			files = append(files, path)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var dirFiles []string
+		err = filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					dirFiles = append(dirFiles, file)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to walk %s", path)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// parseDocuments splits data at "---" lines and decodes each document.
+func parseDocuments(file string, data []byte) ([]document, error) {
+	var docs []document
+	var current bytes.Buffer
+	start, line := 1, 0
+
+	flush := func() error {
+		defer current.Reset()
+		if len(bytes.TrimSpace(current.Bytes())) == 0 {
+			return nil
+		}
+		objects, err := decodeDocument(current.Bytes())
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d", file, start)
+		}
+		for _, object := range objects {
+			docs = append(docs, document{file: file, line: start, object: object})
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.HasPrefix(text, "---") && strings.TrimSpace(strings.TrimLeft(text, "-")) == "" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			start = line + 1
+			continue
+		}
+		current.WriteString(text)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", file)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// decodeDocument decodes a YAML or JSON document into its objects. A document
+// holding only comments or null has none.
+func decodeDocument(data []byte) ([]*unstructured.Unstructured, error) {
+	jsonData, err := utilyaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(jsonData, &content); err != nil {
+		return nil, errors.Wrap(err, "document is not an object")
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	object := &unstructured.Unstructured{Object: content}
+	if object.GetKind() == "" || object.GetAPIVersion() == "" {
+		return nil, errors.New("document has no apiVersion or kind")
+	}
+	if !object.IsList() {
+		return []*unstructured.Unstructured{object}, nil
+	}
+
+	list, err := object.ToList()
+	if err != nil {
+		return nil, err
+	}
+	var objects []*unstructured.Unstructured
+	for i := range list.Items {
+		objects = append(objects, &list.Items[i])
+	}
+	return objects, nil
+}
+
+// decodePolicy converts a document into a KubeAegisPolicy. Unknown fields are
+// reported, and the policy is nil when the document cannot be converted.
+func decodePolicy(doc document) (*v1.KubeAegisPolicy, validator.ResultList) {
+	kap := &v1.KubeAegisPolicy{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(doc.object.Object, kap, true)
+	if err == nil {
+		return kap, nil
+	}
+
+	strictErr, ok := runtime.AsStrictDecodingError(err)
+	if !ok {
+		return nil, validator.ResultList{validator.Errorf(field.NewPath("spec"), validator.CodeFieldInvalid, "%v", err)}
+	}
+
+	var results validator.ResultList
+	for _, fieldErr := range strictErr.Errors() {
+		path := fieldErr.Error()
+		if match := unknownField.FindStringSubmatch(path); match != nil {
+			path = match[1]
+		}
+		results = append(results, validator.Result{
+			Field:    path,
+			Severity: validator.SeverityError,
+			Code:     validator.CodeFieldUnknown,
+			Message:  "unknown field",
+		})
+	}
+	return kap, results
+}
+
+// toTyped converts a manifest into a typed object, in the default namespace if
+// it is namespaced and sets none. Kinds that are not looked up are skipped.
+func toTyped(doc document, defaultNamespace string) (client.Object, error) {
+	gvk := doc.object.GroupVersionKind()
+	namespaced, ok := manifestKinds[gvk.GroupKind()]
+	if !ok || !scheme.Recognizes(gvk) {
+		fmt.Fprintf(os.Stderr, "%s:%d: skipping %s %s, which is not looked up\n", doc.file, doc.line, gvk.Kind, doc.object.GetName())
+		return nil, nil
+	}
+
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(doc.object.Object, typed); err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", doc.file, doc.line, err)
+	}
+	object, ok := typed.(client.Object)
+	if !ok {
+		return nil, nil
+	}
+
+	switch {
+	case !namespaced:
+		object.SetNamespace("")
+	case object.GetNamespace() == "":
+		object.SetNamespace(defaultNamespace)
+	}
+	object.SetResourceVersion("")
+
+	if namespace, ok := object.(*corev1.Namespace); ok && namespace.Status.Phase == "" {
+		namespace.Status.Phase = corev1.NamespaceActive
+	}
+	return object, nil
+}
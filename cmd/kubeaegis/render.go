@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/exporter"
+)
+
+// renderedObject is an engine policy converted from a KubeAegisPolicy.
+type renderedObject struct {
+	policy loadedPolicy
+	engine string
+	object client.Object
+}
+
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kubeaegis render [flags] <file or directory>...")
+		fmt.Fprintln(flags.Output(), "\nConverts KubeAegisPolicy files into the policies of each engine, as the adapters would.")
+		flags.PrintDefaults()
+	}
+	var manifests, namespace, engineList, outputDir string
+	flags.StringVar(&manifests, "manifests", "", "A file or directory of Pods, Deployments, Services, Namespaces and other "+
+		"objects that selectors are resolved against.")
+	flags.StringVar(&namespace, "namespace", "default", "The namespace of policies and objects that do not set one.")
+	flags.StringVar(&engineList, "engines", "cilium,kubearmor,kyverno", "Comma-separated engines to render for: "+
+		strings.Join(engineNames(), ", ")+".")
+	flags.StringVar(&outputDir, "output-dir", "", "Write one file per policy to <dir>/<engine>/ instead of standard output.")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	selected, err := parseEngines(engineList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	snap, err := loadSnapshot(flags.Args(), manifests, namespace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	objects, failed := renderSnapshot(context.Background(), snap, selected)
+
+	if outputDir != "" {
+		err = writeObjectFiles(outputDir, objects)
+	} else {
+		err = writeObjects(os.Stdout, objects)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if failed {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// renderSnapshot converts every policy of the snapshot for each selected
+// engine that the controller would dispatch it to. Policies that cannot be
+// converted are reported on standard error, and failed is set.
+func renderSnapshot(ctx context.Context, snap *snapshot, selected []string) (objects []renderedObject, failed bool) {
+	k8sClient := snap.client()
+	for _, policy := range snap.policies {
+		if policy.kap == nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s/%s cannot be rendered:\n%s", policy.file, policy.line, policy.namespace, policy.name, policy.decodeResults)
+			failed = true
+			continue
+		}
+
+		for _, name := range selected {
+			if !dispatchedTo(policy.kap, engines[name]) {
+				continue
+			}
+			converted, err := engines[name].convert(ctx, k8sClient, logr.Discard(), policy.kap.DeepCopy())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s:%d: %s/%s: %s: %v\n", policy.file, policy.line, policy.namespace, policy.name, name, err)
+				failed = true
+				continue
+			}
+			for _, object := range converted {
+				objects = append(objects, renderedObject{policy: policy, engine: name, object: object})
+			}
+		}
+	}
+	return objects, failed
+}
+
+// dispatchedTo reports whether the controller sends the policy to the adapter
+// of the engine, that is whether the engine supports its scope and one of its
+// intents.
+func dispatchedTo(kap *v1.KubeAegisPolicy, e engine) bool {
+	configs := map[string]exporter.AdapterConfig{"engine": {SupportedTypes: e.supportedTypes, Scopes: e.scopes}}
+	for _, intentRequest := range kap.Spec.IntentRequest {
+		if len(exporter.GetSupportedAdapters(configs, logr.Discard(), kap.Spec.Scope, intentRequest.Type, exporter.IntentSubType(intentRequest))) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEngines(list string) ([]string, error) {
+	var selected []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "kubeaegis-")
+		if name == "" {
+			continue
+		}
+		if _, ok := engines[name]; !ok {
+			return nil, errors.Errorf("unknown engine %q; must be one of %s", name, strings.Join(engineNames(), ", "))
+		}
+		selected = append(selected, name)
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("no engine selected")
+	}
+	return selected, nil
+}
+
+// marshalObject renders an engine policy as YAML, with its apiVersion and
+// kind set and without the fields only the API server fills in.
+func marshalObject(object client.Object) ([]byte, error) {
+	gvk, err := apiutil.GVKForObject(object, engineScheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	content["apiVersion"], content["kind"] = gvk.GroupVersion().String(), gvk.Kind
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	delete(content, "status")
+	return yaml.Marshal(content)
+}
+
+// writeObjects prints the objects as a multi-document YAML stream, each with
+// a comment naming the policy and engine it was rendered from.
+func writeObjects(w io.Writer, objects []renderedObject) error {
+	for i, rendered := range objects {
+		data, err := marshalObject(rendered.object)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s", rendered.object.GetName())
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# Source: %s (%s/%s), engine %s\n%s", rendered.policy.file,
+			rendered.policy.namespace, rendered.policy.name, rendered.engine, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeObjectFiles writes each object to <dir>/<engine>/<namespace>/<name>.yaml,
+// or <dir>/<engine>/<name>.yaml when it has no namespace.
+func writeObjectFiles(dir string, objects []renderedObject) error {
+	for _, rendered := range objects {
+		path := filepath.Join(dir, rendered.engine, rendered.object.GetNamespace(), rendered.object.GetName()+".yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		data, err := marshalObject(rendered.object)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s", rendered.object.GetName())
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func engineNames() []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
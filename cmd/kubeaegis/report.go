@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+// reporters write the lint results in each output format.
+var reporters = map[string]func(w io.Writer, policies []policyResult) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+// writeText prints one line per result, prefixed with the file, line and name
+// of its policy, and a summary.
+func writeText(w io.Writer, policies []policyResult) error {
+	var all validator.ResultList
+	for _, policy := range policies {
+		for _, result := range policy.results.Sorted() {
+			if _, err := fmt.Fprintf(w, "%s:%d: %s/%s: %s\n", policy.file, policy.line, policy.namespace, policy.name, result); err != nil {
+				return err
+			}
+		}
+		all = append(all, policy.results...)
+	}
+	_, err := fmt.Fprintf(w, "%d policies checked, %s\n", len(policies), all.Summary())
+	return err
+}
+
+type jsonPolicy struct {
+	File      string                `json:"file"`
+	Line      int                   `json:"line"`
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Valid     bool                  `json:"valid"`
+	Results   []v1.ValidationResult `json:"results"`
+}
+
+// writeJSON prints an array with the results of every policy, in the form of
+// status.validationResults.
+func writeJSON(w io.Writer, policies []policyResult) error {
+	out := make([]jsonPolicy, 0, len(policies))
+	for _, policy := range policies {
+		results := policy.results.Status()
+		if results == nil {
+			results = []v1.ValidationResult{}
+		}
+		out = append(out, jsonPolicy{
+			File:      policy.file,
+			Line:      policy.line,
+			Namespace: policy.namespace,
+			Name:      policy.name,
+			Valid:     !policy.results.HasErrors(),
+			Results:   results,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// SARIF 2.1.0, reduced to the properties code scanning tools read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// writeSARIF prints the results as a SARIF log. Each result points at the
+// start of its policy's YAML document, and its field path is given as the
+// logical location.
+func writeSARIF(w io.Writer, policies []policyResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "kubeaegis",
+			InformationURI: "https://github.com/cclab-inu/KubeAegis",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	codes := map[validator.Code]bool{}
+	for _, policy := range policies {
+		for _, result := range policy.results.Sorted() {
+			codes[result.Code] = true
+			level := "error"
+			if result.Severity == validator.SeverityWarning {
+				level = "warning"
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  string(result.Code),
+				Level:   level,
+				Message: sarifMessage{Text: result.Field + ": " + result.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(policy.file)},
+						Region:           sarifRegion{StartLine: policy.line},
+					},
+					LogicalLocations: []sarifLogicalLocation{{
+						FullyQualifiedName: policy.namespace + "/" + policy.name + "/" + result.Field,
+						Kind:               "member",
+					}},
+				}},
+			})
+		}
+	}
+
+	for code := range codes {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: string(code)})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
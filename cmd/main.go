@@ -26,11 +26,13 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -39,6 +41,7 @@ import (
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	"github.com/cclab-inu/KubeAegis/internal/controller"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
 	kyvernov1 "github.com/kyverno/kyverno/api/kyverno/v1"
 	// +kubebuilder:scaffold:imports
 )
@@ -65,6 +68,7 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var probeWorkloadPaths bool
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -83,6 +87,9 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&probeWorkloadPaths, "probe-workload-paths", false,
+		"If set, the paths of system intents are checked inside the selected pods' containers via pods/exec. "+
+			"Requires the path-prober-role from config/rbac.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -187,6 +194,11 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "81f1f4a9.cclab.kubeaegis.com",
+		// Pull secrets are read on demand by the image precondition, so they
+		// are not cached and only need the get verb.
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -204,6 +216,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if probeWorkloadPaths {
+		prober, err := validator.NewExecPathProber(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create path prober")
+			os.Exit(1)
+		}
+		validator.SetPathProber(prober)
+	}
+
 	if err = (&controller.KubeAegisPolicyReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -21,10 +21,14 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
@@ -43,6 +47,12 @@ type KubeAegisPolicyReconciler struct {
 // +kubebuilder:rbac:groups=cclab.kubeaegis.com,resources=kubeaegispolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cclab.kubeaegis.com,resources=kubeaegispolicies/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cclab.kubeaegis.com,resources=kubeaegispolicies/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets;replicasets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces;pods;serviceaccounts;services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=servicecidrs,verbs=list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -74,15 +84,19 @@ func (r *KubeAegisPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	}
 	logger.Info("KubeAegis found", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
 
-	validationErrors, err := validator.KapValidator(ctx, r.Client, logger, kap)
+	validationResults, err := validator.KapValidator(ctx, r.Client, logger, kap)
 	if err != nil {
 		return requeueWithError(err)
 	}
-	if len(validationErrors) > 0 {
-		logger.Info("Identified a misconfiguration of KubeAegis", "ValidationErrors", validationErrors)
+	if err := statusmanager.SetValidationResults(ctx, r.Client, kap.Name, kap.Namespace, validationResults.Status(), validationResults.HasErrors(), validationResults.Summary()); err != nil {
+		logger.Error(err, "failed to record validation results", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+		return requeueWithError(err)
+	}
+	if validationResults.HasErrors() {
+		logger.Info("Identified a misconfiguration of KubeAegis", "ValidationResults", validationResults.String())
 		return doNotRequeue()
 	}
-	logger.Info("KubeAegis verified", "validationErrors count", len(validationErrors))
+	logger.Info("KubeAegis verified", "validationResults", validationResults.Summary())
 
 	var configMap corev1.ConfigMap
 	if err := r.Get(ctx, client.ObjectKey{Name: "adapter-config", Namespace: "default"}, &configMap); err != nil {
@@ -110,8 +124,15 @@ func (r *KubeAegisPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 // SetupWithManager sets up the controller with the Manager.
 func (r *KubeAegisPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.KubeAegisPolicy{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		For(&v1.KubeAegisPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("Deployment")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("StatefulSet")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("DaemonSet")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&appsv1.ReplicaSet{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("ReplicaSet")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("Job")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("Service")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(r.policiesForWorkload("ServiceAccount")), builder.WithPredicates(podSelectorChanged)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.policiesForPod), builder.WithPredicates(podLabelsChanged)).
 		Complete(r)
 
 }
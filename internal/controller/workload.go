@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+// podSelectorOf returns the part of a workload that decides which pods it
+// selects, or nil for objects that are not workloads.
+func podSelectorOf(obj client.Object) interface{} {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return []interface{}{o.Spec.Selector, o.Spec.Template.Labels}
+	case *appsv1.StatefulSet:
+		return []interface{}{o.Spec.Selector, o.Spec.Template.Labels}
+	case *appsv1.DaemonSet:
+		return []interface{}{o.Spec.Selector, o.Spec.Template.Labels}
+	case *appsv1.ReplicaSet:
+		return []interface{}{o.Spec.Selector, o.Spec.Template.Labels}
+	case *batchv1.Job:
+		return []interface{}{o.Spec.Selector, o.Spec.Template.Labels}
+	case *corev1.Service:
+		return o.Spec.Selector
+	}
+	return nil
+}
+
+// podSelectorChanged passes workload creations and deletions, and updates that
+// change which pods the workload selects.
+var podSelectorChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !equality.Semantic.DeepEqual(podSelectorOf(e.ObjectOld), podSelectorOf(e.ObjectNew))
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+// podLabelsChanged passes pod creations and deletions, and updates that change
+// the labels of the pod.
+var podLabelsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !equality.Semantic.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+// policiesForWorkload maps a workload of the given kind to the KubeAegisPolicies
+// whose selectors name it, so they are dispatched again when it changes.
+func (r *KubeAegisPolicyReconciler) policiesForWorkload(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		logger := log.FromContext(ctx)
+
+		var kaps v1.KubeAegisPolicyList
+		if err := r.List(ctx, &kaps); err != nil {
+			logger.Error(err, "failed to list KubeAegisPolicies for workload", "Kind", kind, "Name", obj.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, kap := range kaps.Items {
+			if selectsWorkload(&kap, kind, obj.GetName(), obj.GetNamespace()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: kap.Name, Namespace: kap.Namespace},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+// policiesForPod maps a pod to the KubeAegisPolicies whose selectors name it
+// or the ServiceAccount it runs under. Both are resolved from the labels of
+// live pods, so they are dispatched again when those pods come, go or are
+// relabeled.
+func (r *KubeAegisPolicyReconciler) policiesForPod(ctx context.Context, obj client.Object) []reconcile.Request {
+	logger := log.FromContext(ctx)
+
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+
+	var kaps v1.KubeAegisPolicyList
+	if err := r.List(ctx, &kaps); err != nil {
+		logger.Error(err, "failed to list KubeAegisPolicies for pod", "Name", pod.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, kap := range kaps.Items {
+		if selectsWorkload(&kap, "Pod", pod.Name, pod.Namespace) || selectsWorkload(&kap, "ServiceAccount", pod.Spec.ServiceAccountName, pod.Namespace) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: kap.Name, Namespace: kap.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+func selectsWorkload(kap *v1.KubeAegisPolicy, kind, name, namespace string) bool {
+	for _, intentRequest := range kap.Spec.IntentRequest {
+		for _, match := range intentRequest.Selector.Match {
+			matchNamespace := match.Namespace
+			if matchNamespace == "" {
+				matchNamespace = kap.Namespace
+			}
+			if match.Kind == kind && match.Name != "" && match.Name == name && matchNamespace == namespace {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestPoliciesForPod(t *testing.T) {
+	kapSelecting := func(name, namespace string, match v1.Match) *v1.KubeAegisPolicy {
+		return &v1.KubeAegisPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: v1.KubeAegisPolicySpec{IntentRequest: []v1.IntentRequest{{
+				Type:     "network",
+				Selector: v1.Selector{Match: []v1.Match{match}},
+			}}},
+		}
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		kapSelecting("by-pod", "default", v1.Match{Kind: "Pod", Name: "web-0"}),
+		kapSelecting("by-sa", "default", v1.Match{Kind: "ServiceAccount", Name: "web"}),
+		kapSelecting("by-sa-elsewhere", "other", v1.Match{Kind: "ServiceAccount", Name: "web", Namespace: "default"}),
+		kapSelecting("by-labels", "default", v1.Match{Kind: "Pod", MatchLabels: map[string]string{"app": "web"}}),
+		kapSelecting("by-other-sa", "default", v1.Match{Kind: "ServiceAccount", Name: "api"}),
+	).Build()
+	r := &KubeAegisPolicyReconciler{Client: k8sClient, Scheme: scheme}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want []string
+	}{
+		{
+			name: "named pod under the serviceaccount",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "web"},
+			},
+			want: []string{"default/by-pod", "default/by-sa", "other/by-sa-elsewhere"},
+		},
+		{
+			name: "other pod under the serviceaccount",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "web"},
+			},
+			want: []string{"default/by-sa", "other/by-sa-elsewhere"},
+		},
+		{
+			name: "same names in another namespace",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "other"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "web"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, request := range r.policiesForPod(context.Background(), tt.pod) {
+				got = append(got, request.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policiesForPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodLabelsChanged(t *testing.T) {
+	old := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Labels: map[string]string{"app": "web"}}}
+	relabeled := old.DeepCopy()
+	relabeled.Labels["app"] = "api"
+	restarted := old.DeepCopy()
+	restarted.Status.Phase = corev1.PodRunning
+
+	if !podLabelsChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: relabeled}) {
+		t.Errorf("relabeled pod was filtered out")
+	}
+	if podLabelsChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: restarted}) {
+		t.Errorf("status update was passed")
+	}
+}
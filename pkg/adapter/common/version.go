@@ -0,0 +1,33 @@
+package common
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/cclab-inu/KubeAegis/api/grpc"
+)
+
+// AdapterInfo builds the GetInfo response advertised by an adapter.
+func AdapterInfo(adapterName, buildVersion string, supportedTypes map[string][]string) *pb.InfoResponse {
+	info := &pb.InfoResponse{
+		AdapterName:        adapterName,
+		BuildVersion:       buildVersion,
+		ProtocolVersion:    pb.ProtocolVersion,
+		MinProtocolVersion: pb.MinProtocolVersion,
+		SupportedTypes:     map[string]*pb.SubTypes{},
+	}
+	for intentType, subTypes := range supportedTypes {
+		info.SupportedTypes[intentType] = &pb.SubTypes{SubTypes: subTypes}
+	}
+	return info
+}
+
+// CheckProtocolVersion rejects requests sent by a controller whose protocol
+// version this adapter no longer understands.
+func CheckProtocolVersion(adapterName string, protocolVersion uint32) error {
+	if protocolVersion < pb.MinProtocolVersion {
+		return status.Errorf(codes.FailedPrecondition,
+			"%s requires PolicyService protocol version %d or newer, got %d", adapterName, pb.MinProtocolVersion, protocolVersion)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+)
+
+// Labels set on the generated policies, which are cluster-scoped and cannot
+// carry an owner reference to the namespaced KubeAegisPolicy.
+const (
+	OriginPolicyLabel    = "kubeaegis.cclab.com/policy"
+	OriginNamespaceLabel = "kubeaegis.cclab.com/namespace"
+)
+
+// BaselineName is the name of the BaselineAdminNetworkPolicy, of which a
+// cluster has at most one.
+const BaselineName = "default"
+
+// MaxPriority is the highest priority an AdminNetworkPolicy can have. It is
+// given to KubeAegisPolicies without a priority, which rank below the others.
+const MaxPriority = 1000
+
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"network": {"endpoint", "pod", "namespace", "cidr", "node", "port"},
+}
+
+// Scopes are the policy scopes the converter renders, as AdminNetworkPolicies
+// and the BaselineAdminNetworkPolicy respectively.
+var Scopes = []string{v1.ScopeCluster, v1.ScopeBaseline}
+
+// Converter returns the AdminNetworkPolicies of a Cluster scope
+// KubeAegisPolicy, one per subject, or the BaselineAdminNetworkPolicy of a
+// Baseline scope one. It returns nothing for namespaced KubeAegisPolicies.
+func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]*anpv1alpha1.AdminNetworkPolicy, *anpv1alpha1.BaselineAdminNetworkPolicy, error) {
+	switch kap.Spec.Scope {
+	case v1.ScopeCluster:
+		logger.Info("AdminNetworkPolicy started to transfer")
+		policies, err := convertAdmin(ctx, k8sClient, logger, kap)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Info("AdminNetworkPolicy converted", "Count", len(policies))
+		return policies, nil, nil
+	case v1.ScopeBaseline:
+		logger.Info("BaselineAdminNetworkPolicy started to transfer")
+		policy, err := convertBaseline(ctx, k8sClient, logger, kap)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Info("BaselineAdminNetworkPolicy converted")
+		return nil, policy, nil
+	default:
+		logger.Info("KubeAegisPolicy is namespaced, nothing to convert", "KubeAegis.Name", kap.Name)
+		return nil, nil, nil
+	}
+}
+
+func convertAdmin(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]*anpv1alpha1.AdminNetworkPolicy, error) {
+	priority := int32(MaxPriority)
+	if kap.Spec.Priority != nil {
+		priority = *kap.Spec.Priority
+	}
+	if priority > MaxPriority {
+		err := fmt.Errorf("priority %d is out of range; AdminNetworkPolicy priorities go up to %d", priority, MaxPriority)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, err
+	}
+
+	// Intents with the same subject share an AdminNetworkPolicy, whose rules
+	// keep the order of the intents.
+	var policies []*anpv1alpha1.AdminNetworkPolicy
+	policyFor := func(subject anpv1alpha1.AdminNetworkPolicySubject) *anpv1alpha1.AdminNetworkPolicy {
+		for _, policy := range policies {
+			if subjectKey(policy.Spec.Subject) == subjectKey(subject) {
+				return policy
+			}
+		}
+		policy := &anpv1alpha1.AdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   generateName(kap.Name, kap.Namespace, len(policies)),
+				Labels: originLabels(kap),
+			},
+			Spec: anpv1alpha1.AdminNetworkPolicySpec{
+				Priority: priority,
+				Subject:  subject,
+			},
+		}
+		policies = append(policies, policy)
+		return policy
+	}
+
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		if intentRequest.Type != "network" {
+			continue
+		}
+		if err := checkSupported(intentRequest); err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+
+		subjects, err := extractSubjects(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		if err != nil {
+			logger.Error(err, "failed to extract selector")
+			return nil, err
+		}
+		if len(subjects) == 0 {
+			continue
+		}
+
+		action, err := adminAction(intentRequest.Rule.Action)
+		if err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+		ingressRules, err := getIngress(intentRequest, action)
+		if err != nil {
+			logger.Error(err, "failed to convert ingress rules")
+			return nil, err
+		}
+		egressRules, err := getEgress(intentRequest, action)
+		if err != nil {
+			logger.Error(err, "failed to convert egress rules")
+			return nil, err
+		}
+
+		for _, subject := range subjects {
+			policy := policyFor(subject)
+			policy.Spec.Ingress = append(policy.Spec.Ingress, ingressRules...)
+			policy.Spec.Egress = append(policy.Spec.Egress, egressRules...)
+		}
+	}
+
+	return policies, nil
+}
+
+func convertBaseline(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*anpv1alpha1.BaselineAdminNetworkPolicy, error) {
+	var policy *anpv1alpha1.BaselineAdminNetworkPolicy
+
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		if intentRequest.Type != "network" {
+			continue
+		}
+		if err := checkSupported(intentRequest); err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+
+		subjects, err := extractSubjects(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		if err != nil {
+			logger.Error(err, "failed to extract selector")
+			return nil, err
+		}
+		if len(subjects) == 0 {
+			continue
+		}
+
+		// The BaselineAdminNetworkPolicy is a singleton with one subject, so
+		// every intent has to select the same pods.
+		if len(subjects) > 1 || (policy != nil && subjectKey(policy.Spec.Subject) != subjectKey(subjects[0])) {
+			err := fmt.Errorf("intentRequest[%d]: a BaselineAdminNetworkPolicy has a single subject; every intent must use the same selector", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+
+		action, err := baselineAction(intentRequest.Rule.Action)
+		if err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+		adminIngress, err := getIngress(intentRequest, anpv1alpha1.AdminNetworkPolicyRuleAction(action))
+		if err != nil {
+			logger.Error(err, "failed to convert ingress rules")
+			return nil, err
+		}
+		adminEgress, err := getEgress(intentRequest, anpv1alpha1.AdminNetworkPolicyRuleAction(action))
+		if err != nil {
+			logger.Error(err, "failed to convert egress rules")
+			return nil, err
+		}
+
+		if policy == nil {
+			policy = &anpv1alpha1.BaselineAdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   BaselineName,
+					Labels: originLabels(kap),
+				},
+				Spec: anpv1alpha1.BaselineAdminNetworkPolicySpec{Subject: subjects[0]},
+			}
+		}
+		for _, rule := range adminIngress {
+			policy.Spec.Ingress = append(policy.Spec.Ingress, anpv1alpha1.BaselineAdminNetworkPolicyIngressRule{
+				Action: action,
+				From:   rule.From,
+				Ports:  rule.Ports,
+			})
+		}
+		for _, rule := range adminEgress {
+			policy.Spec.Egress = append(policy.Spec.Egress, anpv1alpha1.BaselineAdminNetworkPolicyEgressRule{
+				Action: action,
+				To:     rule.To,
+				Ports:  rule.Ports,
+			})
+		}
+	}
+
+	return policy, nil
+}
+
+// checkSupported rejects the parts of a network intent that AdminNetworkPolicy
+// cannot express, which would otherwise be dropped from the policy.
+func checkSupported(intentRequest v1.IntentRequest) error {
+	for _, ap := range intentRequest.Rule.ActionPoint {
+		if ap.SubType == "http" {
+			return fmt.Errorf("http action points are not supported; AdminNetworkPolicy has no L7 rules")
+		}
+	}
+	return nil
+}
+
+// adminAction maps the action of an intent to an AdminNetworkPolicy action.
+// Block denies traffic, and Pass hands it over to the NetworkPolicies of the
+// namespace.
+func adminAction(action string) (anpv1alpha1.AdminNetworkPolicyRuleAction, error) {
+	switch action {
+	case "Allow":
+		return anpv1alpha1.AdminNetworkPolicyRuleActionAllow, nil
+	case "Block":
+		return anpv1alpha1.AdminNetworkPolicyRuleActionDeny, nil
+	case "Pass":
+		return anpv1alpha1.AdminNetworkPolicyRuleActionPass, nil
+	default:
+		return "", fmt.Errorf("action %s is not supported by AdminNetworkPolicy", action)
+	}
+}
+
+// baselineAction maps the action of an intent to a BaselineAdminNetworkPolicy
+// action, which has nothing to pass traffic on to.
+func baselineAction(action string) (anpv1alpha1.BaselineAdminNetworkPolicyRuleAction, error) {
+	switch action {
+	case "Allow":
+		return anpv1alpha1.BaselineAdminNetworkPolicyRuleActionAllow, nil
+	case "Block":
+		return anpv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny, nil
+	default:
+		return "", fmt.Errorf("action %s is not supported by BaselineAdminNetworkPolicy", action)
+	}
+}
+
+// extractSubjects compiles a Selector into subjects, one per alternative. Pods
+// are selected in the namespaces matching the namespaceSelector, or in every
+// namespace without one. It returns nothing for an empty Selector.
+func extractSubjects(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) ([]anpv1alpha1.AdminNetworkPolicySubject, error) {
+	nodes, err := processor.SelectsNodes(selector)
+	if err != nil {
+		return nil, err
+	}
+	if nodes {
+		return nil, fmt.Errorf("node selectors are not supported; AdminNetworkPolicy only selects pods")
+	}
+
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+	if compiled.IsEmpty() {
+		return nil, nil
+	}
+	if compiled.Resolved {
+		return nil, fmt.Errorf("CEL expressions %v are evaluated against the pods of namespace %s and cannot select pods cluster-wide", selector.CEL, namespace)
+	}
+
+	namespaceSelector := metav1.LabelSelector{}
+	if selector.NamespaceSelector != nil {
+		namespaceSelector = *selector.NamespaceSelector
+	}
+	if len(selector.Match) == 0 && len(selector.CEL) == 0 {
+		return []anpv1alpha1.AdminNetworkPolicySubject{{Namespaces: &namespaceSelector}}, nil
+	}
+
+	podSelectors, err := compiled.LabelSelectors()
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
+	subjects := make([]anpv1alpha1.AdminNetworkPolicySubject, 0, len(podSelectors))
+	for _, podSelector := range podSelectors {
+		subjects = append(subjects, anpv1alpha1.AdminNetworkPolicySubject{
+			Pods: &anpv1alpha1.NamespacedPod{NamespaceSelector: namespaceSelector, PodSelector: podSelector},
+		})
+	}
+	return subjects, nil
+}
+
+func subjectKey(subject anpv1alpha1.AdminNetworkPolicySubject) string {
+	if subject.Namespaces != nil {
+		return "namespaces:" + metav1.FormatLabelSelector(subject.Namespaces)
+	}
+	return "pods:" + metav1.FormatLabelSelector(&subject.Pods.NamespaceSelector) + ";" + metav1.FormatLabelSelector(&subject.Pods.PodSelector)
+}
+
+func originLabels(kap *v1.KubeAegisPolicy) map[string]string {
+	return map[string]string{
+		OriginPolicyLabel:    kap.Name,
+		OriginNamespaceLabel: kap.Namespace,
+	}
+}
+
+// generateName names the AdminNetworkPolicies of a KubeAegisPolicy after the
+// policy and its namespace. All but the first get their index as a suffix.
+func generateName(kapName, kapNamespace string, index int) string {
+	name := "anp-" + kapNamespace + "-" + kapName
+	if index == 0 {
+		return name
+	}
+	return name + "-" + strconv.Itoa(index)
+}
@@ -0,0 +1,250 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newPolicy(scope string, intents ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "guard", Namespace: "platform"},
+		Spec:       v1.KubeAegisPolicySpec{Scope: scope, IntentRequest: intents},
+	}
+}
+
+func networkIntent(action string, labels map[string]string, from, to []v1.NetPolDetail) v1.IntentRequest {
+	return v1.IntentRequest{
+		Type:     "network",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: labels}}},
+		Rule:     v1.Rule{Action: action, From: from, To: to},
+	}
+}
+
+func TestConverter(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	scanner := []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "scanner"}, Port: "22", Protocol: "TCP"}}
+	port22 := []anpv1alpha1.AdminNetworkPolicyPort{{PortNumber: &anpv1alpha1.Port{Protocol: "TCP", Port: 22}}}
+	webSubject := anpv1alpha1.AdminNetworkPolicySubject{Pods: &anpv1alpha1.NamespacedPod{PodSelector: metav1.LabelSelector{MatchLabels: web}}}
+	scannerPeer := anpv1alpha1.AdminNetworkPolicyIngressPeer{Pods: &anpv1alpha1.NamespacedPod{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "scanner"}}}}
+	labels := map[string]string{OriginPolicyLabel: "guard", OriginNamespaceLabel: "platform"}
+
+	tests := []struct {
+		name     string
+		kap      *v1.KubeAegisPolicy
+		admin    []*anpv1alpha1.AdminNetworkPolicy
+		baseline *anpv1alpha1.BaselineAdminNetworkPolicy
+		wantErr  bool
+	}{
+		{
+			name: "namespaced policies are not converted",
+			kap:  newPolicy("", networkIntent("Block", web, scanner, nil)),
+		},
+		{
+			name: "cluster policy",
+			kap:  newPolicy(v1.ScopeCluster, networkIntent("Block", web, scanner, nil)),
+			admin: []*anpv1alpha1.AdminNetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "anp-platform-guard", Labels: labels},
+				Spec: anpv1alpha1.AdminNetworkPolicySpec{
+					Priority: MaxPriority,
+					Subject:  webSubject,
+					Ingress: []anpv1alpha1.AdminNetworkPolicyIngressRule{{
+						Action: anpv1alpha1.AdminNetworkPolicyRuleActionDeny,
+						From:   []anpv1alpha1.AdminNetworkPolicyIngressPeer{scannerPeer},
+						Ports:  &port22,
+					}},
+				},
+			}},
+		},
+		{
+			name: "priority",
+			kap: func() *v1.KubeAegisPolicy {
+				kap := newPolicy(v1.ScopeCluster, networkIntent("Block", web, scanner, nil))
+				priority := int32(10)
+				kap.Spec.Priority = &priority
+				return kap
+			}(),
+			admin: []*anpv1alpha1.AdminNetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "anp-platform-guard", Labels: labels},
+				Spec: anpv1alpha1.AdminNetworkPolicySpec{
+					Priority: 10,
+					Subject:  webSubject,
+					Ingress: []anpv1alpha1.AdminNetworkPolicyIngressRule{{
+						Action: anpv1alpha1.AdminNetworkPolicyRuleActionDeny,
+						From:   []anpv1alpha1.AdminNetworkPolicyIngressPeer{scannerPeer},
+						Ports:  &port22,
+					}},
+				},
+			}},
+		},
+		{
+			name: "priority out of range",
+			kap: func() *v1.KubeAegisPolicy {
+				kap := newPolicy(v1.ScopeCluster, networkIntent("Block", web, scanner, nil))
+				priority := int32(MaxPriority + 1)
+				kap.Spec.Priority = &priority
+				return kap
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "intents with another selector get their own policy",
+			kap: newPolicy(v1.ScopeCluster,
+				networkIntent("Pass", web, nil, []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8"}}}),
+				networkIntent("Allow", map[string]string{"app": "api"}, nil, []v1.NetPolDetail{{Kind: "node"}}),
+			),
+			admin: []*anpv1alpha1.AdminNetworkPolicy{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "anp-platform-guard", Labels: labels},
+					Spec: anpv1alpha1.AdminNetworkPolicySpec{
+						Priority: MaxPriority,
+						Subject:  webSubject,
+						Egress: []anpv1alpha1.AdminNetworkPolicyEgressRule{{
+							Action: anpv1alpha1.AdminNetworkPolicyRuleActionPass,
+							To:     []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Networks: []anpv1alpha1.CIDR{"10.0.0.0/8"}}},
+						}},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "anp-platform-guard-1", Labels: labels},
+					Spec: anpv1alpha1.AdminNetworkPolicySpec{
+						Priority: MaxPriority,
+						Subject:  anpv1alpha1.AdminNetworkPolicySubject{Pods: &anpv1alpha1.NamespacedPod{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}}},
+						Egress: []anpv1alpha1.AdminNetworkPolicyEgressRule{{
+							Action: anpv1alpha1.AdminNetworkPolicyRuleActionAllow,
+							To:     []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Nodes: &metav1.LabelSelector{}}},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name: "baseline policy",
+			kap:  newPolicy(v1.ScopeBaseline, networkIntent("Block", web, scanner, nil)),
+			baseline: &anpv1alpha1.BaselineAdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: BaselineName, Labels: labels},
+				Spec: anpv1alpha1.BaselineAdminNetworkPolicySpec{
+					Subject: webSubject,
+					Ingress: []anpv1alpha1.BaselineAdminNetworkPolicyIngressRule{{
+						Action: anpv1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
+						From:   []anpv1alpha1.AdminNetworkPolicyIngressPeer{scannerPeer},
+						Ports:  &port22,
+					}},
+				},
+			},
+		},
+		{
+			name: "baseline policy with two selectors",
+			kap: newPolicy(v1.ScopeBaseline,
+				networkIntent("Block", web, scanner, nil),
+				networkIntent("Block", map[string]string{"app": "api"}, scanner, nil),
+			),
+			wantErr: true,
+		},
+		{
+			name:    "baseline policies cannot pass",
+			kap:     newPolicy(v1.ScopeBaseline, networkIntent("Pass", web, scanner, nil)),
+			wantErr: true,
+		},
+		{
+			name:    "cidr in from",
+			kap:     newPolicy(v1.ScopeCluster, networkIntent("Block", web, []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8"}}}, nil)),
+			wantErr: true,
+		},
+		{
+			name:    "fqdns",
+			kap:     newPolicy(v1.ScopeCluster, networkIntent("Allow", web, nil, []v1.NetPolDetail{{Kind: "fqdns", Args: []string{"example.com"}}})),
+			wantErr: true,
+		},
+		{
+			name: "http action points",
+			kap: func() *v1.KubeAegisPolicy {
+				intent := networkIntent("Allow", web, scanner, nil)
+				intent.Rule.ActionPoint = []v1.ActionPoint{{SubType: "http"}}
+				return newPolicy(v1.ScopeCluster, intent)
+			}(),
+			wantErr: true,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin, baseline, err := Converter(context.Background(), k8sClient, logr.Discard(), tt.kap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(admin, tt.admin) {
+				t.Errorf("AdminNetworkPolicies = %+v, want %+v", admin, tt.admin)
+			}
+			if !reflect.DeepEqual(baseline, tt.baseline) {
+				t.Errorf("BaselineAdminNetworkPolicy = %+v, want %+v", baseline, tt.baseline)
+			}
+		})
+	}
+}
+
+func TestToPorts(t *testing.T) {
+	named := "http"
+	tests := []struct {
+		name    string
+		rule    v1.NetPolDetail
+		want    *[]anpv1alpha1.AdminNetworkPolicyPort
+		wantErr bool
+	}{
+		{
+			name: "peer without a port",
+			rule: v1.NetPolDetail{Kind: "endpoint"},
+		},
+		{
+			name:    "port rule without a port",
+			rule:    v1.NetPolDetail{Kind: "port"},
+			wantErr: true,
+		},
+		{
+			name: "number, range and name",
+			rule: v1.NetPolDetail{Kind: "port", Port: "53,8000-8080,http", Protocol: "UDP"},
+			want: &[]anpv1alpha1.AdminNetworkPolicyPort{
+				{PortNumber: &anpv1alpha1.Port{Protocol: "UDP", Port: 53}},
+				{PortRange: &anpv1alpha1.PortRange{Protocol: "UDP", Start: 8000, End: 8080}},
+				{NamedPort: &named},
+			},
+		},
+		{
+			name: "protocol defaults to TCP",
+			rule: v1.NetPolDetail{Kind: "port", Port: "443"},
+			want: &[]anpv1alpha1.AdminNetworkPolicyPort{{PortNumber: &anpv1alpha1.Port{Protocol: "TCP", Port: 443}}},
+		},
+		{
+			name:    "ICMP",
+			rule:    v1.NetPolDetail{Kind: "port", Port: "80", Protocol: "ICMP"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toPorts(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
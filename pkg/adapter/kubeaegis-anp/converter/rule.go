@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+)
+
+// anyNetwork matches every address, for egress port rules without a peer.
+var anyNetwork = []anpv1alpha1.CIDR{"0.0.0.0/0", "::/0"}
+
+// getIngress generates one ingress rule per from entry, all with action. A
+// port entry applies to its ports from every pod in the cluster, and a peer
+// without a port to all ports.
+func getIngress(intentRequest v1.IntentRequest, action anpv1alpha1.AdminNetworkPolicyRuleAction) ([]anpv1alpha1.AdminNetworkPolicyIngressRule, error) {
+	var ingressRules []anpv1alpha1.AdminNetworkPolicyIngressRule
+	for _, from := range intentRequest.Rule.From {
+		peers, err := toIngressPeers(from)
+		if err != nil {
+			return nil, err
+		}
+		ports, err := toPorts(from)
+		if err != nil {
+			return nil, err
+		}
+		ingressRules = append(ingressRules, anpv1alpha1.AdminNetworkPolicyIngressRule{Action: action, From: peers, Ports: ports})
+	}
+	return ingressRules, nil
+}
+
+// getEgress generates one egress rule per to entry, in the same way as
+// getIngress. A port entry applies to its ports on every address.
+func getEgress(intentRequest v1.IntentRequest, action anpv1alpha1.AdminNetworkPolicyRuleAction) ([]anpv1alpha1.AdminNetworkPolicyEgressRule, error) {
+	var egressRules []anpv1alpha1.AdminNetworkPolicyEgressRule
+	for _, to := range intentRequest.Rule.To {
+		peers, err := toEgressPeers(to)
+		if err != nil {
+			return nil, err
+		}
+		ports, err := toPorts(to)
+		if err != nil {
+			return nil, err
+		}
+		egressRules = append(egressRules, anpv1alpha1.AdminNetworkPolicyEgressRule{Action: action, To: peers, Ports: ports})
+	}
+	return egressRules, nil
+}
+
+// toIngressPeers converts a from entry into ingress peers, which can only be
+// pods and namespaces.
+func toIngressPeers(rule v1.NetPolDetail) ([]anpv1alpha1.AdminNetworkPolicyIngressPeer, error) {
+	switch rule.Kind {
+	case "port":
+		return []anpv1alpha1.AdminNetworkPolicyIngressPeer{{Namespaces: &metav1.LabelSelector{}}}, nil
+	case "cidr", "node":
+		return nil, fmt.Errorf("kind %s is only supported in to rules; AdminNetworkPolicy ingress peers are pods and namespaces", rule.Kind)
+	}
+
+	egressPeers, err := toEgressPeers(rule)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]anpv1alpha1.AdminNetworkPolicyIngressPeer, 0, len(egressPeers))
+	for _, peer := range egressPeers {
+		peers = append(peers, anpv1alpha1.AdminNetworkPolicyIngressPeer{Namespaces: peer.Namespaces, Pods: peer.Pods})
+	}
+	return peers, nil
+}
+
+// toEgressPeers converts a to entry into egress peers. Pods are selected in
+// every namespace, and namespaces by their labels or, with args, by name.
+func toEgressPeers(rule v1.NetPolDetail) ([]anpv1alpha1.AdminNetworkPolicyEgressPeer, error) {
+	switch rule.Kind {
+	case "endpoint", "pod":
+		return []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Pods: &anpv1alpha1.NamespacedPod{
+			PodSelector: metav1.LabelSelector{MatchLabels: rule.Labels},
+		}}}, nil
+	case "namespace":
+		namespaceSelector := &metav1.LabelSelector{MatchLabels: rule.Labels}
+		if len(rule.Args) > 0 {
+			namespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   rule.Args,
+			}}
+		}
+		return []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Namespaces: namespaceSelector}}, nil
+	case "cidr":
+		if len(rule.Args) == 0 {
+			return nil, fmt.Errorf("cidr rule lists no CIDRs")
+		}
+		if len(rule.Except) > 0 {
+			return nil, fmt.Errorf("except is not supported; AdminNetworkPolicy networks cannot exclude ranges")
+		}
+		networks := make([]anpv1alpha1.CIDR, 0, len(rule.Args))
+		for _, cidr := range rule.Args {
+			networks = append(networks, anpv1alpha1.CIDR(cidr))
+		}
+		return []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Networks: networks}}, nil
+	case "node":
+		return []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Nodes: &metav1.LabelSelector{MatchLabels: rule.Labels}}}, nil
+	case "port":
+		return []anpv1alpha1.AdminNetworkPolicyEgressPeer{{Networks: anyNetwork}}, nil
+	case "fqdn", "fqdns":
+		return nil, fmt.Errorf("kind %s is not supported; AdminNetworkPolicy cannot match DNS names", rule.Kind)
+	default:
+		return nil, fmt.Errorf("kind %s is not supported by AdminNetworkPolicy", rule.Kind)
+	}
+}
+
+// toPorts converts the port field of a rule into AdminNetworkPolicy ports. It
+// returns nil for a rule without a port.
+func toPorts(rule v1.NetPolDetail) (*[]anpv1alpha1.AdminNetworkPolicyPort, error) {
+	if rule.Port == "" {
+		if rule.Kind == "port" {
+			return nil, fmt.Errorf("port rule has no port")
+		}
+		return nil, nil
+	}
+
+	protocol := corev1.Protocol(rule.Protocol)
+	switch protocol {
+	case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+	case "":
+		protocol = corev1.ProtocolTCP
+	default:
+		return nil, fmt.Errorf("protocol %s is not supported; AdminNetworkPolicy only matches TCP, UDP and SCTP", rule.Protocol)
+	}
+
+	specs, err := processor.ParsePorts(rule.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]anpv1alpha1.AdminNetworkPolicyPort, 0, len(specs))
+	for _, spec := range specs {
+		switch {
+		case spec.Name != "":
+			name := spec.Name
+			ports = append(ports, anpv1alpha1.AdminNetworkPolicyPort{NamedPort: &name})
+		case spec.IsRange():
+			ports = append(ports, anpv1alpha1.AdminNetworkPolicyPort{PortRange: &anpv1alpha1.PortRange{
+				Protocol: protocol,
+				Start:    spec.Port,
+				End:      spec.EndPort,
+			}})
+		default:
+			ports = append(ports, anpv1alpha1.AdminNetworkPolicyPort{PortNumber: &anpv1alpha1.Port{
+				Protocol: protocol,
+				Port:     spec.Port,
+			}})
+		}
+	}
+	return &ports, nil
+}
@@ -0,0 +1,145 @@
+package enforcer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-anp/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
+)
+
+func EnforceAdmin(ctx context.Context, k8sClient client.Client, logger logr.Logger, anp *anpv1alpha1.AdminNetworkPolicy, kap *v1.KubeAegisPolicy) (string, error) {
+	existingPolicy := &anpv1alpha1.AdminNetworkPolicy{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: anp.Name}, existingPolicy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to fetch AdminNetworkPolicy", "Policy.Name", anp.Name)
+		return "", err
+	}
+
+	if apierrors.IsNotFound(err) {
+		logger.Info("AdminNetworkPolicy enforced", "Policy.Name", anp.Name)
+		if err := k8sClient.Create(ctx, anp); err != nil {
+			logger.Error(err, "failed to create AdminNetworkPolicy", "Policy.Name", anp.Name)
+			return "", err
+		}
+	} else {
+		logger.Info("AdminNetworkPolicy updated", "Policy.Name", anp.Name)
+		before := existingPolicy.DeepCopy()
+		existingPolicy.Labels = anp.Labels
+		existingPolicy.Spec = anp.Spec
+		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
+			logger.Error(err, "failed to update AdminNetworkPolicy", "Policy.Name", anp.Name)
+			return "", err
+		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "AdminNetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to AdminNetworkPolicy", "Policy.Name", anp.Name)
+		}
+	}
+
+	return anp.Name, nil
+}
+
+// EnforceBaseline creates or updates the BaselineAdminNetworkPolicy. It
+// refuses to replace one generated from another KubeAegisPolicy or created
+// outside KubeAegis.
+func EnforceBaseline(ctx context.Context, k8sClient client.Client, logger logr.Logger, banp *anpv1alpha1.BaselineAdminNetworkPolicy, kap *v1.KubeAegisPolicy) (string, error) {
+	existingPolicy := &anpv1alpha1.BaselineAdminNetworkPolicy{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: banp.Name}, existingPolicy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to fetch BaselineAdminNetworkPolicy", "Policy.Name", banp.Name)
+		return "", err
+	}
+
+	if apierrors.IsNotFound(err) {
+		logger.Info("BaselineAdminNetworkPolicy enforced", "Policy.Name", banp.Name)
+		if err := k8sClient.Create(ctx, banp); err != nil {
+			logger.Error(err, "failed to create BaselineAdminNetworkPolicy", "Policy.Name", banp.Name)
+			return "", err
+		}
+		return banp.Name, nil
+	}
+
+	if !originatesFrom(existingPolicy.Labels, kap.Name, kap.Namespace) {
+		err := fmt.Errorf("BaselineAdminNetworkPolicy %s is not managed by KubeAegisPolicy %s/%s", banp.Name, kap.Namespace, kap.Name)
+		if owner, ok := existingPolicy.Labels[converter.OriginPolicyLabel]; ok {
+			err = fmt.Errorf("BaselineAdminNetworkPolicy %s is managed by KubeAegisPolicy %s/%s", banp.Name, existingPolicy.Labels[converter.OriginNamespaceLabel], owner)
+		}
+		logger.Error(err, "failed to update BaselineAdminNetworkPolicy", "Policy.Name", banp.Name)
+		return "", err
+	}
+
+	logger.Info("BaselineAdminNetworkPolicy updated", "Policy.Name", banp.Name)
+	before := existingPolicy.DeepCopy()
+	existingPolicy.Spec = banp.Spec
+	if err := k8sClient.Update(ctx, existingPolicy); err != nil {
+		logger.Error(err, "failed to update BaselineAdminNetworkPolicy", "Policy.Name", banp.Name)
+		return "", err
+	}
+	if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "BaselineAdminNetworkPolicy", before, existingPolicy); err != nil {
+		logger.Error(err, "failed to record the changes to BaselineAdminNetworkPolicy", "Policy.Name", banp.Name)
+	}
+
+	return banp.Name, nil
+}
+
+// PruneAdmin deletes the AdminNetworkPolicies labeled with the
+// KubeAegisPolicy that are not in keep.
+func PruneAdmin(ctx context.Context, k8sClient client.Client, logger logr.Logger, kapName, kapNamespace string, keep []string) error {
+	var policies anpv1alpha1.AdminNetworkPolicyList
+	if err := k8sClient.List(ctx, &policies, client.MatchingLabels{
+		converter.OriginPolicyLabel:    kapName,
+		converter.OriginNamespaceLabel: kapNamespace,
+	}); err != nil {
+		logger.Error(err, "failed to list AdminNetworkPolicies", "KubeAegis.Name", kapName)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(policy.Name) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete AdminNetworkPolicy", "Policy.Name", policy.Name)
+			return err
+		}
+		logger.Info("AdminNetworkPolicy deleted", "Policy.Name", policy.Name)
+	}
+	return nil
+}
+
+// RemoveBaseline deletes the BaselineAdminNetworkPolicy if it was generated
+// from the KubeAegisPolicy.
+func RemoveBaseline(ctx context.Context, k8sClient client.Client, logger logr.Logger, kapName, kapNamespace string) error {
+	policy := &anpv1alpha1.BaselineAdminNetworkPolicy{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: converter.BaselineName}, policy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "failed to fetch BaselineAdminNetworkPolicy", "Policy.Name", converter.BaselineName)
+		return err
+	}
+	if !originatesFrom(policy.Labels, kapName, kapNamespace) {
+		return nil
+	}
+
+	if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to delete BaselineAdminNetworkPolicy", "Policy.Name", policy.Name)
+		return err
+	}
+	logger.Info("BaselineAdminNetworkPolicy deleted", "Policy.Name", policy.Name)
+	return nil
+}
+
+func originatesFrom(labels map[string]string, kapName, kapNamespace string) bool {
+	return labels[converter.OriginPolicyLabel] == kapName && labels[converter.OriginNamespaceLabel] == kapNamespace
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	anpv1alpha1 "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/k8s"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-anp/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-anp/enforcer"
+	watcher "github.com/cclab-inu/KubeAegis/pkg/adapter/watcher"
+	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
+)
+
+var (
+	scheme    = runtime.NewScheme()
+	k8sClient client.Client
+)
+
+func init() {
+	utilruntime.Must(v1.AddToScheme(scheme))
+	utilruntime.Must(anpv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	k8sClient = k8s.NewOrDie(scheme)
+}
+
+func Run(ctx context.Context, logger logr.Logger, KapName string, KapNamespace string) (string, error) {
+	kap, err := watcher.GetKubeAegisPolicy(ctx, k8sClient, KapName, KapNamespace)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("KubeAegisPolicy fetched", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+
+	anps, banp, err := converter.Converter(ctx, k8sClient, logger, kap)
+	if err != nil {
+		return "", err
+	}
+
+	var policyNames []string
+	for _, anp := range anps {
+		policyName, err := enforcer.EnforceAdmin(ctx, k8sClient, logger, anp, kap)
+		if err != nil {
+			return "", err
+		}
+		policyNames = append(policyNames, policyName)
+	}
+	if banp != nil {
+		policyName, err := enforcer.EnforceBaseline(ctx, k8sClient, logger, banp, kap)
+		if err != nil {
+			return "", err
+		}
+		policyNames = append(policyNames, policyName)
+	}
+
+	// The scope or the selectors of the KubeAegisPolicy may have changed
+	// since it was last converted.
+	if err := enforcer.PruneAdmin(ctx, k8sClient, logger, kap.Name, kap.Namespace, policyNames); err != nil {
+		return "", err
+	}
+	if banp == nil {
+		if err := enforcer.RemoveBaseline(ctx, k8sClient, logger, kap.Name, kap.Namespace); err != nil {
+			return "", err
+		}
+	}
+
+	for _, policyName := range policyNames {
+		if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, policyName, KapName, KapNamespace); err != nil {
+			logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+			return "", err
+		}
+	}
+
+	if len(policyNames) == 0 {
+		return "", nil
+	}
+	return policyNames[0], nil
+}
+
+// Cleanup deletes the AdminNetworkPolicies and the BaselineAdminNetworkPolicy
+// of a deleted KubeAegisPolicy. The controller names the deleted policy after
+// its KubeArmorPolicy.
+func Cleanup(ctx context.Context, logger logr.Logger, policyName string, kapNamespace string) error {
+	kapName := strings.TrimPrefix(policyName, "ksp-")
+	if err := enforcer.PruneAdmin(ctx, k8sClient, logger, kapName, kapNamespace, nil); err != nil {
+		return err
+	}
+	return enforcer.RemoveBaseline(ctx, k8sClient, logger, kapName, kapNamespace)
+}
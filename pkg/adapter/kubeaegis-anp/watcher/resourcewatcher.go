@@ -0,0 +1,60 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var (
+	factory dynamicinformer.DynamicSharedInformerFactory
+)
+
+func init() {
+	k8sClient, err := dynamic.NewForConfig(ctrl.GetConfigOrDie())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	factory = dynamicinformer.NewDynamicSharedInformerFactory(k8sClient, time.Minute)
+}
+
+func policyInformer(resource string) cache.SharedIndexInformer {
+	PolicyGvr := schema.GroupVersionResource{
+		Group:    "policy.networking.k8s.io",
+		Version:  "v1alpha1",
+		Resource: resource,
+	}
+	informer := factory.ForResource(PolicyGvr).Informer()
+	return informer
+}
+
+// WatchRealPolicy watches for AdminNetworkPolicy and BaselineAdminNetworkPolicy
+// events and logs deletions.
+func WatchRealPolicy(ctx context.Context, logger logr.Logger) {
+	for _, resource := range []string{"adminnetworkpolicies", "baselineadminnetworkpolicies"} {
+		informer := policyInformer(resource)
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			DeleteFunc: func(obj interface{}) {
+				policy, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					logger.Error(nil, "Could not cast to "+resource, "obj", obj)
+					return
+				}
+				logger.Info(policy.GetKind()+" deleted", "Policy.Name", policy.GetName())
+			},
+		})
+		go informer.Run(ctx.Done())
+	}
+
+	logger.Info("Starting AdminNetworkPolicy watcher")
+	<-ctx.Done()
+}
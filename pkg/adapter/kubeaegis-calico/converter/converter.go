@@ -3,6 +3,7 @@ package converter
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -11,66 +12,261 @@ import (
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+	recommendpool "github.com/cclab-inu/KubeAegis/pkg/recommandpool"
 
 	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 )
 
-func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*calico.NetworkPolicy, error) {
-	logger.Info("NetworkPolicy started to transfer")
+// Labels set on GlobalNetworkPolicies, which cannot carry an owner reference
+// to the namespaced KubeAegisPolicy they were generated from.
+const (
+	OriginPolicyLabel    = "kubeaegis.cclab.com/policy"
+	OriginNamespaceLabel = "kubeaegis.cclab.com/namespace"
+)
+
+// policyRules are the rules of the intents that share an endpoint selector.
+type policyRules struct {
+	selector string
+	ingress  []calico.Rule
+	egress   []calico.Rule
+}
+
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"network": {"pod", "namespace", "serviceAccounts", "cidr", "protocol", "icmp", "port"},
+}
+
+// Scopes are the policy scopes the converter renders, as NetworkPolicies and
+// GlobalNetworkPolicies respectively.
+var Scopes = []string{v1.ScopeNamespace, v1.ScopeCluster}
 
-	policy := &calico.NetworkPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      generateName(kap.Name),
-			Namespace: kap.Namespace,
-		},
-		Spec: calico.NetworkPolicySpec{},
+// Converter returns one Calico policy per endpoint selector of the network
+// intents of a KubeAegisPolicy: NetworkPolicies for a namespaced policy, and
+// GlobalNetworkPolicies for a Cluster scope one. Intents Calico cannot express
+// in full are rejected instead of being left out.
+func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]*calico.NetworkPolicy, []*calico.GlobalNetworkPolicy, error) {
+	global := kap.Spec.Scope == v1.ScopeCluster
+	if kap.Spec.Scope == v1.ScopeBaseline {
+		err := fmt.Errorf("scope %s is not supported; Calico cannot order a policy after the namespaces' own policies", kap.Spec.Scope)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, nil, err
+	}
+	if global {
+		logger.Info("GlobalNetworkPolicy started to transfer")
+	} else {
+		logger.Info("NetworkPolicy started to transfer")
 	}
 
-	// Assuming that there might be multiple IntentRequests, we iterate through them.
-	for _, intentRequest := range kap.Spec.IntentRequest {
-		matchLabels, err := extractSelector(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+	// Intents with the same selector share a policy, whose rules keep the
+	// order of the intents.
+	var policies []*policyRules
+	policyFor := func(selector string) *policyRules {
+		for _, policy := range policies {
+			if policy.selector == selector {
+				return policy
+			}
+		}
+		policy := &policyRules{selector: selector}
+		policies = append(policies, policy)
+		return policy
+	}
+
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		if intentRequest.Type != "network" {
+			continue
+		}
+
+		selectors, err := extractSelector(ctx, k8sClient, kap.Namespace, global, intentRequest.Selector)
 		if err != nil {
 			logger.Error(err, "failed to extract selector")
-			return nil, err
+			return nil, nil, err
 		}
-		if len(matchLabels) == 0 {
+		if len(selectors) == 0 {
 			continue
 		}
-	}
-	logger.Info("NetworkPolicy converted")
-	return policy, nil
-}
-
-// extractSelector extracts match labels from a Selector.
-func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
-	matchLabels := make(map[string]string) // Initialize map for match labels.
 
-	// Process CEL expressions.
-	if len(selector.CEL) > 0 {
-		celExpressions := selector.CEL
-		celMatchLabels, err := processor.ProcessCEL(ctx, k8sClient, namespace, celExpressions)
+		action, err := toAction(intentRequest.Rule.Action)
+		if err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, nil, err
+		}
+		httpRules, err := getHTTPRules(intentRequest, action)
+		if err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to generate HTTP rules")
+			return nil, nil, err
+		}
+		ingressRules, err := getIngressRules(intentRequest, action, httpRules)
+		if err != nil {
+			logger.Error(err, "failed to generate ingress rules")
+			return nil, nil, err
+		}
+		egressRules, err := getEgressRules(intentRequest, action)
 		if err != nil {
-			return nil, fmt.Errorf("error processing CEL: %v", err)
+			logger.Error(err, "failed to generate egress rules")
+			return nil, nil, err
 		}
-		for k, v := range celMatchLabels {
-			matchLabels[k] = v
+
+		for _, selector := range selectors {
+			policy := policyFor(selector)
+			policy.ingress = append(policy.ingress, ingressRules...)
+			policy.egress = append(policy.egress, egressRules...)
 		}
 	}
 
-	// Process Match fields.
-	if len(selector.Match) > 0 {
-		processedMatchLabels, err := processor.ProcessMatchLabels(selector.Match)
-		if err != nil {
-			return nil, errors.Wrap(err, "error processing matchLabels")
+	var order *float64
+	if kap.Spec.Priority != nil {
+		o := float64(*kap.Spec.Priority)
+		order = &o
+	}
+
+	if global {
+		globalPolicies := make([]*calico.GlobalNetworkPolicy, 0, len(policies))
+		for i, policy := range policies {
+			globalPolicies = append(globalPolicies, &calico.GlobalNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: generateGlobalName(kap.Name, kap.Namespace, i),
+					Labels: map[string]string{
+						OriginPolicyLabel:    kap.Name,
+						OriginNamespaceLabel: kap.Namespace,
+					},
+				},
+				Spec: calico.GlobalNetworkPolicySpec{
+					Order:    order,
+					Selector: policy.selector,
+					Types:    policyTypes(policy),
+					Ingress:  policy.ingress,
+					Egress:   policy.egress,
+				},
+			})
 		}
-		for key, value := range processedMatchLabels {
-			matchLabels[key] = value
+		logger.Info("GlobalNetworkPolicy converted", "Count", len(globalPolicies))
+		return nil, globalPolicies, nil
+	}
+
+	networkPolicies := make([]*calico.NetworkPolicy, 0, len(policies))
+	for i, policy := range policies {
+		networkPolicies = append(networkPolicies, &calico.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateName(kap.Name, i),
+				Namespace: kap.Namespace,
+			},
+			Spec: calico.NetworkPolicySpec{
+				Order:    order,
+				Selector: policy.selector,
+				Types:    policyTypes(policy),
+				Ingress:  policy.ingress,
+				Egress:   policy.egress,
+			},
+		})
+	}
+	logger.Info("NetworkPolicy converted", "Count", len(networkPolicies))
+	return networkPolicies, nil, nil
+}
+
+// extractSelector compiles a Selector into Calico selector expressions, one
+// per alternative. It returns nothing for an empty Selector. Global policies
+// select endpoints in every namespace, so their selectors cannot depend on
+// the pods of the policy's namespace.
+func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, global bool, selector v1.Selector) ([]string, error) {
+	nodes, err := processor.SelectsNodes(selector)
+	if err != nil {
+		return nil, err
+	}
+	if nodes {
+		return nil, fmt.Errorf("node selectors are not supported by the Calico adapter")
+	}
+
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+	if compiled.IsEmpty() {
+		return nil, nil
+	}
+	if global && compiled.Resolved {
+		return nil, fmt.Errorf("CEL expressions %v are evaluated against the pods of namespace %s and cannot select endpoints cluster-wide", selector.CEL, namespace)
+	}
+
+	// Calico endpoints inherit the labels of their namespace with a pcns. prefix.
+	compiled = compiled.WithNamespaceLabels(selector.NamespaceSelector, processor.CalicoNamespaceLabelPrefix)
+
+	selectors := make([]string, 0, len(compiled.Terms))
+	for _, term := range compiled.Terms {
+		alternative := *compiled
+		alternative.Terms = []processor.Term{term}
+		rendered, err := alternative.CalicoSelector()
+		if err != nil {
+			return nil, err
 		}
+		selectors = append(selectors, rendered)
 	}
+	return selectors, nil
+}
 
-	return matchLabels, nil
+// toAction maps the action of an intent to a Calico action. Log rules only
+// log the traffic and leave the decision to the rules after them.
+func toAction(action string) (calico.Action, error) {
+	switch action {
+	case "Allow":
+		return calico.Allow, nil
+	case "Block":
+		return calico.Deny, nil
+	case "Log":
+		return calico.Log, nil
+	default:
+		return "", fmt.Errorf("action %s is not supported by Calico", action)
+	}
 }
 
-func generateName(kapName string) string {
-	return "networkpolicy" + "-" + kapName
+// getHTTPRules converts the http action points of an intent into HTTP
+// matches. Calico only matches HTTP requests on ingress Allow rules.
+func getHTTPRules(intentRequest v1.IntentRequest, action calico.Action) ([]calico.HTTPMatch, error) {
+	httpRules, err := recommendpool.CreateHTTPRules(intentRequest.Rule.ActionPoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(httpRules) == 0 {
+		return nil, nil
+	}
+	if action != calico.Allow {
+		return nil, fmt.Errorf("http action points are only supported on Allow intents")
+	}
+	if len(intentRequest.Rule.To) > 0 {
+		return nil, fmt.Errorf("http action points are not supported on to rules; Calico only matches HTTP requests on ingress")
+	}
+	return httpRules, nil
+}
+
+func policyTypes(policy *policyRules) []calico.PolicyType {
+	var types []calico.PolicyType
+	if len(policy.ingress) > 0 {
+		types = append(types, calico.PolicyTypeIngress)
+	}
+	if len(policy.egress) > 0 {
+		types = append(types, calico.PolicyTypeEgress)
+	}
+	return types
+}
+
+// generateName names the NetworkPolicies of a KubeAegisPolicy. The first is
+// named after the policy alone, the others get their index as a suffix.
+func generateName(kapName string, index int) string {
+	name := "networkpolicy" + "-" + kapName
+	if index == 0 {
+		return name
+	}
+	return name + "-" + strconv.Itoa(index)
+}
+
+// generateGlobalName names the GlobalNetworkPolicies of a KubeAegisPolicy
+// after the policy and its namespace, in the same way as generateName.
+func generateGlobalName(kapName, kapNamespace string, index int) string {
+	name := "globalnetworkpolicy" + "-" + kapNamespace + "-" + kapName
+	if index == 0 {
+		return name
+	}
+	return name + "-" + strconv.Itoa(index)
 }
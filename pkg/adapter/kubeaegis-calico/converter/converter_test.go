@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+)
+
+func newPolicy(scope string, priority *int32, intents ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "guard", Namespace: "platform"},
+		Spec:       v1.KubeAegisPolicySpec{Scope: scope, Priority: priority, IntentRequest: intents},
+	}
+}
+
+func networkIntent(action string, labels map[string]string, from []v1.NetPolDetail) v1.IntentRequest {
+	return v1.IntentRequest{
+		Type:     "network",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: labels}}},
+		Rule:     v1.Rule{Action: action, From: from},
+	}
+}
+
+func TestConverter(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	api := map[string]string{"app": "api"}
+	cidr := []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8"}, Except: []string{"10.1.0.0/16"}}}
+	cidrRule := func(action calico.Action) calico.Rule {
+		return calico.Rule{
+			Action: action,
+			Source: calico.EntityRule{Nets: []string{"10.0.0.0/8"}, NotNets: []string{"10.1.0.0/16"}},
+		}
+	}
+	ingress := []calico.PolicyType{calico.PolicyTypeIngress}
+	priority := int32(10)
+	order := float64(10)
+
+	tests := []struct {
+		name           string
+		kap            *v1.KubeAegisPolicy
+		policies       []*calico.NetworkPolicy
+		globalPolicies []*calico.GlobalNetworkPolicy
+		wantErr        bool
+	}{
+		{
+			name: "namespaced policy",
+			kap:  newPolicy("", nil, networkIntent("Block", web, cidr)),
+			policies: []*calico.NetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "networkpolicy-guard", Namespace: "platform"},
+				Spec: calico.NetworkPolicySpec{
+					Selector: "app == 'web'",
+					Types:    ingress,
+					Ingress:  []calico.Rule{cidrRule(calico.Deny)},
+				},
+			}},
+		},
+		{
+			name: "priority sets the order",
+			kap:  newPolicy("", &priority, networkIntent("Allow", web, cidr)),
+			policies: []*calico.NetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "networkpolicy-guard", Namespace: "platform"},
+				Spec: calico.NetworkPolicySpec{
+					Order:    &order,
+					Selector: "app == 'web'",
+					Types:    ingress,
+					Ingress:  []calico.Rule{cidrRule(calico.Allow)},
+				},
+			}},
+		},
+		{
+			name: "intents with different selectors get their own policy",
+			kap:  newPolicy("", nil, networkIntent("Log", web, cidr), networkIntent("Block", api, cidr)),
+			policies: []*calico.NetworkPolicy{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "networkpolicy-guard", Namespace: "platform"},
+					Spec: calico.NetworkPolicySpec{
+						Selector: "app == 'web'",
+						Types:    ingress,
+						Ingress:  []calico.Rule{cidrRule(calico.Log)},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "networkpolicy-guard-1", Namespace: "platform"},
+					Spec: calico.NetworkPolicySpec{
+						Selector: "app == 'api'",
+						Types:    ingress,
+						Ingress:  []calico.Rule{cidrRule(calico.Deny)},
+					},
+				},
+			},
+		},
+		{
+			name: "cluster policy",
+			kap:  newPolicy(v1.ScopeCluster, nil, networkIntent("Block", web, cidr)),
+			globalPolicies: []*calico.GlobalNetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "globalnetworkpolicy-platform-guard",
+					Labels: map[string]string{OriginPolicyLabel: "guard", OriginNamespaceLabel: "platform"},
+				},
+				Spec: calico.GlobalNetworkPolicySpec{
+					Selector: "app == 'web'",
+					Types:    ingress,
+					Ingress:  []calico.Rule{cidrRule(calico.Deny)},
+				},
+			}},
+		},
+		{
+			name:    "baseline scope",
+			kap:     newPolicy(v1.ScopeBaseline, nil, networkIntent("Block", web, cidr)),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported action",
+			kap:     newPolicy("", nil, networkIntent("Audit", web, cidr)),
+			wantErr: true,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			policies, globalPolicies, err := Converter(context.Background(), c, logr.Discard(), tt.kap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Converter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(policies, tt.policies) {
+				t.Errorf("Converter() policies = %+v, want %+v", policies, tt.policies)
+			}
+			if !reflect.DeepEqual(globalPolicies, tt.globalPolicies) {
+				t.Errorf("Converter() globalPolicies = %+v, want %+v", globalPolicies, tt.globalPolicies)
+			}
+		})
+	}
+}
@@ -1 +1,222 @@
 package converter
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+	recommendpool "github.com/cclab-inu/KubeAegis/pkg/recommandpool"
+	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	crdnum "github.com/projectcalico/api/pkg/lib/numorstring"
+)
+
+// namespaceNameLabel is the label Calico sets on every namespace with its name.
+const namespaceNameLabel = "projectcalico.org/name"
+
+// getIngressRules generates one ingress rule per from entry of an intent. An
+// intent with HTTP matches gets one rule per match instead.
+func getIngressRules(intentRequest v1.IntentRequest, action calico.Action, httpRules []calico.HTTPMatch) ([]calico.Rule, error) {
+	var ingressRules []calico.Rule
+
+	for i, from := range intentRequest.Rule.From {
+		target := v1.IntentRequest{Rule: v1.Rule{From: []v1.NetPolDetail{from}}}
+
+		var rules []calico.Rule
+		var err error
+		switch from.Kind {
+		case "pod":
+			rules, err = recommendpool.CreateIngressPodSelectorRule(target)
+			if len(from.Labels) == 0 {
+				rules = []calico.Rule{{Source: calico.EntityRule{Selector: "all()"}}}
+			}
+		case "namespace":
+			rules, err = recommendpool.CreateIngressNamespaceSelectorRule(target)
+			if len(from.Labels) == 0 {
+				rules = []calico.Rule{{}}
+			}
+			rules[0].Source.NamespaceSelector = namespaceSelector(rules[0].Source.NamespaceSelector, from.Args)
+		case "serviceAccounts":
+			rules, err = recommendpool.CreateSourceServiceAccountsRule(target)
+			if len(from.Labels) == 0 {
+				rules = []calico.Rule{{Source: calico.EntityRule{ServiceAccounts: &calico.ServiceAccountMatch{}}}}
+			}
+			rules[0].Source.ServiceAccounts.Names = from.Args
+		case "cidr":
+			rules, err = recommendpool.CreateIngressCIDRNets(target)
+			if len(rules) > 0 {
+				rules[0].Source.NotNets = from.Except
+			}
+		case "protocol":
+			rules, err = recommendpool.CreateIngressProtocolRule(target)
+		case "icmp":
+			rules, err = recommendpool.CreateIngressICMPRule(target)
+		case "port":
+			rules = []calico.Rule{{}}
+		default:
+			return nil, fmt.Errorf("from[%d]: unsupported kind: %s", i, from.Kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("from[%d]: %v", i, err)
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("from[%d]: %s rule has nothing to match", i, from.Kind)
+		}
+
+		rule := rules[0]
+		rule.Action = action
+		if err := setPorts(&rule, from); err != nil {
+			return nil, fmt.Errorf("from[%d]: %v", i, err)
+		}
+
+		if len(httpRules) == 0 {
+			ingressRules = append(ingressRules, rule)
+			continue
+		}
+		if rule.Protocol == nil || rule.Protocol.String() != "TCP" {
+			return nil, fmt.Errorf("from[%d]: http action points only apply to TCP rules", i)
+		}
+		for j := range httpRules {
+			httpRule := rule
+			httpRule.HTTP = &httpRules[j]
+			ingressRules = append(ingressRules, httpRule)
+		}
+	}
+	return ingressRules, nil
+}
+
+// getEgressRules generates one egress rule per to entry of an intent.
+func getEgressRules(intentRequest v1.IntentRequest, action calico.Action) ([]calico.Rule, error) {
+	var egressRules []calico.Rule
+
+	for i, to := range intentRequest.Rule.To {
+		target := v1.IntentRequest{Rule: v1.Rule{To: []v1.NetPolDetail{to}}}
+
+		var rules []calico.Rule
+		var err error
+		switch to.Kind {
+		case "pod":
+			rules, err = recommendpool.CreateEgressPodSelectorRule(target)
+			if len(to.Labels) == 0 {
+				rules = []calico.Rule{{Destination: calico.EntityRule{Selector: "all()"}}}
+			}
+		case "namespace":
+			rules, err = recommendpool.CreateEgressNamespaceSelectorRule(target)
+			if len(to.Labels) == 0 {
+				rules = []calico.Rule{{}}
+			}
+			rules[0].Destination.NamespaceSelector = namespaceSelector(rules[0].Destination.NamespaceSelector, to.Args)
+		case "serviceAccounts":
+			rules, err = recommendpool.CreateDestinationServiceAccountsRule(target)
+			if len(to.Labels) == 0 {
+				rules = []calico.Rule{{Destination: calico.EntityRule{ServiceAccounts: &calico.ServiceAccountMatch{}}}}
+			}
+			rules[0].Destination.ServiceAccounts.Names = to.Args
+		case "cidr":
+			rules, err = recommendpool.CreateEgressCIDRNets(target)
+			if len(rules) > 0 {
+				rules[0].Destination.NotNets = to.Except
+			}
+		case "protocol":
+			rules, err = recommendpool.CreateEgressProtocolRule(target)
+		case "icmp":
+			rules, err = recommendpool.CreateEgressICMPRule(target)
+		case "port":
+			rules = []calico.Rule{{}}
+		default:
+			return nil, fmt.Errorf("to[%d]: unsupported kind: %s", i, to.Kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("to[%d]: %v", i, err)
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("to[%d]: %s rule has nothing to match", i, to.Kind)
+		}
+
+		rule := rules[0]
+		rule.Action = action
+		if err := setPorts(&rule, to); err != nil {
+			return nil, fmt.Errorf("to[%d]: %v", i, err)
+		}
+		egressRules = append(egressRules, rule)
+	}
+	return egressRules, nil
+}
+
+// namespaceSelector adds the namespaces named in args to a namespace
+// selector. A namespace entry with neither labels nor names matches every
+// namespace.
+func namespaceSelector(selector string, names []string) string {
+	if len(names) > 0 {
+		quoted := make([]string, 0, len(names))
+		for _, name := range names {
+			quoted = append(quoted, "'"+name+"'")
+		}
+		byName := fmt.Sprintf("%s in {%s}", namespaceNameLabel, strings.Join(quoted, ", "))
+		if selector == "" {
+			return byName
+		}
+		return selector + " && " + byName
+	}
+	if selector == "" {
+		return "all()"
+	}
+	return selector
+}
+
+// setPorts restricts a rule to the port and protocol of a from or to entry.
+// The ports are those of the destination: the selected endpoints for ingress
+// and the peer for egress. Calico only accepts ports on TCP, UDP and SCTP
+// rules, so the protocol defaults to TCP.
+func setPorts(rule *calico.Rule, detail v1.NetPolDetail) error {
+	// The protocol of icmp and protocol rules is set by their builder.
+	if detail.Kind == "icmp" || detail.Kind == "protocol" {
+		if detail.Port != "" {
+			return fmt.Errorf("%s rules cannot have a port", detail.Kind)
+		}
+		return nil
+	}
+
+	if detail.Port != "" {
+		ports, err := toPorts(detail.Port)
+		if err != nil {
+			return err
+		}
+		rule.Destination.Ports = ports
+	}
+	protocol := detail.Protocol
+	if protocol == "" && detail.Port != "" {
+		protocol = "TCP"
+	}
+	if protocol != "" {
+		p := crdnum.ProtocolFromString(protocol)
+		rule.Protocol = &p
+	}
+	return nil
+}
+
+// toPorts converts the port field of a rule into Calico ports, keeping ranges
+// and named ports.
+func toPorts(port string) ([]crdnum.Port, error) {
+	specs, err := processor.ParsePorts(port)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]crdnum.Port, 0, len(specs))
+	for _, spec := range specs {
+		switch {
+		case spec.Name != "":
+			ports = append(ports, crdnum.NamedPort(spec.Name))
+		case spec.IsRange():
+			p, err := crdnum.PortFromRange(uint16(spec.Port), uint16(spec.EndPort))
+			if err != nil {
+				return nil, fmt.Errorf("invalid port range %s: %v", spec, err)
+			}
+			ports = append(ports, p)
+		default:
+			ports = append(ports, crdnum.SinglePort(uint16(spec.Port)))
+		}
+	}
+	return ports, nil
+}
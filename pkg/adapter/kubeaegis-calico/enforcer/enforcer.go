@@ -6,11 +6,13 @@ import (
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-calico/converter"
 	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
 )
 
@@ -39,12 +41,109 @@ func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 		}
 	} else {
 		logger.Info("NetworkPolicy updated", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		before := existingPolicy.DeepCopy()
 		existingPolicy.Spec = policy.Spec
 		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
 			logger.Error(err, "failed to update NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
 			return "", err
 		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "NetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		}
+	}
+
+	return policy.Name, nil
+}
+
+// EnforceGlobal creates or updates a GlobalNetworkPolicy. It carries the
+// origin labels instead of an owner reference.
+func EnforceGlobal(ctx context.Context, k8sClient client.Client, logger logr.Logger, policy *calico.GlobalNetworkPolicy, kap *v1.KubeAegisPolicy) (string, error) {
+	existingPolicy := &calico.GlobalNetworkPolicy{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: policy.Name}, existingPolicy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to fetch GlobalNetworkPolicy", "Policy.Name", policy.Name)
+		return "", err
+	}
+
+	if apierrors.IsNotFound(err) {
+		logger.Info("GlobalNetworkPolicy enforced", "Policy.Name", policy.Name)
+		if err := k8sClient.Create(ctx, policy); err != nil {
+			logger.Error(err, "failed to create GlobalNetworkPolicy", "Policy.Name", policy.Name)
+			return "", err
+		}
+	} else {
+		logger.Info("GlobalNetworkPolicy updated", "Policy.Name", policy.Name)
+		before := existingPolicy.DeepCopy()
+		existingPolicy.Labels = policy.Labels
+		existingPolicy.Spec = policy.Spec
+		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
+			logger.Error(err, "failed to update GlobalNetworkPolicy", "Policy.Name", policy.Name)
+			return "", err
+		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "GlobalNetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to GlobalNetworkPolicy", "Policy.Name", policy.Name)
+		}
 	}
 
 	return policy.Name, nil
 }
+
+// Prune deletes the NetworkPolicies owned by the KubeAegisPolicy that are not
+// in keep, left over from selectors the policy no longer has.
+func Prune(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy, keep []string) error {
+	var policies calico.NetworkPolicyList
+	if err := k8sClient.List(ctx, &policies, client.InNamespace(kap.Namespace)); err != nil {
+		logger.Error(err, "failed to list NetworkPolicies", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(policy.Name) || !ownedBy(policy, kap) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+			return err
+		}
+		logger.Info("NetworkPolicy deleted", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+	}
+	return nil
+}
+
+// PruneGlobal deletes the GlobalNetworkPolicies labeled with the
+// KubeAegisPolicy that are not in keep.
+func PruneGlobal(ctx context.Context, k8sClient client.Client, logger logr.Logger, kapName, kapNamespace string, keep []string) error {
+	var policies calico.GlobalNetworkPolicyList
+	if err := k8sClient.List(ctx, &policies, client.MatchingLabels{
+		converter.OriginPolicyLabel:    kapName,
+		converter.OriginNamespaceLabel: kapNamespace,
+	}); err != nil {
+		logger.Error(err, "failed to list GlobalNetworkPolicies", "KubeAegis.Name", kapName)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(policy.Name) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete GlobalNetworkPolicy", "Policy.Name", policy.Name)
+			return err
+		}
+		logger.Info("GlobalNetworkPolicy deleted", "Policy.Name", policy.Name)
+	}
+	return nil
+}
+
+func ownedBy(policy *calico.NetworkPolicy, kap *v1.KubeAegisPolicy) bool {
+	for _, owner := range policy.GetOwnerReferences() {
+		if owner.Kind == "KubeAegisPolicy" && owner.UID == kap.UID {
+			return true
+		}
+	}
+	return false
+}
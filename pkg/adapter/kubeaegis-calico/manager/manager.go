@@ -2,6 +2,7 @@ package manager
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -32,26 +33,59 @@ func init() {
 }
 
 func Run(ctx context.Context, logger logr.Logger, KapName string, KapNamespace string) (string, error) {
-	var policyName string
 	kap, err := watcher.GetKubeAegisPolicy(ctx, k8sClient, KapName, KapNamespace)
 	if err != nil {
 		return "", err
 	}
 	logger.Info("KubeAegisPolicy fetched", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
 
-	realPolicy, err := converter.Converter(ctx, k8sClient, logger, kap)
+	policies, globalPolicies, err := converter.Converter(ctx, k8sClient, logger, kap)
 	if err != nil {
 		return "", err
 	}
 
-	if policyName, err = enforcer.Enforcer(ctx, k8sClient, logger, realPolicy, kap); err != nil {
-		return "", err
+	var policyNames, globalNames []string
+	for _, policy := range policies {
+		policyName, err := enforcer.Enforcer(ctx, k8sClient, logger, policy, kap)
+		if err != nil {
+			return "", err
+		}
+		policyNames = append(policyNames, policyName)
+	}
+	for _, policy := range globalPolicies {
+		policyName, err := enforcer.EnforceGlobal(ctx, k8sClient, logger, policy, kap)
+		if err != nil {
+			return "", err
+		}
+		globalNames = append(globalNames, policyName)
 	}
 
-	if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, realPolicy.Name, KapName, KapNamespace); err != nil {
-		logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+	// The scope or the selectors of the KubeAegisPolicy may have changed
+	// since it was last converted.
+	if err := enforcer.Prune(ctx, k8sClient, logger, kap, policyNames); err != nil {
 		return "", err
 	}
+	if err := enforcer.PruneGlobal(ctx, k8sClient, logger, kap.Name, kap.Namespace, globalNames); err != nil {
+		return "", err
+	}
+
+	policyNames = append(policyNames, globalNames...)
+	for _, policyName := range policyNames {
+		if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, policyName, KapName, KapNamespace); err != nil {
+			logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+			return "", err
+		}
+	}
+
+	if len(policyNames) == 0 {
+		return "", nil
+	}
+	return policyNames[0], nil
+}
 
-	return policyName, nil
+// Cleanup deletes the GlobalNetworkPolicies of a deleted KubeAegisPolicy. Its
+// NetworkPolicies are garbage collected. The controller names the deleted
+// policy after its KubeArmorPolicy.
+func Cleanup(ctx context.Context, logger logr.Logger, policyName string, kapNamespace string) error {
+	return enforcer.PruneGlobal(ctx, k8sClient, logger, strings.TrimPrefix(policyName, "ksp-"), kapNamespace, nil)
 }
@@ -5,14 +5,13 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
-
-	calico "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 )
 
 var (
@@ -28,31 +27,34 @@ func init() {
 	factory = dynamicinformer.NewDynamicSharedInformerFactory(k8sClient, time.Minute)
 }
 
-func calicoInformer() cache.SharedIndexInformer {
+func calicoInformer(resource string) cache.SharedIndexInformer {
 	PolicyGvr := schema.GroupVersionResource{
 		Group:    "crd.projectcalico.org",
 		Version:  "v1",
-		Resource: "NetworkPolicy",
+		Resource: resource,
 	}
 	informer := factory.ForResource(PolicyGvr).Informer()
 	return informer
 }
 
-// Watchciliums watches for cilium events and logs deletions.
+// WatchRealPolicy watches for NetworkPolicy and GlobalNetworkPolicy events
+// and logs deletions.
 func WatchRealPolicy(ctx context.Context, logger logr.Logger) {
-	informer := calicoInformer()
-
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		DeleteFunc: func(obj interface{}) {
-			policy, ok := obj.(*calico.NetworkPolicy)
-			if !ok {
-				logger.Error(nil, "Could not cast to NetworkPolicy", "obj", obj)
-				return
-			}
-			logger.Info("NetworkPolicy deleted", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
-		},
-	})
+	for _, resource := range []string{"networkpolicies", "globalnetworkpolicies"} {
+		informer := calicoInformer(resource)
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			DeleteFunc: func(obj interface{}) {
+				policy, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					logger.Error(nil, "Could not cast to "+resource, "obj", obj)
+					return
+				}
+				logger.Info(policy.GetKind()+" deleted", "Policy.Name", policy.GetName(), "Policy.Namespace", policy.GetNamespace())
+			},
+		})
+		go informer.Run(ctx.Done())
+	}
 
 	logger.Info("Starting NetworkPolicy watcher")
-	informer.Run(ctx.Done())
+	<-ctx.Done()
 }
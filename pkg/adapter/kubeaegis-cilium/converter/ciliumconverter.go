@@ -13,77 +13,259 @@ import (
 	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
 
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	slim_metav1 "github.com/cilium/cilium/pkg/k8s/slim/k8s/apis/meta/v1"
 	"github.com/cilium/cilium/pkg/policy/api"
 )
 
-func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*ciliumv2.CiliumNetworkPolicy, error) {
+// Labels set on CiliumClusterwideNetworkPolicies, which cannot carry an owner
+// reference to the namespaced KubeAegisPolicy they were generated from.
+const (
+	OriginPolicyLabel    = "kubeaegis.cclab.com/policy"
+	OriginNamespaceLabel = "kubeaegis.cclab.com/namespace"
+)
+
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"network": {"endpoint", "entities", "port", "cidr", "fqdn", "fqdns", "service", "node"},
+}
+
+// Scopes are the policy scopes the converter renders. CiliumNetworkPolicies
+// are namespaced, so Cluster and Baseline policies go to other adapters.
+var Scopes = []string{v1.ScopeNamespace}
+
+// Converter returns the CiliumNetworkPolicy for the intents that select pods,
+// and a CiliumClusterwideNetworkPolicy for those that select nodes. Either is
+// nil when no intent needs it, but the CiliumNetworkPolicy is always returned
+// for a KubeAegisPolicy without node intents.
+func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*ciliumv2.CiliumNetworkPolicy, *ciliumv2.CiliumClusterwideNetworkPolicy, error) {
 	logger.Info("CiliumNetworkPolicy started to transfer")
 
+	if kap.Spec.Scope != "" && kap.Spec.Scope != v1.ScopeNamespace {
+		err := fmt.Errorf("scope %s is not supported; the Cilium adapter only renders namespaced policies", kap.Spec.Scope)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, nil, err
+	}
+
 	ciliumNetworkPolicy := &ciliumv2.CiliumNetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      generateCNPName(kap.Name),
+			Name:      GenerateCNPName(kap.Name),
 			Namespace: kap.Namespace,
 		},
 		Spec: &api.Rule{},
 	}
 
-	// Assuming that there might be multiple IntentRequests, we iterate through them.
+	// Each intent adds its ingress and egress rules to the Cilium rule of
+	// every endpoint selector it compiles to. Intents with the same selector
+	// share a rule, so that Allow and Block intents for the same Pods merge.
+	// Intents that select nodes go to host rules instead.
+	var rules, hostRules []*api.Rule
+
 	for _, intentRequest := range kap.Spec.IntentRequest {
-		matchLabels, err := extractSelector(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		nodes, err := processor.SelectsNodes(intentRequest.Selector)
+		if err != nil {
+			logger.Error(err, "failed to extract selector")
+			return nil, nil, err
+		}
+		var endpointSelectors []api.EndpointSelector
+		if nodes {
+			endpointSelectors, err = extractNodeSelector(ctx, k8sClient, intentRequest.Selector)
+		} else {
+			endpointSelectors, err = extractSelector(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		}
 		if err != nil {
 			logger.Error(err, "failed to extract selector")
-			return nil, err
+			return nil, nil, err
 		}
-		if len(matchLabels) == 0 {
+		if len(endpointSelectors) == 0 {
 			continue
 		}
 
-		ciliumNetworkPolicy.Spec.EndpointSelector = api.NewESFromMatchRequirements(matchLabels, nil)
+		// Cilium deny rules cannot carry HTTP rules, and leaving them out
+		// would deny all traffic on the ports.
+		httpRules := getHTTPRules(intentRequest.Rule.ActionPoint)
+		if intentRequest.Rule.Action == "Block" && len(httpRules) > 0 {
+			err = errors.New("http action points are not supported with action Block")
+			logger.Error(err, "failed to convert HTTP rules")
+			return nil, nil, err
+		}
+
+		intentRule := &api.Rule{}
 		if intentRequest.Rule.Action == "Block" && len(intentRequest.Rule.To) > 0 {
-			ciliumNetworkPolicy.Spec.EgressDeny, _ = getEgressDeny(intentRequest)
+			intentRule.EgressDeny, err = getEgressDeny(intentRequest)
+			if err != nil {
+				logger.Error(err, "failed to convert egress deny rules")
+				return nil, nil, err
+			}
 		} else if intentRequest.Rule.Action == "Block" && len(intentRequest.Rule.From) > 0 {
-			ciliumNetworkPolicy.Spec.IngressDeny, _ = getIngressDeny(intentRequest)
+			intentRule.IngressDeny, err = getIngressDeny(intentRequest)
+			if err != nil {
+				logger.Error(err, "failed to convert ingress deny rules")
+				return nil, nil, err
+			}
 		} else if intentRequest.Rule.Action == "Allow" && len(intentRequest.Rule.To) > 0 {
-			logger.Info("Egress Rules will be added at a later date ...")
-			//ciliumNetworkPolicy.Spec.Egress = getEgress(intentRequest.Rule.To)
+			intentRule.Egress, err = getEgress(intentRequest, kap.Namespace, httpRules)
+			if err != nil {
+				logger.Error(err, "failed to convert egress rules")
+				return nil, nil, err
+			}
 		} else if intentRequest.Rule.Action == "Allow" && len(intentRequest.Rule.From) > 0 {
-			ciliumNetworkPolicy.Spec.Ingress, _ = getIngress(intentRequest)
+			intentRule.Ingress, err = getIngress(intentRequest, httpRules)
+			if err != nil {
+				logger.Error(err, "failed to convert ingress rules")
+				return nil, nil, err
+			}
+		}
+
+		// A Cilium rule takes a single endpoint selector, so every
+		// alternative of the KAP selector gets its own copy of the rules.
+		for _, endpointSelector := range endpointSelectors {
+			var rule *api.Rule
+			if nodes {
+				rule = ruleFor(&hostRules, endpointSelector, true)
+			} else {
+				rule = ruleFor(&rules, endpointSelector, false)
+			}
+			rule.IngressDeny = append(rule.IngressDeny, intentRule.IngressDeny...)
+			rule.EgressDeny = append(rule.EgressDeny, intentRule.EgressDeny...)
+			rule.Ingress = append(rule.Ingress, intentRule.Ingress...)
+			rule.Egress = appendEgress(rule.Egress, intentRule.Egress)
 		}
 	}
-	logger.Info("CiliumPolicy converted")
-	return ciliumNetworkPolicy, nil
-}
 
-// extractSelector extracts match labels from a Selector.
-func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
-	matchLabels := make(map[string]string) // Initialize map for match labels.
+	if len(rules) > 0 {
+		ciliumNetworkPolicy.Spec = rules[0]
+		ciliumNetworkPolicy.Specs = rules[1:]
+	}
+	if len(hostRules) == 0 {
+		logger.Info("CiliumPolicy converted")
+		return ciliumNetworkPolicy, nil, nil
+	}
 
-	// Process CEL expressions.
-	if len(selector.CEL) > 0 {
-		celExpressions := selector.CEL
-		celMatchLabels, err := processor.ProcessCEL(ctx, k8sClient, namespace, celExpressions)
-		if err != nil {
-			return nil, fmt.Errorf("error processing CEL: %v", err)
+	// Cluster-scoped policies are tied to the KubeAegisPolicy by labels, and
+	// are named after its namespace as well to stay unique.
+	ciliumClusterwideNetworkPolicy := &ciliumv2.CiliumClusterwideNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: generateCCNPName(kap.Name, kap.Namespace),
+			Labels: map[string]string{
+				OriginPolicyLabel:    kap.Name,
+				OriginNamespaceLabel: kap.Namespace,
+			},
+		},
+		Spec:  hostRules[0],
+		Specs: hostRules[1:],
+	}
+	if len(rules) == 0 {
+		ciliumNetworkPolicy = nil
+	}
+	logger.Info("CiliumPolicy converted", "Clusterwide", ciliumClusterwideNetworkPolicy.Name)
+	return ciliumNetworkPolicy, ciliumClusterwideNetworkPolicy, nil
+}
+
+// ruleFor returns the rule among rules that applies to the selector, adding
+// one if there is none. Host rules select nodes through their NodeSelector.
+func ruleFor(rules *[]*api.Rule, selector api.EndpointSelector, host bool) *api.Rule {
+	for _, rule := range *rules {
+		ruleSelector := rule.EndpointSelector
+		if host {
+			ruleSelector = rule.NodeSelector
 		}
-		for k, v := range celMatchLabels {
-			matchLabels[k] = v
+		if ruleSelector.String() == selector.String() {
+			return rule
 		}
 	}
+	rule := &api.Rule{EndpointSelector: selector}
+	if host {
+		rule = &api.Rule{NodeSelector: selector}
+	}
+	*rules = append(*rules, rule)
+	return rule
+}
 
-	// Process Match fields.
-	if len(selector.Match) > 0 {
-		processedMatchLabels, err := processor.ProcessMatchLabels(selector.Match)
-		if err != nil {
-			return nil, errors.Wrap(err, "error processing matchLabels")
-		}
-		for key, value := range processedMatchLabels {
-			matchLabels[key] = value
+// appendEgress appends egress rules to those of a Cilium rule. The DNS proxy
+// rule of FQDN targets is added only once.
+func appendEgress(egressRules, more []api.EgressRule) []api.EgressRule {
+	hasDNSProxy := false
+	for i := range egressRules {
+		hasDNSProxy = hasDNSProxy || isDNSProxyRule(&egressRules[i])
+	}
+	for i := range more {
+		if isDNSProxyRule(&more[i]) {
+			if hasDNSProxy {
+				continue
+			}
+			hasDNSProxy = true
 		}
+		egressRules = append(egressRules, more[i])
 	}
+	return egressRules
+}
 
-	return matchLabels, nil
+// extractSelector compiles a Selector into Cilium endpoint selectors, one per
+// alternative. It returns nothing for an empty Selector.
+func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) ([]api.EndpointSelector, error) {
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+	if compiled.IsEmpty() {
+		return nil, nil
+	}
+
+	labelSelectors, err := compiled.LabelSelectorsOrResolve(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
+
+	// Cilium labels every endpoint with the labels of its namespace.
+	namespaceRequirements := processor.NamespaceRequirements(selector.NamespaceSelector, processor.CiliumNamespaceLabelPrefix)
+
+	endpointSelectors := make([]api.EndpointSelector, 0, len(labelSelectors))
+	for _, labelSelector := range labelSelectors {
+		labelSelector.MatchExpressions = append(labelSelector.MatchExpressions, namespaceRequirements...)
+		endpointSelectors = append(endpointSelectors, toEndpointSelector(labelSelector))
+	}
+	return endpointSelectors, nil
 }
 
-func generateCNPName(kapName string) string {
+// extractNodeSelector compiles a selector of Node entries into Cilium node
+// selectors, one per alternative.
+func extractNodeSelector(ctx context.Context, k8sClient client.Client, selector v1.Selector) ([]api.EndpointSelector, error) {
+	compiled, err := processor.CompileSelector(ctx, k8sClient, "", selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+
+	labelSelectors, err := compiled.LabelSelectors()
+	if err != nil {
+		return nil, fmt.Errorf("error converting node selector: %v", err)
+	}
+
+	nodeSelectors := make([]api.EndpointSelector, 0, len(labelSelectors))
+	for _, labelSelector := range labelSelectors {
+		nodeSelectors = append(nodeSelectors, toEndpointSelector(labelSelector))
+	}
+	return nodeSelectors, nil
+}
+
+// toEndpointSelector converts a Kubernetes LabelSelector into a Cilium EndpointSelector.
+func toEndpointSelector(labelSelector metav1.LabelSelector) api.EndpointSelector {
+	requirements := make([]slim_metav1.LabelSelectorRequirement, 0, len(labelSelector.MatchExpressions))
+	for _, expr := range labelSelector.MatchExpressions {
+		requirements = append(requirements, slim_metav1.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: slim_metav1.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+	return api.NewESFromMatchRequirements(labelSelector.MatchLabels, requirements)
+}
+
+// GenerateCNPName returns the name of the CiliumNetworkPolicy of a KubeAegisPolicy.
+func GenerateCNPName(kapName string) string {
 	return "cnp-" + kapName
 }
+
+func generateCCNPName(kapName, kapNamespace string) string {
+	return "ccnp-" + kapNamespace + "-" + kapName
+}
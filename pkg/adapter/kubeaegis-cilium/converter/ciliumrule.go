@@ -2,8 +2,12 @@ package converter
 
 import (
 	"fmt"
+	"net/netip"
+	"strconv"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+	recommendpool "github.com/cclab-inu/KubeAegis/pkg/recommandpool"
 	"github.com/cilium/cilium/pkg/policy/api"
 )
 
@@ -29,27 +33,40 @@ func getIngressDeny(intentRequest v1.IntentRequest) ([]api.IngressDenyRule, erro
 				}
 			}
 		case "cidr":
-			if len(from.Args) > 0 {
+			if len(from.Except) > 0 {
+				ingressRule.FromCIDRSet = toCIDRRules(from)
+			} else if len(from.Args) > 0 {
 				// Add each CIDR to the FromCIDR slice
 				for _, cidr := range from.Args {
 					ingressRule.FromCIDR = append(ingressRule.FromCIDR, api.CIDR(cidr))
 				}
 			}
-
+		case "node":
+			ingressRule.FromNodes = append(ingressRule.FromNodes, api.NewESFromMatchRequirements(from.Labels, nil))
+		case "port":
 		default:
 			return nil, fmt.Errorf("unsupported kind: %s", from.Kind)
 		}
 
+		// A peer with a port is only denied on that port.
+		if from.Kind == "port" || from.Port != "" {
+			portDenyRule, err := toPortDenyRule(from)
+			if err != nil {
+				return nil, err
+			}
+			ingressRule.ToPorts = api.PortDenyRules{portDenyRule}
+		}
 		ingressDenyRules = append(ingressDenyRules, ingressRule)
 	}
 	return ingressDenyRules, nil
 }
 
-func getIngress(intentRequest v1.IntentRequest) ([]api.IngressRule, error) {
+func getIngress(intentRequest v1.IntentRequest, httpRules []api.PortRuleHTTP) ([]api.IngressRule, error) {
 	var ingressRules []api.IngressRule
-	ingressRule := api.IngressRule{}
 
 	for _, from := range intentRequest.Rule.From {
+		ingressRule := api.IngressRule{}
+
 		switch from.Kind {
 		case "endpoint":
 			if len(from.Labels) > 0 {
@@ -57,36 +74,285 @@ func getIngress(intentRequest v1.IntentRequest) ([]api.IngressRule, error) {
 				selector := api.NewESFromMatchRequirements(from.Labels, nil)
 				ingressRule.FromEndpoints = append(ingressRule.FromEndpoints, selector)
 			}
+		case "entities":
+			for _, entity := range from.Args {
+				ingressRule.FromEntities = append(ingressRule.FromEntities, api.Entity(entity))
+			}
+		case "cidr":
+			if len(from.Except) > 0 {
+				ingressRule.FromCIDRSet = toCIDRRules(from)
+			} else {
+				for _, cidr := range from.Args {
+					ingressRule.FromCIDR = append(ingressRule.FromCIDR, api.CIDR(cidr))
+				}
+			}
+		case "node":
+			ingressRule.FromNodes = append(ingressRule.FromNodes, api.NewESFromMatchRequirements(from.Labels, nil))
+		case "port":
 		default:
 			return nil, fmt.Errorf("unsupported kind: %s", from.Kind)
 		}
+
+		// A peer with a port is only allowed on that port.
+		if from.Kind == "port" || from.Port != "" {
+			portRule, err := toPortRule(from, httpRules)
+			if err != nil {
+				return nil, err
+			}
+			ingressRule.ToPorts = api.PortRules{portRule}
+		}
 		ingressRules = append(ingressRules, ingressRule)
 	}
 	return ingressRules, nil
 }
 
+// getEgressDeny generates egress deny rules from KubeAegisPolicy IntentRequests
 func getEgressDeny(intentRequest v1.IntentRequest) ([]api.EgressDenyRule, error) {
 	var egressDenyRules []api.EgressDenyRule
 	for _, to := range intentRequest.Rule.To {
+		egressDenyRule := api.EgressDenyRule{}
+
 		switch to.Kind {
-		case "port":
-			// Convert to PortProtocol format for cilium
-			portProtocols := make([]api.PortProtocol, 0)
-			portProtocol := api.PortProtocol{
-				Port:     to.Port,
-				Protocol: api.L4Proto(to.Protocol),
+		case "endpoint":
+			if len(to.Labels) > 0 {
+				selector := api.NewESFromMatchRequirements(to.Labels, nil)
+				egressDenyRule.ToEndpoints = append(egressDenyRule.ToEndpoints, selector)
 			}
-			portProtocols = append(portProtocols, portProtocol)
+		case "entities":
+			for _, entity := range to.Args {
+				egressDenyRule.ToEntities = append(egressDenyRule.ToEntities, api.Entity(entity))
+			}
+		case "cidr":
+			if len(to.Except) > 0 {
+				egressDenyRule.ToCIDRSet = toCIDRRules(to)
+			} else {
+				for _, cidr := range to.Args {
+					egressDenyRule.ToCIDR = append(egressDenyRule.ToCIDR, api.CIDR(cidr))
+				}
+			}
+		case "node":
+			egressDenyRule.ToNodes = append(egressDenyRule.ToNodes, api.NewESFromMatchRequirements(to.Labels, nil))
+		case "port":
+		default:
+			return nil, fmt.Errorf("unsupported kind: %s", to.Kind)
+		}
 
-			egressDenyRule := api.EgressDenyRule{
-				ToPorts: []api.PortDenyRule{
-					{
-						Ports: portProtocols,
-					},
-				},
+		// A peer with a port is only denied on that port.
+		if to.Kind == "port" || to.Port != "" {
+			portDenyRule, err := toPortDenyRule(to)
+			if err != nil {
+				return nil, err
 			}
-			egressDenyRules = append(egressDenyRules, egressDenyRule)
+			egressDenyRule.ToPorts = api.PortDenyRules{portDenyRule}
 		}
+		egressDenyRules = append(egressDenyRules, egressDenyRule)
 	}
 	return egressDenyRules, nil
 }
+
+// getEgress generates egress rules from KubeAegisPolicy IntentRequests, one
+// per target. Once a Cilium endpoint has an egress rule, all other egress
+// traffic is denied. The HTTP rules apply to the targets with ports.
+func getEgress(intentRequest v1.IntentRequest, namespace string, httpRules []api.PortRuleHTTP) ([]api.EgressRule, error) {
+	var egressRules []api.EgressRule
+	hasFQDNs := false
+
+	for _, to := range intentRequest.Rule.To {
+		target := v1.IntentRequest{Rule: v1.Rule{To: []v1.NetPolDetail{to}}}
+		var rules []api.EgressRule
+		var err error
+
+		switch to.Kind {
+		case "endpoint":
+			rules, err = recommendpool.CreateEgressEndpointSelectorRule(target)
+		case "entities":
+			rules, err = recommendpool.CreateEgressEntitiesRule(target)
+		case "cidr":
+			if len(to.Except) > 0 {
+				rules = []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToCIDRSet: toCIDRRules(to)}}}
+			} else {
+				rules, err = recommendpool.CreateEgressCIDRRule(target)
+			}
+		case "node":
+			nodeSelector := api.NewESFromMatchRequirements(to.Labels, nil)
+			rules = []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToNodes: []api.EndpointSelector{nodeSelector}}}}
+		case "fqdn", "fqdns":
+			rules, err = recommendpool.CreateEgressFQDNsRule(target)
+			hasFQDNs = true
+		case "port":
+			var portRule api.PortRule
+			portRule, err = toPortRule(to, httpRules)
+			rules = []api.EgressRule{{ToPorts: api.PortRules{portRule}}}
+		case "service":
+			var service api.Service
+			service, err = toService(to, namespace)
+			rules = []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToServices: []api.Service{service}}}}
+		default:
+			return nil, fmt.Errorf("unsupported kind: %s", to.Kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// A peer with a port is only allowed on that port.
+		if to.Kind != "port" && to.Port != "" {
+			if to.Kind == "service" {
+				return nil, fmt.Errorf("service rules cannot be restricted to ports")
+			}
+			portRule, err := toPortRule(to, httpRules)
+			if err != nil {
+				return nil, err
+			}
+			for i := range rules {
+				rules[i].ToPorts = api.PortRules{portRule}
+			}
+		}
+		egressRules = append(egressRules, rules...)
+	}
+
+	// Cilium learns the addresses of allowed names from the DNS lookups it
+	// proxies, so the lookups themselves have to be allowed.
+	if hasFQDNs {
+		egressRules = append(egressRules, dnsProxyRule())
+	}
+	return egressRules, nil
+}
+
+// toService converts a service rule into a Cilium service. The args name the
+// Service and, optionally, its namespace; labels select Services instead.
+func toService(rule v1.NetPolDetail, namespace string) (api.Service, error) {
+	if len(rule.Args) > 1 {
+		namespace = rule.Args[1]
+	}
+	if len(rule.Args) > 0 {
+		return api.Service{K8sService: &api.K8sServiceNamespace{ServiceName: rule.Args[0], Namespace: namespace}}, nil
+	}
+	if len(rule.Labels) > 0 {
+		return api.Service{K8sServiceSelector: &api.K8sServiceSelectorNamespace{
+			Selector:  api.ServiceSelector(api.NewESFromMatchRequirements(rule.Labels, nil)),
+			Namespace: namespace,
+		}}, nil
+	}
+	return api.Service{}, fmt.Errorf("service rule names no Service")
+}
+
+// dnsProxyRule allows DNS lookups to kube-dns through Cilium's DNS proxy.
+func dnsProxyRule() api.EgressRule {
+	kubeDNS := api.NewESFromMatchRequirements(map[string]string{
+		"k8s:io.kubernetes.pod.namespace": "kube-system",
+		"k8s:k8s-app":                     "kube-dns",
+	}, nil)
+	return api.EgressRule{
+		EgressCommonRule: api.EgressCommonRule{
+			ToEndpoints: []api.EndpointSelector{kubeDNS},
+		},
+		ToPorts: api.PortRules{
+			{
+				Ports: []api.PortProtocol{{Port: "53", Protocol: api.ProtoAny}},
+				Rules: &api.L7Rules{DNS: []api.PortRuleDNS{{MatchPattern: "*"}}},
+			},
+		},
+	}
+}
+
+// toPortDenyRule converts the port and protocol of a rule into a Cilium port
+// deny rule.
+func toPortDenyRule(rule v1.NetPolDetail) (api.PortDenyRule, error) {
+	portProtocols, err := toPortProtocols(rule)
+	if err != nil {
+		return api.PortDenyRule{}, err
+	}
+	return api.PortDenyRule{Ports: portProtocols}, nil
+}
+
+// toPortRule converts the port and protocol of a rule into a Cilium port rule
+// that carries the HTTP rules, if any.
+func toPortRule(rule v1.NetPolDetail, httpRules []api.PortRuleHTTP) (api.PortRule, error) {
+	portProtocols, err := toPortProtocols(rule)
+	if err != nil {
+		return api.PortRule{}, err
+	}
+	portRule := api.PortRule{Ports: portProtocols}
+	if len(httpRules) > 0 {
+		portRule.Rules = &api.L7Rules{HTTP: httpRules}
+	}
+	return portRule, nil
+}
+
+// getHTTPRules generates Cilium HTTP rules from the http ActionPoints, one
+// per method and path. Methods and paths are regular expressions, and a
+// header without a value only has to be present.
+func getHTTPRules(actionPoints []v1.ActionPoint) []api.PortRuleHTTP {
+	var httpRules []api.PortRuleHTTP
+	for _, ap := range actionPoints {
+		if ap.SubType != "http" {
+			continue
+		}
+
+		var headers []string
+		for _, header := range ap.Headers {
+			if header.Value == "" {
+				headers = append(headers, header.Name)
+			} else {
+				headers = append(headers, header.Name+": "+header.Value)
+			}
+		}
+
+		methods := ap.Resource.Methods
+		if len(methods) == 0 {
+			methods = []string{""}
+		}
+		paths := ap.Resource.Path
+		if len(paths) == 0 {
+			paths = []string{""}
+		}
+		for _, method := range methods {
+			for _, path := range paths {
+				httpRules = append(httpRules, api.PortRuleHTTP{Method: method, Path: path, Headers: headers})
+			}
+		}
+	}
+	return httpRules
+}
+
+// toPortProtocols converts the port field of a rule into Cilium PortProtocols.
+// Ranges use EndPort, and named ports are passed through for Cilium to resolve.
+func toPortProtocols(rule v1.NetPolDetail) ([]api.PortProtocol, error) {
+	specs, err := processor.ParsePorts(rule.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	portProtocols := make([]api.PortProtocol, 0, len(specs))
+	for _, spec := range specs {
+		portProtocol := api.PortProtocol{
+			Protocol: api.L4Proto(rule.Protocol),
+		}
+		if spec.Name != "" {
+			portProtocol.Port = spec.Name
+		} else {
+			portProtocol.Port = strconv.Itoa(int(spec.Port))
+			portProtocol.EndPort = spec.EndPort
+		}
+		portProtocols = append(portProtocols, portProtocol)
+	}
+	return portProtocols, nil
+}
+
+// toCIDRRules converts a cidr rule with except ranges into a Cilium CIDR set.
+// Each except range goes to the CIDRs that contain it.
+func toCIDRRules(rule v1.NetPolDetail) api.CIDRRuleSlice {
+	cidrRules := make(api.CIDRRuleSlice, 0, len(rule.Args))
+	for _, cidr := range rule.Args {
+		cidrRule := api.CIDRRule{Cidr: api.CIDR(cidr)}
+		prefix, err := netip.ParsePrefix(cidr)
+		for _, except := range rule.Except {
+			exceptPrefix, exceptErr := netip.ParsePrefix(except)
+			if err == nil && exceptErr == nil && prefix.Contains(exceptPrefix.Addr()) {
+				cidrRule.ExceptCIDRs = append(cidrRule.ExceptCIDRs, api.CIDR(except))
+			}
+		}
+		cidrRules = append(cidrRules, cidrRule)
+	}
+	return cidrRules
+}
@@ -0,0 +1,613 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cilium/cilium/pkg/policy/api"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newPolicy(intentRequests ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       v1.KubeAegisPolicySpec{IntentRequest: intentRequests},
+	}
+}
+
+// selects returns a network intent for the pods with the labels.
+func selects(labels map[string]string, rule v1.Rule) v1.IntentRequest {
+	return v1.IntentRequest{Type: "network", Selector: v1.Selector{Match: []v1.Match{{MatchLabels: labels}}}, Rule: rule}
+}
+
+func podSelector(labels map[string]string) api.EndpointSelector {
+	return toEndpointSelector(metav1.LabelSelector{MatchLabels: labels})
+}
+
+func endpoints(labels map[string]string) []api.EndpointSelector {
+	return []api.EndpointSelector{api.NewESFromMatchRequirements(labels, nil)}
+}
+
+func TestGetEgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		to      []v1.NetPolDetail
+		want    []api.EgressRule
+		wantErr bool
+	}{
+		{
+			name: "endpoint",
+			to:   []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "db"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToEndpoints: endpoints(map[string]string{"app": "db"})}}},
+		},
+		{
+			name: "entities",
+			to:   []v1.NetPolDetail{{Kind: "entities", Args: []string{"world", "kube-apiserver"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToEntities: api.EntitySlice{"world", "kube-apiserver"}}}},
+		},
+		{
+			name: "cidr",
+			to:   []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8", "192.168.0.0/16"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToCIDR: api.CIDRSlice{"10.0.0.0/8", "192.168.0.0/16"}}}},
+		},
+		{
+			name: "cidr with except",
+			to:   []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8", "192.168.0.0/16"}, Except: []string{"192.168.1.0/24"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToCIDRSet: api.CIDRRuleSlice{
+				{Cidr: "10.0.0.0/8"},
+				{Cidr: "192.168.0.0/16", ExceptCIDRs: []api.CIDR{"192.168.1.0/24"}},
+			}}}},
+		},
+		{
+			name: "port",
+			to:   []v1.NetPolDetail{{Kind: "port", Port: "80,8000-8080", Protocol: "TCP"}},
+			want: []api.EgressRule{{ToPorts: api.PortRules{{Ports: []api.PortProtocol{
+				{Port: "80", Protocol: api.ProtoTCP},
+				{Port: "8000", EndPort: 8080, Protocol: api.ProtoTCP},
+			}}}}},
+		},
+		{
+			name: "endpoint with a port",
+			to:   []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "db"}, Port: "5432", Protocol: "TCP"}},
+			want: []api.EgressRule{{
+				EgressCommonRule: api.EgressCommonRule{ToEndpoints: endpoints(map[string]string{"app": "db"})},
+				ToPorts:          api.PortRules{{Ports: []api.PortProtocol{{Port: "5432", Protocol: api.ProtoTCP}}}},
+			}},
+		},
+		{
+			name: "service in the policy namespace",
+			to:   []v1.NetPolDetail{{Kind: "service", Args: []string{"db"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToServices: []api.Service{
+				{K8sService: &api.K8sServiceNamespace{ServiceName: "db", Namespace: "shop"}},
+			}}}},
+		},
+		{
+			name: "service in another namespace",
+			to:   []v1.NetPolDetail{{Kind: "service", Args: []string{"db", "data"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToServices: []api.Service{
+				{K8sService: &api.K8sServiceNamespace{ServiceName: "db", Namespace: "data"}},
+			}}}},
+		},
+		{
+			name: "services by labels",
+			to:   []v1.NetPolDetail{{Kind: "service", Labels: map[string]string{"tier": "data"}}},
+			want: []api.EgressRule{{EgressCommonRule: api.EgressCommonRule{ToServices: []api.Service{
+				{K8sServiceSelector: &api.K8sServiceSelectorNamespace{
+					Selector:  api.ServiceSelector(api.NewESFromMatchRequirements(map[string]string{"tier": "data"}, nil)),
+					Namespace: "shop",
+				}},
+			}}}},
+		},
+		{
+			name:    "service without a name",
+			to:      []v1.NetPolDetail{{Kind: "service"}},
+			wantErr: true,
+		},
+		{
+			name:    "service with a port",
+			to:      []v1.NetPolDetail{{Kind: "service", Args: []string{"db"}, Port: "5432"}},
+			wantErr: true,
+		},
+		{
+			name: "fqdn allows DNS lookups through the proxy",
+			to:   []v1.NetPolDetail{{Kind: "fqdn", Args: []string{"api.example.com", "*.example.org"}}},
+			want: []api.EgressRule{
+				{ToFQDNs: api.FQDNSelectorSlice{{MatchName: "api.example.com"}, {MatchPattern: "*.example.org"}}},
+				dnsProxyRule(),
+			},
+		},
+		{
+			name: "several fqdn targets share the DNS proxy rule",
+			to: []v1.NetPolDetail{
+				{Kind: "fqdn", Args: []string{"api.example.com"}},
+				{Kind: "fqdns", Args: []string{"cdn.example.com"}, Port: "443", Protocol: "TCP"},
+			},
+			want: []api.EgressRule{
+				{ToFQDNs: api.FQDNSelectorSlice{{MatchName: "api.example.com"}}},
+				{
+					ToFQDNs: api.FQDNSelectorSlice{{MatchName: "cdn.example.com"}},
+					ToPorts: api.PortRules{{Ports: []api.PortProtocol{{Port: "443", Protocol: api.ProtoTCP}}}},
+				},
+				dnsProxyRule(),
+			},
+		},
+		{
+			name:    "unsupported kind",
+			to:      []v1.NetPolDetail{{Kind: "namespace", Args: []string{"shop"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Allow", To: tt.to}}
+			got, err := getEgress(intentRequest, "shop", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getEgress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEgress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendEgress(t *testing.T) {
+	fqdn := api.EgressRule{ToFQDNs: api.FQDNSelectorSlice{{MatchName: "api.example.com"}}}
+	other := api.EgressRule{ToFQDNs: api.FQDNSelectorSlice{{MatchName: "cdn.example.com"}}}
+	kubeDNS := api.EgressRule{EgressCommonRule: api.EgressCommonRule{ToEndpoints: endpoints(map[string]string{
+		"k8s:io.kubernetes.pod.namespace": "kube-system",
+		"k8s:k8s-app":                     "kube-dns",
+	})}}
+
+	tests := []struct {
+		name  string
+		rules []api.EgressRule
+		more  []api.EgressRule
+		want  []api.EgressRule
+	}{
+		{
+			name: "first DNS proxy rule",
+			more: []api.EgressRule{fqdn, dnsProxyRule()},
+			want: []api.EgressRule{fqdn, dnsProxyRule()},
+		},
+		{
+			name:  "DNS proxy rule already present",
+			rules: []api.EgressRule{fqdn, dnsProxyRule()},
+			more:  []api.EgressRule{other, dnsProxyRule()},
+			want:  []api.EgressRule{fqdn, dnsProxyRule(), other},
+		},
+		{
+			name:  "kube-dns without the DNS proxy is another rule",
+			rules: []api.EgressRule{kubeDNS},
+			more:  []api.EgressRule{fqdn, dnsProxyRule()},
+			want:  []api.EgressRule{kubeDNS, fqdn, dnsProxyRule()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendEgress(tt.rules, tt.more); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("appendEgress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHTTPRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []v1.ActionPoint
+		want   []api.PortRuleHTTP
+	}{
+		{
+			name:   "no http action points",
+			points: []v1.ActionPoint{{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/etc/passwd"}}}},
+		},
+		{
+			name: "every method with every path",
+			points: []v1.ActionPoint{{SubType: "http", Resource: v1.EventMatchResource{
+				Methods: []string{"GET", "P(OST|UT)"},
+				Path:    []string{"/api/v1/.*", "/healthz"},
+			}}},
+			want: []api.PortRuleHTTP{
+				{Method: "GET", Path: "/api/v1/.*"},
+				{Method: "GET", Path: "/healthz"},
+				{Method: "P(OST|UT)", Path: "/api/v1/.*"},
+				{Method: "P(OST|UT)", Path: "/healthz"},
+			},
+		},
+		{
+			name:   "methods only",
+			points: []v1.ActionPoint{{SubType: "http", Resource: v1.EventMatchResource{Methods: []string{"GET"}}}},
+			want:   []api.PortRuleHTTP{{Method: "GET"}},
+		},
+		{
+			name: "headers with and without a value",
+			points: []v1.ActionPoint{{
+				SubType:  "http",
+				Headers:  []v1.EventHeader{{Name: "X-Token"}, {Name: "X-Team", Value: "store"}},
+				Resource: v1.EventMatchResource{Path: []string{"/admin"}},
+			}},
+			want: []api.PortRuleHTTP{{Path: "/admin", Headers: []string{"X-Token", "X-Team: store"}}},
+		},
+		{
+			name: "several http action points",
+			points: []v1.ActionPoint{
+				{SubType: "http", Resource: v1.EventMatchResource{Methods: []string{"GET"}}},
+				{SubType: "process", Resource: v1.EventMatchResource{Path: []string{"/bin/sh"}}},
+				{SubType: "http", Resource: v1.EventMatchResource{Methods: []string{"DELETE"}, Path: []string{"/cart/.*"}}},
+			},
+			want: []api.PortRuleHTTP{{Method: "GET"}, {Method: "DELETE", Path: "/cart/.*"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getHTTPRules(tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getHTTPRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterHTTPRules(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	getAPI := []v1.ActionPoint{{SubType: "http", Resource: v1.EventMatchResource{Methods: []string{"GET"}, Path: []string{"/api/.*"}}}}
+	httpRules := &api.L7Rules{HTTP: []api.PortRuleHTTP{{Method: "GET", Path: "/api/.*"}}}
+	port80 := []api.PortProtocol{{Port: "80", Protocol: api.ProtoTCP}}
+
+	tests := []struct {
+		name    string
+		rule    v1.Rule
+		want    *api.Rule
+		wantErr bool
+	}{
+		{
+			name: "ingress port",
+			rule: v1.Rule{Action: "Allow", From: []v1.NetPolDetail{{Kind: "port", Port: "80", Protocol: "TCP"}}, ActionPoint: getAPI},
+			want: &api.Rule{
+				EndpointSelector: podSelector(web),
+				Ingress:          []api.IngressRule{{ToPorts: api.PortRules{{Ports: port80, Rules: httpRules}}}},
+			},
+		},
+		{
+			name: "egress peer with a port",
+			rule: v1.Rule{Action: "Allow", To: []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "api"}, Port: "80", Protocol: "TCP"}}, ActionPoint: getAPI},
+			want: &api.Rule{
+				EndpointSelector: podSelector(web),
+				Egress: []api.EgressRule{{
+					EgressCommonRule: api.EgressCommonRule{ToEndpoints: endpoints(map[string]string{"app": "api"})},
+					ToPorts:          api.PortRules{{Ports: port80, Rules: httpRules}},
+				}},
+			},
+		},
+		{
+			name:    "block",
+			rule:    v1.Rule{Action: "Block", From: []v1.NetPolDetail{{Kind: "port", Port: "80", Protocol: "TCP"}}, ActionPoint: getAPI},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnp, _, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), newPolicy(selects(web, tt.rule)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Converter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(cnp.Spec, tt.want) {
+				t.Errorf("Converter() Spec = %+v, want %+v", cnp.Spec, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterRules(t *testing.T) {
+	web, apiLabels := map[string]string{"app": "web"}, map[string]string{"app": "api"}
+	fromAPI := []v1.NetPolDetail{{Kind: "endpoint", Labels: apiLabels}}
+	toWorld := []v1.NetPolDetail{{Kind: "entities", Args: []string{"world"}}}
+
+	tests := []struct {
+		name      string
+		intents   []v1.IntentRequest
+		wantSpec  *api.Rule
+		wantSpecs api.Rules
+	}{
+		{
+			name: "intents with different selectors",
+			intents: []v1.IntentRequest{
+				selects(web, v1.Rule{Action: "Allow", From: fromAPI}),
+				selects(apiLabels, v1.Rule{Action: "Block", To: toWorld}),
+			},
+			wantSpec: &api.Rule{
+				EndpointSelector: podSelector(web),
+				Ingress:          []api.IngressRule{{IngressCommonRule: api.IngressCommonRule{FromEndpoints: endpoints(apiLabels)}}},
+			},
+			wantSpecs: api.Rules{{
+				EndpointSelector: podSelector(apiLabels),
+				EgressDeny:       []api.EgressDenyRule{{EgressCommonRule: api.EgressCommonRule{ToEntities: api.EntitySlice{"world"}}}},
+			}},
+		},
+		{
+			name: "allow and block on the same selector",
+			intents: []v1.IntentRequest{
+				selects(web, v1.Rule{Action: "Allow", From: fromAPI}),
+				selects(web, v1.Rule{Action: "Block", To: toWorld}),
+				selects(web, v1.Rule{Action: "Block", From: []v1.NetPolDetail{{Kind: "port", Port: "22", Protocol: "TCP"}}}),
+			},
+			wantSpec: &api.Rule{
+				EndpointSelector: podSelector(web),
+				Ingress:          []api.IngressRule{{IngressCommonRule: api.IngressCommonRule{FromEndpoints: endpoints(apiLabels)}}},
+				IngressDeny:      []api.IngressDenyRule{{ToPorts: api.PortDenyRules{{Ports: []api.PortProtocol{{Port: "22", Protocol: api.ProtoTCP}}}}}},
+				EgressDeny:       []api.EgressDenyRule{{EgressCommonRule: api.EgressCommonRule{ToEntities: api.EntitySlice{"world"}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnp, ccnp, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), newPolicy(tt.intents...))
+			if err != nil {
+				t.Fatalf("Converter() error = %v", err)
+			}
+			if ccnp != nil {
+				t.Errorf("Converter() CiliumClusterwideNetworkPolicy = %+v, want none", ccnp)
+			}
+			if !reflect.DeepEqual(cnp.Spec, tt.wantSpec) {
+				t.Errorf("Converter() Spec = %+v, want %+v", cnp.Spec, tt.wantSpec)
+			}
+			if len(cnp.Specs)+len(tt.wantSpecs) > 0 && !reflect.DeepEqual(cnp.Specs, tt.wantSpecs) {
+				t.Errorf("Converter() Specs = %+v, want %+v", cnp.Specs, tt.wantSpecs)
+			}
+		})
+	}
+}
+
+func TestRuleFor(t *testing.T) {
+	web, apiPods := podSelector(map[string]string{"app": "web"}), podSelector(map[string]string{"app": "api"})
+	var rules []*api.Rule
+
+	first := ruleFor(&rules, web, false)
+	if got := ruleFor(&rules, podSelector(map[string]string{"app": "web"}), false); got != first {
+		t.Errorf("ruleFor() returned a new rule for an equal selector")
+	}
+	if got := ruleFor(&rules, apiPods, false); got == first || !reflect.DeepEqual(got.EndpointSelector, apiPods) {
+		t.Errorf("ruleFor() = %+v, want a new rule for %v", got, apiPods)
+	}
+	if len(rules) != 2 {
+		t.Errorf("ruleFor() added %d rules, want 2", len(rules))
+	}
+
+	var hostRules []*api.Rule
+	host := ruleFor(&hostRules, web, true)
+	if host.NodeSelector.LabelSelector == nil || host.EndpointSelector.LabelSelector != nil || ruleFor(&hostRules, web, true) != host {
+		t.Errorf("ruleFor() host rule = %+v, want one rule selecting nodes", host)
+	}
+}
+
+func TestPeersWithPorts(t *testing.T) {
+	apiLabels := map[string]string{"app": "api"}
+	peers := []v1.NetPolDetail{
+		{Kind: "endpoint", Labels: apiLabels, Port: "8080", Protocol: "TCP"},
+		{Kind: "cidr", Args: []string{"10.0.0.0/8"}, Port: "53", Protocol: "UDP"},
+		{Kind: "entities", Args: []string{"world"}, Port: "443", Protocol: "TCP"},
+		{Kind: "entities", Args: []string{"host"}},
+	}
+	tcp8080 := []api.PortProtocol{{Port: "8080", Protocol: api.ProtoTCP}}
+	udp53 := []api.PortProtocol{{Port: "53", Protocol: api.ProtoUDP}}
+	tcp443 := []api.PortProtocol{{Port: "443", Protocol: api.ProtoTCP}}
+
+	t.Run("ingress", func(t *testing.T) {
+		got, err := getIngress(v1.IntentRequest{Rule: v1.Rule{Action: "Allow", From: peers}}, nil)
+		if err != nil {
+			t.Fatalf("getIngress() error = %v", err)
+		}
+		want := []api.IngressRule{
+			{IngressCommonRule: api.IngressCommonRule{FromEndpoints: endpoints(apiLabels)}, ToPorts: api.PortRules{{Ports: tcp8080}}},
+			{IngressCommonRule: api.IngressCommonRule{FromCIDR: api.CIDRSlice{"10.0.0.0/8"}}, ToPorts: api.PortRules{{Ports: udp53}}},
+			{IngressCommonRule: api.IngressCommonRule{FromEntities: api.EntitySlice{"world"}}, ToPorts: api.PortRules{{Ports: tcp443}}},
+			{IngressCommonRule: api.IngressCommonRule{FromEntities: api.EntitySlice{"host"}}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("getIngress() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("ingress deny", func(t *testing.T) {
+		got, err := getIngressDeny(v1.IntentRequest{Rule: v1.Rule{Action: "Block", From: peers}})
+		if err != nil {
+			t.Fatalf("getIngressDeny() error = %v", err)
+		}
+		want := []api.IngressDenyRule{
+			{IngressCommonRule: api.IngressCommonRule{FromEndpoints: endpoints(apiLabels)}, ToPorts: api.PortDenyRules{{Ports: tcp8080}}},
+			{IngressCommonRule: api.IngressCommonRule{FromCIDR: api.CIDRSlice{"10.0.0.0/8"}}, ToPorts: api.PortDenyRules{{Ports: udp53}}},
+			{IngressCommonRule: api.IngressCommonRule{FromEntities: api.EntitySlice{"world"}}, ToPorts: api.PortDenyRules{{Ports: tcp443}}},
+			{IngressCommonRule: api.IngressCommonRule{FromEntities: api.EntitySlice{"host"}}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("getIngressDeny() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("egress deny", func(t *testing.T) {
+		got, err := getEgressDeny(v1.IntentRequest{Rule: v1.Rule{Action: "Block", To: peers}})
+		if err != nil {
+			t.Fatalf("getEgressDeny() error = %v", err)
+		}
+		want := []api.EgressDenyRule{
+			{EgressCommonRule: api.EgressCommonRule{ToEndpoints: endpoints(apiLabels)}, ToPorts: api.PortDenyRules{{Ports: tcp8080}}},
+			{EgressCommonRule: api.EgressCommonRule{ToCIDR: api.CIDRSlice{"10.0.0.0/8"}}, ToPorts: api.PortDenyRules{{Ports: udp53}}},
+			{EgressCommonRule: api.EgressCommonRule{ToEntities: api.EntitySlice{"world"}}, ToPorts: api.PortDenyRules{{Ports: tcp443}}},
+			{EgressCommonRule: api.EgressCommonRule{ToEntities: api.EntitySlice{"host"}}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("getEgressDeny() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestToPortProtocols(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    v1.NetPolDetail
+		want    []api.PortProtocol
+		wantErr bool
+	}{
+		{
+			name: "single port",
+			rule: v1.NetPolDetail{Port: "80", Protocol: "TCP"},
+			want: []api.PortProtocol{{Port: "80", Protocol: api.ProtoTCP}},
+		},
+		{
+			name: "range",
+			rule: v1.NetPolDetail{Port: "8000-8080", Protocol: "TCP"},
+			want: []api.PortProtocol{{Port: "8000", EndPort: 8080, Protocol: api.ProtoTCP}},
+		},
+		{
+			name: "range of one port",
+			rule: v1.NetPolDetail{Port: "9000-9000", Protocol: "UDP"},
+			want: []api.PortProtocol{{Port: "9000", Protocol: api.ProtoUDP}},
+		},
+		{
+			name: "named ports are left to Cilium",
+			rule: v1.NetPolDetail{Port: "http, 443, 30000-32767", Protocol: "TCP"},
+			want: []api.PortProtocol{
+				{Port: "http", Protocol: api.ProtoTCP},
+				{Port: "443", Protocol: api.ProtoTCP},
+				{Port: "30000", EndPort: 32767, Protocol: api.ProtoTCP},
+			},
+		},
+		{
+			name: "no port",
+			rule: v1.NetPolDetail{Protocol: "TCP"},
+			want: []api.PortProtocol{},
+		},
+		{
+			name:    "reversed range",
+			rule:    v1.NetPolDetail{Port: "8080-8000"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			rule:    v1.NetPolDetail{Port: "http_alt"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toPortProtocols(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toPortProtocols() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toPortProtocols() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterClusterwide(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	workers := map[string]string{"node-role.kubernetes.io/worker": ""}
+	nodes := func(rule v1.Rule, names ...string) v1.IntentRequest {
+		intentRequest := v1.IntentRequest{Type: "network", Rule: rule}
+		for _, name := range names {
+			intentRequest.Selector.Match = append(intentRequest.Selector.Match, v1.Match{Kind: "Node", Name: name})
+		}
+		return intentRequest
+	}
+	nodeSelector := func(name string) api.EndpointSelector {
+		return toEndpointSelector(metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/hostname": name}})
+	}
+	ssh := []v1.NetPolDetail{{Kind: "port", Port: "22", Protocol: "TCP"}}
+	sshDeny := []api.IngressDenyRule{{ToPorts: api.PortDenyRules{{Ports: []api.PortProtocol{{Port: "22", Protocol: api.ProtoTCP}}}}}}
+	fromWorkers := []v1.NetPolDetail{{Kind: "node", Labels: workers}}
+
+	tests := []struct {
+		name     string
+		intents  []v1.IntentRequest
+		wantCNP  *api.Rule
+		wantCCNP api.Rules
+	}{
+		{
+			name:    "node peers stay namespaced",
+			intents: []v1.IntentRequest{selects(web, v1.Rule{Action: "Allow", From: fromWorkers})},
+			wantCNP: &api.Rule{
+				EndpointSelector: podSelector(web),
+				Ingress:          []api.IngressRule{{IngressCommonRule: api.IngressCommonRule{FromNodes: endpoints(workers)}}},
+			},
+		},
+		{
+			name:    "node selector",
+			intents: []v1.IntentRequest{nodes(v1.Rule{Action: "Block", From: ssh}, "node-1", "node-2")},
+			wantCCNP: api.Rules{
+				{NodeSelector: nodeSelector("node-1"), IngressDeny: sshDeny},
+				{NodeSelector: nodeSelector("node-2"), IngressDeny: sshDeny},
+			},
+		},
+		{
+			name: "node selector with node peers next to a pod intent",
+			intents: []v1.IntentRequest{
+				selects(web, v1.Rule{Action: "Block", From: ssh}),
+				nodes(v1.Rule{Action: "Allow", From: fromWorkers}, "node-1"),
+			},
+			wantCNP: &api.Rule{EndpointSelector: podSelector(web), IngressDeny: sshDeny},
+			wantCCNP: api.Rules{{
+				NodeSelector: nodeSelector("node-1"),
+				Ingress:      []api.IngressRule{{IngressCommonRule: api.IngressCommonRule{FromNodes: endpoints(workers)}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnp, ccnp, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), newPolicy(tt.intents...))
+			if err != nil {
+				t.Fatalf("Converter() error = %v", err)
+			}
+
+			if tt.wantCNP == nil && cnp != nil {
+				t.Errorf("Converter() CiliumNetworkPolicy = %+v, want none", cnp)
+			}
+			if tt.wantCNP != nil && (cnp == nil || cnp.Name != "cnp-web" || cnp.Namespace != "shop" || !reflect.DeepEqual(cnp.Spec, tt.wantCNP)) {
+				t.Errorf("Converter() CiliumNetworkPolicy = %+v, want cnp-web in shop with Spec %+v", cnp, tt.wantCNP)
+			}
+
+			if tt.wantCCNP == nil {
+				if ccnp != nil {
+					t.Errorf("Converter() CiliumClusterwideNetworkPolicy = %+v, want none", ccnp)
+				}
+				return
+			}
+			if ccnp == nil {
+				t.Fatalf("Converter() returned no CiliumClusterwideNetworkPolicy")
+			}
+			wantLabels := map[string]string{OriginPolicyLabel: "web", OriginNamespaceLabel: "shop"}
+			if ccnp.Name != "ccnp-shop-web" || ccnp.Namespace != "" || !reflect.DeepEqual(ccnp.Labels, wantLabels) {
+				t.Errorf("Converter() CiliumClusterwideNetworkPolicy %q in %q with labels %v, want ccnp-shop-web with labels %v", ccnp.Name, ccnp.Namespace, ccnp.Labels, wantLabels)
+			}
+			got := append(api.Rules{ccnp.Spec}, ccnp.Specs...)
+			if !reflect.DeepEqual(got, tt.wantCCNP) {
+				t.Errorf("Converter() CiliumClusterwideNetworkPolicy rules = %+v, want %+v", got, tt.wantCCNP)
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+package converter
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+	"github.com/cclab-inu/KubeAegis/pkg/importer"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/policy/api"
+)
+
+// Cilium label sources a KAP label stands for, and those it cannot express.
+var (
+	kapLabelSources   = []string{"any", "k8s"}
+	otherLabelSources = []string{"reserved", "cidr", "cidrgroup", "fqdn", "unspec", "container", "cilium-generated", "kvstore", "node"}
+)
+
+// importGroup is the KubeAegisPolicy made from the rules of a
+// CiliumNetworkPolicy that allow and deny the same traffic.
+type importGroup struct {
+	intents           []v1.IntentRequest
+	namespaceSelector *metav1.LabelSelector
+	matches           []v1.Match
+}
+
+// Import converts a CiliumNetworkPolicy back into KubeAegisPolicies. Rules of
+// spec and specs that differ only in their endpoint selector are merged into
+// one KubeAegisPolicy, as Converter splits them; any other rule gets its own.
+// Rules the converter cannot produce are left out, and every field that was
+// not imported is reported.
+func Import(cnp *ciliumv2.CiliumNetworkPolicy) ([]*v1.KubeAegisPolicy, validator.ResultList, error) {
+	mapped := importer.NewMapped()
+
+	type pathRule struct {
+		rule *api.Rule
+		path *field.Path
+	}
+	var rules []pathRule
+	if cnp.Spec != nil {
+		rules = append(rules, pathRule{cnp.Spec, field.NewPath("spec")})
+	}
+	for i, rule := range cnp.Specs {
+		if rule != nil {
+			rules = append(rules, pathRule{rule, field.NewPath("specs").Index(i)})
+		}
+	}
+
+	var groups []*importGroup
+	for _, r := range rules {
+		match, namespaceSelector, ok := importEndpointSelector(r.rule.EndpointSelector)
+		if !ok {
+			continue
+		}
+		intents := importRule(r.rule, r.path, cnp.Namespace, mapped)
+		if len(intents) == 0 {
+			continue
+		}
+		mapped.Add(r.path.Child("endpointSelector"))
+
+		var group *importGroup
+		for _, g := range groups {
+			if reflect.DeepEqual(g.intents, intents) && reflect.DeepEqual(g.namespaceSelector, namespaceSelector) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &importGroup{intents: intents, namespaceSelector: namespaceSelector}
+			groups = append(groups, group)
+		}
+		group.matches = append(group.matches, match)
+	}
+
+	kaps := make([]*v1.KubeAegisPolicy, 0, len(groups))
+	for i, group := range groups {
+		kap := importer.NewPolicy(cnp.Name, "cnp-", cnp.Namespace)
+		if i > 0 {
+			kap.Name += "-" + strconv.Itoa(i+1)
+		}
+		for _, intent := range group.intents {
+			intent.Selector = v1.Selector{Match: group.matches, NamespaceSelector: group.namespaceSelector}
+			kap.Spec.IntentRequest = append(kap.Spec.IntentRequest, intent)
+		}
+		kaps = append(kaps, kap)
+	}
+
+	results, err := mapped.Unmapped(cnp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kaps, results, nil
+}
+
+// importRule converts the ingress and egress rules of a Cilium rule into intent
+// requests without a selector, in the form Converter reads them.
+func importRule(rule *api.Rule, path *field.Path, namespace string, mapped *importer.Mapped) []v1.IntentRequest {
+	var intents []v1.IntentRequest
+
+	var denyFrom []v1.NetPolDetail
+	for i := range rule.IngressDeny {
+		ingressDeny := rule.IngressDeny[i]
+		portRules := ingressDeny.ToPorts
+		ingressDeny.ToPorts = nil
+		if !importer.OnlySet(&ingressDeny, "fromEndpoints", "fromEntities", "fromCIDR", "fromCIDRSet") {
+			continue
+		}
+		peers, ok := importPeers(ingressDeny.FromEndpoints, ingressDeny.FromEntities, ingressDeny.FromCIDR, ingressDeny.FromCIDRSet)
+		if !ok {
+			continue
+		}
+		var ports []v1.NetPolDetail
+		if len(portRules) > 0 {
+			if ports, ok = importPortDenyRules(portRules); !ok {
+				continue
+			}
+		}
+		denyFrom = append(denyFrom, withPorts(peers, ports)...)
+		mapped.Add(path.Child("ingressDeny").Index(i))
+	}
+	if len(denyFrom) > 0 {
+		intents = append(intents, v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Block", From: denyFrom}})
+	}
+
+	var denyTo []v1.NetPolDetail
+	for i := range rule.EgressDeny {
+		egressDeny := rule.EgressDeny[i]
+		portRules := egressDeny.ToPorts
+		egressDeny.ToPorts = nil
+		if !importer.OnlySet(&egressDeny, "toEndpoints", "toEntities", "toCIDR", "toCIDRSet") {
+			continue
+		}
+		peers, ok := importPeers(egressDeny.ToEndpoints, egressDeny.ToEntities, egressDeny.ToCIDR, egressDeny.ToCIDRSet)
+		if !ok {
+			continue
+		}
+		var ports []v1.NetPolDetail
+		if len(portRules) > 0 {
+			if ports, ok = importPortDenyRules(portRules); !ok {
+				continue
+			}
+		}
+		denyTo = append(denyTo, withPorts(peers, ports)...)
+		mapped.Add(path.Child("egressDeny").Index(i))
+	}
+	if len(denyTo) > 0 {
+		intents = append(intents, v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Block", To: denyTo}})
+	}
+
+	// The port rules of an intent all carry the HTTP rules of its http
+	// action points, so port rules with other HTTP rules are left out.
+	var allowFrom []v1.NetPolDetail
+	var ingressHTTP httpRules
+	for i := range rule.Ingress {
+		ingress := rule.Ingress[i]
+		portRules := ingress.ToPorts
+		ingress.ToPorts = nil
+		if !importer.OnlySet(&ingress, "fromEndpoints", "fromEntities", "fromCIDR", "fromCIDRSet") {
+			continue
+		}
+		peers, ok := importPeers(ingress.FromEndpoints, ingress.FromEntities, ingress.FromCIDR, ingress.FromCIDRSet)
+		if !ok {
+			continue
+		}
+		var ports []v1.NetPolDetail
+		if len(portRules) > 0 {
+			var http []api.PortRuleHTTP
+			if ports, http, ok = importPortRules(portRules); !ok || !ingressHTTP.add(http) {
+				continue
+			}
+		}
+		allowFrom = append(allowFrom, withPorts(peers, ports)...)
+		mapped.Add(path.Child("ingress").Index(i))
+	}
+	if len(allowFrom) > 0 {
+		intents = append(intents, v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Allow", From: allowFrom, ActionPoint: ingressHTTP.actionPoints()}})
+	}
+
+	var allowTo []v1.NetPolDetail
+	var egressHTTP httpRules
+	dnsProxy, hasFQDNs := -1, false
+	for i := range rule.Egress {
+		if isDNSProxyRule(&rule.Egress[i]) {
+			dnsProxy = i
+			continue
+		}
+		egress := rule.Egress[i]
+		portRules := egress.ToPorts
+		egress.ToPorts = nil
+		var targets []v1.NetPolDetail
+		ok := true
+		if !importer.OnlySet(&egress) {
+			// The converter never restricts service targets to ports.
+			if targets, ok = importEgress(&egress, namespace); !ok || (len(egress.ToServices) > 0 && len(portRules) > 0) {
+				continue
+			}
+		}
+		var ports []v1.NetPolDetail
+		if len(portRules) > 0 {
+			var http []api.PortRuleHTTP
+			if ports, http, ok = importPortRules(portRules); !ok || !egressHTTP.add(http) {
+				continue
+			}
+		}
+		targets = withPorts(targets, ports)
+		for _, target := range targets {
+			hasFQDNs = hasFQDNs || target.Kind == "fqdns"
+		}
+		allowTo = append(allowTo, targets...)
+		mapped.Add(path.Child("egress").Index(i))
+	}
+	// The converter adds the DNS proxy rule for FQDN targets only, so on its
+	// own it is reported like any other rule.
+	if dnsProxy >= 0 && hasFQDNs {
+		mapped.Add(path.Child("egress").Index(dnsProxy))
+	}
+	if len(allowTo) > 0 {
+		intents = append(intents, v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Allow", To: allowTo, ActionPoint: egressHTTP.actionPoints()}})
+	}
+
+	return intents
+}
+
+// withPorts restricts the peers of a rule to its ports, as the converter
+// renders the port and protocol of a from or to entry. Without peers, the
+// ports stand on their own.
+func withPorts(peers, ports []v1.NetPolDetail) []v1.NetPolDetail {
+	if len(ports) == 0 {
+		return peers
+	}
+	if len(peers) == 0 {
+		return ports
+	}
+	details := make([]v1.NetPolDetail, 0, len(peers)*len(ports))
+	for _, peer := range peers {
+		for _, port := range ports {
+			peer.Port, peer.Protocol = port.Port, port.Protocol
+			details = append(details, peer)
+		}
+	}
+	return details
+}
+
+// importEgress converts the peers of an egress rule into the targets it
+// allows, as getEgress produces them.
+func importEgress(egress *api.EgressRule, namespace string) ([]v1.NetPolDetail, bool) {
+	switch {
+	case importer.OnlySet(egress, "toEndpoints", "toEntities", "toCIDR", "toCIDRSet"):
+		return importPeers(egress.ToEndpoints, egress.ToEntities, egress.ToCIDR, egress.ToCIDRSet)
+	case importer.OnlySet(egress, "toFQDNs"):
+		detail := v1.NetPolDetail{Kind: "fqdns"}
+		for _, fqdn := range egress.ToFQDNs {
+			switch {
+			case fqdn.MatchName != "" && fqdn.MatchPattern == "":
+				detail.Args = append(detail.Args, fqdn.MatchName)
+			case fqdn.MatchPattern != "" && fqdn.MatchName == "":
+				detail.Args = append(detail.Args, fqdn.MatchPattern)
+			default:
+				return nil, false
+			}
+		}
+		return []v1.NetPolDetail{detail}, true
+	case importer.OnlySet(egress, "toServices"):
+		var details []v1.NetPolDetail
+		for _, service := range egress.ToServices {
+			detail, ok := importService(service, namespace)
+			if !ok {
+				return nil, false
+			}
+			details = append(details, detail)
+		}
+		return details, true
+	}
+	return nil, false
+}
+
+// isDNSProxyRule reports whether the egress rule is the one dnsProxyRule
+// returns.
+func isDNSProxyRule(egress *api.EgressRule) bool {
+	if !importer.OnlySet(egress, "toEndpoints", "toPorts") || len(egress.ToEndpoints) != 1 || len(egress.ToPorts) != 1 {
+		return false
+	}
+	labels, ok := endpointLabels(egress.ToEndpoints[0])
+	if !ok || !reflect.DeepEqual(labels, map[string]string{"io.kubernetes.pod.namespace": "kube-system", "k8s-app": "kube-dns"}) {
+		return false
+	}
+	portRule := egress.ToPorts[0]
+	return len(portRule.Ports) == 1 && portRule.Ports[0].Port == "53" && portRule.Ports[0].Protocol == api.ProtoAny &&
+		portRule.Rules != nil && len(portRule.Rules.DNS) == 1 && portRule.Rules.DNS[0].MatchPattern == "*" && portRule.Rules.DNS[0].MatchName == ""
+}
+
+// importPortRules converts the port rules of an ingress or egress rule into
+// port rules, one per protocol, and returns the HTTP rules they share.
+func importPortRules(portRules api.PortRules) ([]v1.NetPolDetail, []api.PortRuleHTTP, bool) {
+	var details []v1.NetPolDetail
+	var http []api.PortRuleHTTP
+	for i := range portRules {
+		portRule := &portRules[i]
+		if !importer.OnlySet(portRule, "ports", "rules") {
+			return nil, nil, false
+		}
+		var ruleHTTP []api.PortRuleHTTP
+		if portRule.Rules != nil {
+			if !importer.OnlySet(portRule.Rules, "http") {
+				return nil, nil, false
+			}
+			ruleHTTP = portRule.Rules.HTTP
+		}
+		if i > 0 && !reflect.DeepEqual(ruleHTTP, http) {
+			return nil, nil, false
+		}
+		http = ruleHTTP
+
+		ports, ok := importPorts(portRule.Ports)
+		if !ok {
+			return nil, nil, false
+		}
+		details = append(details, ports...)
+	}
+	return details, http, len(details) > 0
+}
+
+// httpRules collects the HTTP rules of the port rules of an intent.
+type httpRules struct {
+	rules []api.PortRuleHTTP
+	set   bool
+}
+
+// add reports whether the HTTP rules of a port rule can be imported, that is
+// whether they are those of the intent's other port rules and each is a
+// method, path and headers.
+func (h *httpRules) add(rules []api.PortRuleHTTP) bool {
+	if h.set {
+		return reflect.DeepEqual(h.rules, rules)
+	}
+	for i := range rules {
+		if !importer.OnlySet(&rules[i], "method", "path", "headers") {
+			return false
+		}
+	}
+	h.rules, h.set = rules, true
+	return true
+}
+
+// actionPoints converts the HTTP rules into http action points, one per rule,
+// which getHTTPRules turns back into the same rules.
+func (h *httpRules) actionPoints() []v1.ActionPoint {
+	var points []v1.ActionPoint
+	for _, rule := range h.rules {
+		point := v1.ActionPoint{SubType: "http"}
+		if rule.Method != "" {
+			point.Resource.Methods = []string{rule.Method}
+		}
+		if rule.Path != "" {
+			point.Resource.Path = []string{rule.Path}
+		}
+		for _, header := range rule.Headers {
+			name, value, _ := strings.Cut(header, ":")
+			point.Headers = append(point.Headers, v1.EventHeader{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// importService converts a Cilium service into a service rule.
+func importService(service api.Service, namespace string) (v1.NetPolDetail, bool) {
+	switch {
+	case service.K8sService != nil && service.K8sServiceSelector == nil:
+		detail := v1.NetPolDetail{Kind: "service", Args: []string{service.K8sService.ServiceName}}
+		if service.K8sService.Namespace != "" && service.K8sService.Namespace != namespace {
+			detail.Args = append(detail.Args, service.K8sService.Namespace)
+		}
+		return detail, true
+	case service.K8sServiceSelector != nil && service.K8sService == nil:
+		// Selected Services are looked up in the policy's namespace only.
+		if service.K8sServiceSelector.Namespace != "" && service.K8sServiceSelector.Namespace != namespace {
+			return v1.NetPolDetail{}, false
+		}
+		labels, ok := endpointLabels(api.EndpointSelector(service.K8sServiceSelector.Selector))
+		if !ok {
+			return v1.NetPolDetail{}, false
+		}
+		return v1.NetPolDetail{Kind: "service", Labels: labels}, true
+	}
+	return v1.NetPolDetail{}, false
+}
+
+// importPeers converts the peers of a rule into endpoint, entities and cidr
+// rules. It fails when one of them cannot be expressed, since leaving it out
+// would change the traffic the rule applies to.
+func importPeers(endpoints []api.EndpointSelector, entities api.EntitySlice, cidrs api.CIDRSlice, cidrSet api.CIDRRuleSlice) ([]v1.NetPolDetail, bool) {
+	var details []v1.NetPolDetail
+	for _, endpoint := range endpoints {
+		labels, ok := endpointLabels(endpoint)
+		if !ok {
+			return nil, false
+		}
+		details = append(details, v1.NetPolDetail{Kind: "endpoint", Labels: labels})
+	}
+
+	if len(entities) > 0 {
+		detail := v1.NetPolDetail{Kind: "entities"}
+		for _, entity := range entities {
+			detail.Args = append(detail.Args, string(entity))
+		}
+		details = append(details, detail)
+	}
+
+	if len(cidrs) > 0 {
+		detail := v1.NetPolDetail{Kind: "cidr"}
+		for _, cidr := range cidrs {
+			detail.Args = append(detail.Args, string(cidr))
+		}
+		details = append(details, detail)
+	}
+
+	for i := range cidrSet {
+		if !importer.OnlySet(&cidrSet[i], "cidr", "except") {
+			return nil, false
+		}
+		detail := v1.NetPolDetail{Kind: "cidr", Args: []string{string(cidrSet[i].Cidr)}}
+		for _, except := range cidrSet[i].ExceptCIDRs {
+			detail.Except = append(detail.Except, string(except))
+		}
+		details = append(details, detail)
+	}
+	return details, true
+}
+
+// importPortDenyRules converts the ports of egress deny rules into port rules,
+// one per protocol.
+func importPortDenyRules(portRules api.PortDenyRules) ([]v1.NetPolDetail, bool) {
+	var details []v1.NetPolDetail
+	for i := range portRules {
+		if !importer.OnlySet(&portRules[i], "ports") {
+			return nil, false
+		}
+		ports, ok := importPorts(portRules[i].Ports)
+		if !ok {
+			return nil, false
+		}
+		details = append(details, ports...)
+	}
+	return details, len(details) > 0
+}
+
+// importPorts converts Cilium ports into port rules, one per protocol.
+func importPorts(portProtocols []api.PortProtocol) ([]v1.NetPolDetail, bool) {
+	var protocols []string
+	ports := map[string][]string{}
+	for _, portProtocol := range portProtocols {
+		if portProtocol.Port == "" || portProtocol.Port == "0" {
+			return nil, false
+		}
+		port := portProtocol.Port
+		if portProtocol.EndPort > 0 {
+			port += "-" + strconv.Itoa(int(portProtocol.EndPort))
+		}
+
+		// The converter leaves the protocol empty for any protocol.
+		protocol := string(portProtocol.Protocol)
+		if portProtocol.Protocol == api.ProtoAny {
+			protocol = ""
+		}
+		if _, ok := ports[protocol]; !ok {
+			protocols = append(protocols, protocol)
+		}
+		ports[protocol] = append(ports[protocol], port)
+	}
+
+	details := make([]v1.NetPolDetail, 0, len(protocols))
+	for _, protocol := range protocols {
+		details = append(details, v1.NetPolDetail{Kind: "port", Port: strings.Join(ports[protocol], ","), Protocol: protocol})
+	}
+	return details, len(details) > 0
+}
+
+// importEndpointSelector converts the endpoint selector of a rule into a Match
+// entry and the namespaceSelector that Converter turns into Cilium's
+// namespace labels.
+func importEndpointSelector(endpointSelector api.EndpointSelector) (v1.Match, *metav1.LabelSelector, bool) {
+	match := v1.Match{Kind: "Pod"}
+	if endpointSelector.LabelSelector == nil {
+		return match, nil, true
+	}
+
+	namespaceSelector := &metav1.LabelSelector{}
+	for key, value := range endpointSelector.MatchLabels {
+		key, ok := trimLabelSource(key)
+		if !ok {
+			return v1.Match{}, nil, false
+		}
+		if namespaceKey, isNamespaceLabel := strings.CutPrefix(key, processor.CiliumNamespaceLabelPrefix); isNamespaceLabel {
+			if namespaceSelector.MatchLabels == nil {
+				namespaceSelector.MatchLabels = map[string]string{}
+			}
+			namespaceSelector.MatchLabels[namespaceKey] = string(value)
+			continue
+		}
+		if match.MatchLabels == nil {
+			match.MatchLabels = map[string]string{}
+		}
+		match.MatchLabels[key] = string(value)
+	}
+
+	for _, requirement := range endpointSelector.MatchExpressions {
+		key, ok := trimLabelSource(requirement.Key)
+		if !ok {
+			return v1.Match{}, nil, false
+		}
+		expression := metav1.LabelSelectorRequirement{
+			Key:      key,
+			Operator: metav1.LabelSelectorOperator(requirement.Operator),
+			Values:   requirement.Values,
+		}
+		if namespaceKey, isNamespaceLabel := strings.CutPrefix(key, processor.CiliumNamespaceLabelPrefix); isNamespaceLabel {
+			expression.Key = namespaceKey
+			namespaceSelector.MatchExpressions = append(namespaceSelector.MatchExpressions, expression)
+			continue
+		}
+		match.MatchExpressions = append(match.MatchExpressions, expression)
+	}
+
+	if len(namespaceSelector.MatchLabels) == 0 && len(namespaceSelector.MatchExpressions) == 0 {
+		namespaceSelector = nil
+	}
+	return match, namespaceSelector, true
+}
+
+// endpointLabels returns the labels of a peer endpoint selector, which the
+// converter takes as matchLabels only.
+func endpointLabels(endpointSelector api.EndpointSelector) (map[string]string, bool) {
+	if endpointSelector.LabelSelector == nil || len(endpointSelector.MatchExpressions) > 0 || len(endpointSelector.MatchLabels) == 0 {
+		return nil, false
+	}
+
+	labels := make(map[string]string, len(endpointSelector.MatchLabels))
+	for key, value := range endpointSelector.MatchLabels {
+		key, ok := trimLabelSource(key)
+		if !ok {
+			return nil, false
+		}
+		labels[key] = string(value)
+	}
+	return labels, true
+}
+
+// trimLabelSource strips the any or k8s source from a Cilium label key, in
+// either its "source:key" or its decoded "source.key" form. Keys of other
+// sources, such as reserved:host, have no KAP equivalent.
+func trimLabelSource(key string) (string, bool) {
+	for _, source := range kapLabelSources {
+		for _, delimiter := range []string{":", "."} {
+			if trimmed, ok := strings.CutPrefix(key, source+delimiter); ok {
+				return trimmed, true
+			}
+		}
+	}
+	if strings.Contains(key, ":") {
+		return "", false
+	}
+	for _, source := range otherLabelSources {
+		if strings.HasPrefix(key, source+".") {
+			return "", false
+		}
+	}
+	return key, true
+}
@@ -2,6 +2,7 @@ package enforcer
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -9,6 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-cilium/converter"
 	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
 	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
 )
@@ -36,12 +38,101 @@ func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 		}
 	} else {
 		logger.Info("CiliumNetworkPolicy updated", "PolicyName", cnp.Name, "Cilium.Namespace", cnp.Namespace)
+		before := existingPolicy.DeepCopy()
 		existingPolicy.Spec = cnp.Spec
+		existingPolicy.Specs = cnp.Specs
 		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
 			logger.Error(err, "failed to update CiliumNetworkPolicy", "Cilium.Name", cnp.Name, "Cilium.Namespace", cnp.Namespace)
 			return "", err
 		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "CiliumNetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to CiliumNetworkPolicy", "Cilium.Name", cnp.Name, "Cilium.Namespace", cnp.Namespace)
+		}
 	}
 
 	return cnp.Name, nil
 }
+
+// EnforceClusterwide creates or updates the CiliumClusterwideNetworkPolicy of
+// the node intents. It carries the origin labels instead of an owner reference.
+func EnforceClusterwide(ctx context.Context, k8sClient client.Client, logger logr.Logger, ccnp *ciliumv2.CiliumClusterwideNetworkPolicy, kap *v1.KubeAegisPolicy) (string, error) {
+	existingPolicy := &ciliumv2.CiliumClusterwideNetworkPolicy{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: ccnp.Name}, existingPolicy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to fetch CiliumClusterwideNetworkPolicy", "Cilium.Name", ccnp.Name)
+		return "", err
+	}
+
+	if apierrors.IsNotFound(err) {
+		logger.Info("CiliumClusterwideNetworkPolicy enforced", "Cilium.Name", ccnp.Name)
+		if err := k8sClient.Create(ctx, ccnp); err != nil {
+			logger.Error(err, "failed to create CiliumClusterwideNetworkPolicy", "Cilium.Name", ccnp.Name)
+			return "", err
+		}
+	} else {
+		logger.Info("CiliumClusterwideNetworkPolicy updated", "Cilium.Name", ccnp.Name)
+		before := existingPolicy.DeepCopy()
+		existingPolicy.Labels = ccnp.Labels
+		existingPolicy.Spec = ccnp.Spec
+		existingPolicy.Specs = ccnp.Specs
+		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
+			logger.Error(err, "failed to update CiliumClusterwideNetworkPolicy", "Cilium.Name", ccnp.Name)
+			return "", err
+		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "CiliumClusterwideNetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to CiliumClusterwideNetworkPolicy", "Cilium.Name", ccnp.Name)
+		}
+	}
+
+	return ccnp.Name, nil
+}
+
+// PruneClusterwide deletes the CiliumClusterwideNetworkPolicies labeled with
+// the KubeAegisPolicy, except the one named keep.
+func PruneClusterwide(ctx context.Context, k8sClient client.Client, logger logr.Logger, kapName, kapNamespace, keep string) error {
+	var policies ciliumv2.CiliumClusterwideNetworkPolicyList
+	if err := k8sClient.List(ctx, &policies, client.MatchingLabels{
+		converter.OriginPolicyLabel:    kapName,
+		converter.OriginNamespaceLabel: kapNamespace,
+	}); err != nil {
+		logger.Error(err, "failed to list CiliumClusterwideNetworkPolicies", "KubeAegis.Name", kapName)
+		return err
+	}
+
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if policy.Name == keep {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete CiliumClusterwideNetworkPolicy", "Cilium.Name", policy.Name)
+			return err
+		}
+		logger.Info("CiliumClusterwideNetworkPolicy deleted", "Cilium.Name", policy.Name)
+	}
+	return nil
+}
+
+// CleanupClusterwide deletes the CiliumClusterwideNetworkPolicies of a deleted
+// KubeAegisPolicy. The controller names the deleted policy after its
+// KubeArmorPolicy.
+func CleanupClusterwide(ctx context.Context, k8sClient client.Client, logger logr.Logger, policyName, kapNamespace string) error {
+	return PruneClusterwide(ctx, k8sClient, logger, strings.TrimPrefix(policyName, "ksp-"), kapNamespace, "")
+}
+
+// Remove deletes the CiliumNetworkPolicy of a KubeAegisPolicy that no longer
+// has intents selecting pods.
+func Remove(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) error {
+	name, namespace := converter.GenerateCNPName(kap.Name), kap.Namespace
+	cnp := &ciliumv2.CiliumNetworkPolicy{}
+	cnp.Name, cnp.Namespace = name, namespace
+	if err := k8sClient.Delete(ctx, cnp); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "failed to delete CiliumNetworkPolicy", "Cilium.Name", name, "Cilium.Namespace", namespace)
+		return err
+	}
+	logger.Info("CiliumNetworkPolicy deleted", "Cilium.Name", name, "Cilium.Namespace", namespace)
+	return nil
+}
@@ -0,0 +1,100 @@
+package enforcer
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-cilium/converter"
+	ciliumv2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+)
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := ciliumv2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newCCNP(name string, labels map[string]string) *ciliumv2.CiliumClusterwideNetworkPolicy {
+	return &ciliumv2.CiliumClusterwideNetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func ccnpNames(t *testing.T, k8sClient client.Client) []string {
+	t.Helper()
+	var policies ciliumv2.CiliumClusterwideNetworkPolicyList
+	if err := k8sClient.List(context.Background(), &policies); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, policy := range policies.Items {
+		names = append(names, policy.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCleanupClusterwide(t *testing.T) {
+	ctx := context.Background()
+	kap := &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: v1.KubeAegisPolicySpec{IntentRequest: []v1.IntentRequest{{
+			Type:     "network",
+			Selector: v1.Selector{Match: []v1.Match{{Kind: "Node", Name: "node-1"}}},
+			Rule:     v1.Rule{Action: "Block", From: []v1.NetPolDetail{{Kind: "port", Port: "22", Protocol: "TCP"}}},
+		}}},
+	}
+	_, ccnp, err := converter.Converter(ctx, newFakeClient(t), logr.Discard(), kap)
+	if err != nil || ccnp == nil {
+		t.Fatalf("Converter() = %v, %v, want a CiliumClusterwideNetworkPolicy", ccnp, err)
+	}
+
+	k8sClient := newFakeClient(t,
+		newCCNP("ccnp-shop-old", map[string]string{converter.OriginPolicyLabel: "web", converter.OriginNamespaceLabel: "shop"}),
+		newCCNP("ccnp-staging-web", map[string]string{converter.OriginPolicyLabel: "web", converter.OriginNamespaceLabel: "staging"}),
+		newCCNP("ccnp-shop-api", map[string]string{converter.OriginPolicyLabel: "api", converter.OriginNamespaceLabel: "shop"}),
+		newCCNP("unmanaged", nil),
+	)
+	if _, err := EnforceClusterwide(ctx, k8sClient, logr.Discard(), ccnp, kap); err != nil {
+		t.Fatalf("EnforceClusterwide() error = %v", err)
+	}
+	want := []string{"ccnp-shop-api", "ccnp-shop-old", "ccnp-shop-web", "ccnp-staging-web", "unmanaged"}
+	if got := ccnpNames(t, k8sClient); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CiliumClusterwideNetworkPolicies = %q, want %q", got, want)
+	}
+
+	// The controller reports the deletion under the KubeArmorPolicy name.
+	if err := CleanupClusterwide(ctx, k8sClient, logr.Discard(), "ksp-web", "shop"); err != nil {
+		t.Fatalf("CleanupClusterwide() error = %v", err)
+	}
+	want = []string{"ccnp-shop-api", "ccnp-staging-web", "unmanaged"}
+	if got := ccnpNames(t, k8sClient); !reflect.DeepEqual(got, want) {
+		t.Errorf("CiliumClusterwideNetworkPolicies after cleanup = %q, want %q", got, want)
+	}
+}
+
+func TestPruneClusterwide(t *testing.T) {
+	labels := map[string]string{converter.OriginPolicyLabel: "web", converter.OriginNamespaceLabel: "shop"}
+	k8sClient := newFakeClient(t, newCCNP("ccnp-shop-web", labels), newCCNP("ccnp-shop-old", labels))
+
+	if err := PruneClusterwide(context.Background(), k8sClient, logr.Discard(), "web", "shop", "ccnp-shop-web"); err != nil {
+		t.Fatalf("PruneClusterwide() error = %v", err)
+	}
+	if got, want := ccnpNames(t, k8sClient), []string{"ccnp-shop-web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CiliumClusterwideNetworkPolicies = %q, want %q", got, want)
+	}
+}
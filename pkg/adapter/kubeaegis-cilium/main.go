@@ -19,12 +19,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	pb "github.com/cclab-inu/KubeAegis/api/grpc"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/common"
 	"github.com/cclab-inu/KubeAegis/pkg/adapter/k8s"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-cilium/converter"
 	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-cilium/manager"
 )
 
 const adapterName = "kubeaegis-cilium"
 
+// buildVersion is overridden at build time with -ldflags "-X main.buildVersion=<tag>".
+var buildVersion = "dev"
+
 type server struct {
 	pb.UnimplementedPolicyServiceServer
 }
@@ -32,6 +37,10 @@ type server struct {
 func (s *server) DispatchPolicy(ctx context.Context, in *pb.PolicyRequest) (*pb.PolicyResponse, error) {
 	logger := ctrl.Log.WithName("main")
 	logger.Info("KubeAegis arrived", "KubeAegis.Name", in.GetPolicyName(), "KubeAegis.Namespace", in.GetPolicyNamespace())
+	if err := common.CheckProtocolVersion(adapterName, in.GetProtocolVersion()); err != nil {
+		logger.Error(err, "rejecting KubeAegis from incompatible controller")
+		return nil, err
+	}
 
 	kspname, _ := manager.Run(ctx, logger, in.GetPolicyName(), in.GetPolicyNamespace())
 
@@ -42,9 +51,17 @@ func (s *server) DispatchPolicy(ctx context.Context, in *pb.PolicyRequest) (*pb.
 	}, nil
 }
 
+func (s *server) GetInfo(ctx context.Context, in *pb.InfoRequest) (*pb.InfoResponse, error) {
+	return common.AdapterInfo(adapterName, buildVersion, converter.SupportedTypes), nil
+}
+
 func (s *server) NotifyPolicyDeletion(ctx context.Context, in *pb.PolicyDeletionRequest) (*pb.PolicyDeletionResponse, error) {
 	logger := ctrl.Log.WithName("main")
 	logger.Info("CiliumPolicy deleted", "cilium.Name", in.GetPolicyName(), "cilium.Namespace", in.GetPolicyNamespace())
+	if err := manager.Cleanup(ctx, logger, in.GetPolicyName(), in.GetPolicyNamespace()); err != nil {
+		logger.Error(err, "failed to delete CiliumClusterwideNetworkPolicies", "cilium.Name", in.GetPolicyName())
+		return nil, err
+	}
 
 	return &pb.PolicyDeletionResponse{
 		Success: true,
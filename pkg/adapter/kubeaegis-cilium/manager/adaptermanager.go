@@ -39,19 +39,47 @@ func Run(ctx context.Context, logger logr.Logger, KapName string, KapNamespace s
 	}
 	logger.Info("KubeAegisPolicy fetched", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
 
-	ksp, err := converter.Converter(ctx, k8sClient, logger, kap)
+	cnp, ccnp, err := converter.Converter(ctx, k8sClient, logger, kap)
 	if err != nil {
 		return "", err
 	}
 
-	if kspname, err = enforcer.Enforcer(ctx, k8sClient, logger, ksp, kap); err != nil {
+	var policyNames []string
+	if cnp != nil {
+		if kspname, err = enforcer.Enforcer(ctx, k8sClient, logger, cnp, kap); err != nil {
+			return "", err
+		}
+		policyNames = append(policyNames, cnp.Name)
+	} else if err := enforcer.Remove(ctx, k8sClient, logger, kap); err != nil {
 		return "", err
 	}
 
-	if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, ksp.Name, KapName, KapNamespace); err != nil {
-		logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+	var keep string
+	if ccnp != nil {
+		if keep, err = enforcer.EnforceClusterwide(ctx, k8sClient, logger, ccnp, kap); err != nil {
+			return "", err
+		}
+		if kspname == "" {
+			kspname = keep
+		}
+		policyNames = append(policyNames, ccnp.Name)
+	}
+	if err := enforcer.PruneClusterwide(ctx, k8sClient, logger, KapName, KapNamespace, keep); err != nil {
 		return "", err
 	}
 
+	for _, policyName := range policyNames {
+		if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, policyName, KapName, KapNamespace); err != nil {
+			logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+			return "", err
+		}
+	}
+
 	return kspname, nil
 }
+
+// Cleanup deletes the CiliumClusterwideNetworkPolicies of a deleted
+// KubeAegisPolicy. Its CiliumNetworkPolicy is garbage collected.
+func Cleanup(ctx context.Context, logger logr.Logger, policyName string, kapNamespace string) error {
+	return enforcer.CleanupClusterwide(ctx, k8sClient, logger, policyName, kapNamespace)
+}
@@ -0,0 +1,158 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+)
+
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"network": {"endpoint", "pod", "namespace", "cidr", "port"},
+}
+
+// Scopes are the policy scopes the converter renders. A NetworkPolicy only
+// selects pods in its own namespace.
+var Scopes = []string{v1.ScopeNamespace}
+
+// Converter returns one NetworkPolicy per pod selector of the network intents
+// of a KubeAegisPolicy. NetworkPolicy can only allow traffic, so intents it
+// cannot express in full are rejected instead of being left out.
+func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]*networkingv1.NetworkPolicy, error) {
+	logger.Info("NetworkPolicy started to transfer")
+
+	if kap.Spec.Scope != "" && kap.Spec.Scope != v1.ScopeNamespace {
+		err := fmt.Errorf("scope %s is not supported; a NetworkPolicy only selects pods in its own namespace", kap.Spec.Scope)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, err
+	}
+
+	// Intents whose selectors compile to the same pod selector share a
+	// NetworkPolicy.
+	var policies []*networkingv1.NetworkPolicy
+	policyFor := func(podSelector metav1.LabelSelector) *networkingv1.NetworkPolicy {
+		for _, policy := range policies {
+			if metav1.FormatLabelSelector(&policy.Spec.PodSelector) == metav1.FormatLabelSelector(&podSelector) {
+				return policy
+			}
+		}
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateName(kap.Name, len(policies)),
+				Namespace: kap.Namespace,
+			},
+			Spec: networkingv1.NetworkPolicySpec{PodSelector: podSelector},
+		}
+		policies = append(policies, policy)
+		return policy
+	}
+
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		if intentRequest.Type != "network" {
+			continue
+		}
+		if err := checkSupported(intentRequest); err != nil {
+			err = errors.Wrapf(err, "intentRequest[%d]", i)
+			logger.Error(err, "failed to convert intent")
+			return nil, err
+		}
+
+		podSelectors, err := extractSelector(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		if err != nil {
+			logger.Error(err, "failed to extract selector")
+			return nil, err
+		}
+		if len(podSelectors) == 0 {
+			continue
+		}
+
+		ingressRules, err := getIngress(intentRequest)
+		if err != nil {
+			logger.Error(err, "failed to convert ingress rules")
+			return nil, err
+		}
+		egressRules, err := getEgress(intentRequest)
+		if err != nil {
+			logger.Error(err, "failed to convert egress rules")
+			return nil, err
+		}
+
+		for _, podSelector := range podSelectors {
+			policy := policyFor(podSelector)
+			policy.Spec.Ingress = append(policy.Spec.Ingress, ingressRules...)
+			policy.Spec.Egress = append(policy.Spec.Egress, egressRules...)
+			if len(ingressRules) > 0 {
+				addPolicyType(policy, networkingv1.PolicyTypeIngress)
+			}
+			if len(egressRules) > 0 {
+				addPolicyType(policy, networkingv1.PolicyTypeEgress)
+			}
+		}
+	}
+
+	logger.Info("NetworkPolicy converted", "Count", len(policies))
+	return policies, nil
+}
+
+// checkSupported rejects the parts of a network intent that NetworkPolicy
+// cannot express, which would otherwise be dropped from the policy.
+func checkSupported(intentRequest v1.IntentRequest) error {
+	if intentRequest.Rule.Action != "Allow" {
+		return fmt.Errorf("action %s is not supported; NetworkPolicy can only allow traffic", intentRequest.Rule.Action)
+	}
+	for _, ap := range intentRequest.Rule.ActionPoint {
+		if ap.SubType == "http" {
+			return fmt.Errorf("http action points are not supported; NetworkPolicy has no L7 rules")
+		}
+	}
+	if intentRequest.Selector.NamespaceSelector != nil {
+		return fmt.Errorf("namespaceSelector is not supported; a NetworkPolicy only selects pods in its own namespace")
+	}
+	return nil
+}
+
+// extractSelector compiles a Selector into pod selectors, one per
+// alternative. It returns nothing for an empty Selector.
+func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) ([]metav1.LabelSelector, error) {
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+	if compiled.IsEmpty() {
+		return nil, nil
+	}
+
+	podSelectors, err := compiled.LabelSelectorsOrResolve(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
+	return podSelectors, nil
+}
+
+func addPolicyType(policy *networkingv1.NetworkPolicy, policyType networkingv1.PolicyType) {
+	for _, existing := range policy.Spec.PolicyTypes {
+		if existing == policyType {
+			return
+		}
+	}
+	policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, policyType)
+}
+
+// generateName names the NetworkPolicies of a KubeAegisPolicy. The first is
+// named after the policy alone, the others get their index as a suffix.
+func generateName(kapName string, index int) string {
+	if index == 0 {
+		return "netpol-" + kapName
+	}
+	return "netpol-" + kapName + "-" + strconv.Itoa(index)
+}
@@ -0,0 +1,203 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newPolicy(scope string, intents ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       v1.KubeAegisPolicySpec{Scope: scope, IntentRequest: intents},
+	}
+}
+
+func networkIntent(action string, match []v1.Match, from, to []v1.NetPolDetail) v1.IntentRequest {
+	return v1.IntentRequest{
+		Type:     "network",
+		Selector: v1.Selector{Match: match},
+		Rule:     v1.Rule{Action: action, From: from, To: to},
+	}
+}
+
+func TestConverter(t *testing.T) {
+	web := []v1.Match{{MatchLabels: map[string]string{"app": "web"}}}
+	tcp := corev1.ProtocolTCP
+	port80 := intstr.FromInt32(80)
+	frontend := []v1.NetPolDetail{{Kind: "pod", Labels: map[string]string{"app": "frontend"}, Port: "80"}}
+
+	tests := []struct {
+		name    string
+		kap     *v1.KubeAegisPolicy
+		want    []*networkingv1.NetworkPolicy
+		wantErr bool
+	}{
+		{
+			name: "ingress and egress",
+			kap: newPolicy("",
+				networkIntent("Allow", web, frontend, nil),
+				networkIntent("Allow", web, nil, []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8"}, Except: []string{"10.1.0.0/16", "192.168.0.0/16"}}}),
+			),
+			want: []*networkingv1.NetworkPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "netpol-web", Namespace: "shop"},
+				Spec: networkingv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+					Ingress: []networkingv1.NetworkPolicyIngressRule{{
+						From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}},
+						Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &port80}},
+					}},
+					Egress: []networkingv1.NetworkPolicyEgressRule{{
+						To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}}},
+					}},
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+				},
+			}},
+		},
+		{
+			name: "one policy per selector alternative",
+			kap: newPolicy("", networkIntent("Allow", []v1.Match{
+				{MatchLabels: map[string]string{"app": "web"}},
+				{MatchLabels: map[string]string{"app": "api"}},
+			}, nil, []v1.NetPolDetail{{Kind: "namespace", Args: []string{"monitoring"}}})),
+			want: func() []*networkingv1.NetworkPolicy {
+				egress := []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: []string{"monitoring"},
+					}}}}},
+				}}
+				var policies []*networkingv1.NetworkPolicy
+				for i, app := range []string{"web", "api"} {
+					name := "netpol-web"
+					if i > 0 {
+						name += "-1"
+					}
+					policies = append(policies, &networkingv1.NetworkPolicy{
+						ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+						Spec: networkingv1.NetworkPolicySpec{
+							PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+							Egress:      egress,
+							PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+						},
+					})
+				}
+				return policies
+			}(),
+		},
+		{
+			name: "intents of other types are skipped",
+			kap:  newPolicy("", v1.IntentRequest{Type: "system", Selector: v1.Selector{Match: web}}),
+		},
+		{
+			name:    "block",
+			kap:     newPolicy("", networkIntent("Block", web, frontend, nil)),
+			wantErr: true,
+		},
+		{
+			name:    "fqdns",
+			kap:     newPolicy("", networkIntent("Allow", web, nil, []v1.NetPolDetail{{Kind: "fqdns", Args: []string{"example.com"}}})),
+			wantErr: true,
+		},
+		{
+			name: "http action points",
+			kap: func() *v1.KubeAegisPolicy {
+				intent := networkIntent("Allow", web, frontend, nil)
+				intent.Rule.ActionPoint = []v1.ActionPoint{{SubType: "http"}}
+				return newPolicy("", intent)
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "namespaceSelector",
+			kap: func() *v1.KubeAegisPolicy {
+				intent := networkIntent("Allow", web, frontend, nil)
+				intent.Selector.NamespaceSelector = &metav1.LabelSelector{}
+				return newPolicy("", intent)
+			}(),
+			wantErr: true,
+		},
+		{
+			name:    "cluster scope",
+			kap:     newPolicy(v1.ScopeCluster, networkIntent("Allow", web, frontend, nil)),
+			wantErr: true,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Converter(context.Background(), k8sClient, logr.Discard(), tt.kap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToPorts(t *testing.T) {
+	udp := corev1.ProtocolUDP
+	port53, port8000, named := intstr.FromInt32(53), intstr.FromInt32(8000), intstr.FromString("dns")
+	endPort := int32(8080)
+
+	tests := []struct {
+		name    string
+		rule    v1.NetPolDetail
+		want    []networkingv1.NetworkPolicyPort
+		wantErr bool
+	}{
+		{
+			name: "peer without a port",
+			rule: v1.NetPolDetail{Kind: "pod"},
+		},
+		{
+			name:    "port rule without a port",
+			rule:    v1.NetPolDetail{Kind: "port"},
+			wantErr: true,
+		},
+		{
+			name: "number, range and name",
+			rule: v1.NetPolDetail{Kind: "port", Port: "53,8000-8080,dns", Protocol: "UDP"},
+			want: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &port53},
+				{Protocol: &udp, Port: &port8000, EndPort: &endPort},
+				{Protocol: &udp, Port: &named},
+			},
+		},
+		{
+			name:    "ICMP",
+			rule:    v1.NetPolDetail{Kind: "port", Port: "80", Protocol: "ICMP"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toPorts(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"fmt"
+	"net/netip"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+)
+
+// getIngress generates one ingress rule per from entry. A port entry allows
+// its ports from any source, and a peer without a port is allowed on all
+// ports.
+func getIngress(intentRequest v1.IntentRequest) ([]networkingv1.NetworkPolicyIngressRule, error) {
+	var ingressRules []networkingv1.NetworkPolicyIngressRule
+	for _, from := range intentRequest.Rule.From {
+		peers, err := toPeers(from)
+		if err != nil {
+			return nil, err
+		}
+		ports, err := toPorts(from)
+		if err != nil {
+			return nil, err
+		}
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{From: peers, Ports: ports})
+	}
+	return ingressRules, nil
+}
+
+// getEgress generates one egress rule per to entry, in the same way as
+// getIngress.
+func getEgress(intentRequest v1.IntentRequest) ([]networkingv1.NetworkPolicyEgressRule, error) {
+	var egressRules []networkingv1.NetworkPolicyEgressRule
+	for _, to := range intentRequest.Rule.To {
+		peers, err := toPeers(to)
+		if err != nil {
+			return nil, err
+		}
+		ports, err := toPorts(to)
+		if err != nil {
+			return nil, err
+		}
+		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{To: peers, Ports: ports})
+	}
+	return egressRules, nil
+}
+
+// toPeers converts a from or to entry into NetworkPolicy peers. Pods are
+// selected in the policy's namespace, and namespaces by their labels or, with
+// args, by name.
+func toPeers(rule v1.NetPolDetail) ([]networkingv1.NetworkPolicyPeer, error) {
+	switch rule.Kind {
+	case "endpoint", "pod":
+		return []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: rule.Labels}}}, nil
+	case "namespace":
+		namespaceSelector := &metav1.LabelSelector{MatchLabels: rule.Labels}
+		if len(rule.Args) > 0 {
+			namespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   rule.Args,
+			}}
+		}
+		return []networkingv1.NetworkPolicyPeer{{NamespaceSelector: namespaceSelector}}, nil
+	case "cidr":
+		if len(rule.Args) == 0 {
+			return nil, fmt.Errorf("cidr rule lists no CIDRs")
+		}
+		return toIPBlocks(rule), nil
+	case "port":
+		return nil, nil
+	case "fqdn", "fqdns":
+		return nil, fmt.Errorf("kind %s is not supported; NetworkPolicy cannot match DNS names", rule.Kind)
+	default:
+		return nil, fmt.Errorf("kind %s is not supported by NetworkPolicy", rule.Kind)
+	}
+}
+
+// toIPBlocks converts a cidr rule into one IP block per CIDR. Each except
+// range goes to the CIDRs that contain it.
+func toIPBlocks(rule v1.NetPolDetail) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, 0, len(rule.Args))
+	for _, cidr := range rule.Args {
+		ipBlock := &networkingv1.IPBlock{CIDR: cidr}
+		prefix, err := netip.ParsePrefix(cidr)
+		for _, except := range rule.Except {
+			exceptPrefix, exceptErr := netip.ParsePrefix(except)
+			if err == nil && exceptErr == nil && prefix.Contains(exceptPrefix.Addr()) {
+				ipBlock.Except = append(ipBlock.Except, except)
+			}
+		}
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: ipBlock})
+	}
+	return peers
+}
+
+// toPorts converts the port field of a rule into NetworkPolicy ports. Ranges
+// use endPort, and named ports are passed through for Kubernetes to resolve.
+// It returns nothing for a rule without a port.
+func toPorts(rule v1.NetPolDetail) ([]networkingv1.NetworkPolicyPort, error) {
+	if rule.Port == "" {
+		if rule.Kind == "port" {
+			return nil, fmt.Errorf("port rule has no port")
+		}
+		return nil, nil
+	}
+
+	protocol := corev1.Protocol(rule.Protocol)
+	switch protocol {
+	case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+	case "":
+		protocol = corev1.ProtocolTCP
+	default:
+		return nil, fmt.Errorf("protocol %s is not supported; NetworkPolicy only matches TCP, UDP and SCTP", rule.Protocol)
+	}
+
+	specs, err := processor.ParsePorts(rule.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]networkingv1.NetworkPolicyPort, 0, len(specs))
+	for _, spec := range specs {
+		port := intstr.FromInt32(spec.Port)
+		if spec.Name != "" {
+			port = intstr.FromString(spec.Name)
+		}
+		networkPolicyPort := networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port}
+		if spec.IsRange() {
+			endPort := spec.EndPort
+			networkPolicyPort.EndPort = &endPort
+		}
+		ports = append(ports, networkPolicyPort)
+	}
+	return ports, nil
+}
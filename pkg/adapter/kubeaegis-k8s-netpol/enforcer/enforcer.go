@@ -0,0 +1,85 @@
+package enforcer
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
+)
+
+func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger, policy *networkingv1.NetworkPolicy, kap *v1.KubeAegisPolicy) (string, error) {
+	// Check if the policy already exists
+	existingPolicy := &networkingv1.NetworkPolicy{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, existingPolicy)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to fetch NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		return "", err
+	}
+
+	if err := statusmanager.SetOwnerReferencesNetPol(ctx, k8sClient, kap, policy); err != nil {
+		logger.Error(err, "failed to set KubeAegisPolicy as owner of NetworkPolicy")
+		return "", err
+	}
+
+	// Update if exists, create otherwise
+	if apierrors.IsNotFound(err) {
+		logger.Info("NetworkPolicy enforced", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		if err := k8sClient.Create(ctx, policy); err != nil {
+			logger.Error(err, "failed to create NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+			return "", err
+		}
+	} else {
+		logger.Info("NetworkPolicy updated", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		before := existingPolicy.DeepCopy()
+		existingPolicy.Spec = policy.Spec
+		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
+			logger.Error(err, "failed to update NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+			return "", err
+		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "NetworkPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+		}
+	}
+
+	return policy.Name, nil
+}
+
+// Prune deletes the NetworkPolicies owned by the KubeAegisPolicy that are not
+// in keep, left over from selectors the policy no longer has.
+func Prune(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy, keep []string) error {
+	var policies networkingv1.NetworkPolicyList
+	if err := k8sClient.List(ctx, &policies, client.InNamespace(kap.Namespace)); err != nil {
+		logger.Error(err, "failed to list NetworkPolicies", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(policy.Name) || !ownedBy(policy, kap) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete NetworkPolicy", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+			return err
+		}
+		logger.Info("NetworkPolicy deleted", "Policy.Name", policy.Name, "Policy.Namespace", policy.Namespace)
+	}
+	return nil
+}
+
+func ownedBy(policy *networkingv1.NetworkPolicy, kap *v1.KubeAegisPolicy) bool {
+	for _, owner := range policy.GetOwnerReferences() {
+		if owner.Kind == "KubeAegisPolicy" && owner.UID == kap.UID {
+			return true
+		}
+	}
+	return false
+}
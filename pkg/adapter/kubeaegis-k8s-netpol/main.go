@@ -19,11 +19,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	pb "github.com/cclab-inu/KubeAegis/api/grpc"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/common"
 	"github.com/cclab-inu/KubeAegis/pkg/adapter/k8s"
-	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-calico-have-rule/manager"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-k8s-netpol/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-k8s-netpol/manager"
 )
 
-const adapterName = "kubeaegis-calico"
+const adapterName = "kubeaegis-k8s-netpol"
+
+// buildVersion is overridden at build time with -ldflags "-X main.buildVersion=<tag>".
+var buildVersion = "dev"
 
 type server struct {
 	pb.UnimplementedPolicyServiceServer
@@ -32,6 +37,10 @@ type server struct {
 func (s *server) DispatchPolicy(ctx context.Context, in *pb.PolicyRequest) (*pb.PolicyResponse, error) {
 	logger := ctrl.Log.WithName("main")
 	logger.Info("KubeAegis arrived", "KubeAegis.Name", in.GetPolicyName(), "KubeAegis.Namespace", in.GetPolicyNamespace())
+	if err := common.CheckProtocolVersion(adapterName, in.GetProtocolVersion()); err != nil {
+		logger.Error(err, "rejecting KubeAegis from incompatible controller")
+		return nil, err
+	}
 
 	realPolicyName, _ := manager.Run(ctx, logger, in.GetPolicyName(), in.GetPolicyNamespace())
 
@@ -42,6 +51,10 @@ func (s *server) DispatchPolicy(ctx context.Context, in *pb.PolicyRequest) (*pb.
 	}, nil
 }
 
+func (s *server) GetInfo(ctx context.Context, in *pb.InfoRequest) (*pb.InfoResponse, error) {
+	return common.AdapterInfo(adapterName, buildVersion, converter.SupportedTypes), nil
+}
+
 func (s *server) NotifyPolicyDeletion(ctx context.Context, in *pb.PolicyDeletionRequest) (*pb.PolicyDeletionResponse, error) {
 	logger := ctrl.Log.WithName("main")
 	logger.Info("NetworkPolicy deleted", "Policy.Name", in.GetPolicyName(), "Policy.Namespace", in.GetPolicyNamespace())
@@ -72,10 +85,10 @@ func main() {
 		cancelFunc()
 		os.Exit(1)
 	}()
-	logger.Info("calico adapter started")
-	lis, err := net.Listen("tcp", ":50056")
+	logger.Info("Kubernetes NetworkPolicy adapter started")
+	lis, err := net.Listen("tcp", ":50057")
 	if err != nil {
-		logger.Error(err, "failed to listen on port 50056")
+		logger.Error(err, "failed to listen on port 50057")
 		os.Exit(1)
 	}
 	updateAdapterStatus(ctx, k8sClient, logger, "online")
@@ -83,7 +96,7 @@ func main() {
 	s := grpc.NewServer()
 	pb.RegisterPolicyServiceServer(s, &server{})
 
-	logger.Info("gRPC server listening on port 50056")
+	logger.Info("gRPC server listening on port 50057")
 	if err := s.Serve(lis); err != nil {
 		logger.Error(err, "failed to serve gRPC server")
 		os.Exit(1)
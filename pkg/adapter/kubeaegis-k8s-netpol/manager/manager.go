@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/k8s"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-k8s-netpol/converter"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-k8s-netpol/enforcer"
+	watcher "github.com/cclab-inu/KubeAegis/pkg/adapter/watcher"
+	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
+)
+
+var (
+	scheme    = runtime.NewScheme()
+	k8sClient client.Client
+)
+
+func init() {
+	utilruntime.Must(v1.AddToScheme(scheme))
+	utilruntime.Must(networkingv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	k8sClient = k8s.NewOrDie(scheme)
+}
+
+func Run(ctx context.Context, logger logr.Logger, KapName string, KapNamespace string) (string, error) {
+	kap, err := watcher.GetKubeAegisPolicy(ctx, k8sClient, KapName, KapNamespace)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("KubeAegisPolicy fetched", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+
+	policies, err := converter.Converter(ctx, k8sClient, logger, kap)
+	if err != nil {
+		return "", err
+	}
+
+	var policyNames []string
+	for _, policy := range policies {
+		policyName, err := enforcer.Enforcer(ctx, k8sClient, logger, policy, kap)
+		if err != nil {
+			return "", err
+		}
+		policyNames = append(policyNames, policyName)
+	}
+
+	if err := enforcer.Prune(ctx, k8sClient, logger, kap, policyNames); err != nil {
+		return "", err
+	}
+
+	for _, policyName := range policyNames {
+		if err := statusmanager.UpdateKapStatusAfterPolicy(ctx, k8sClient, policyName, KapName, KapNamespace); err != nil {
+			logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+			return "", err
+		}
+	}
+
+	if len(policyNames) == 0 {
+		return "", nil
+	}
+	return policyNames[0], nil
+}
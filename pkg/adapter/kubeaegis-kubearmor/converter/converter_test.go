@@ -0,0 +1,255 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newPolicy(intentRequests ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       v1.KubeAegisPolicySpec{IntentRequest: intentRequests},
+	}
+}
+
+// selects returns a system intent for the pods with the labels.
+func selects(labels map[string]string, rule v1.Rule) v1.IntentRequest {
+	return v1.IntentRequest{Type: "system", Selector: v1.Selector{Match: []v1.Match{{MatchLabels: labels}}}, Rule: rule}
+}
+
+var fromBash = []v1.FromSource{{Path: "/bin/bash"}, {Dir: "/usr/sbin/", Recursive: true}}
+
+var bashSources = []karmorv1.MatchSourceType{{Path: "/bin/bash"}, {Directory: "/usr/sbin/", Recursive: true}}
+
+func TestToMatchSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []v1.FromSource
+		want    []karmorv1.MatchSourceType
+	}{
+		{name: "none"},
+		{name: "empty", sources: []v1.FromSource{}},
+		{name: "path and recursive dir", sources: fromBash, want: bashSources},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toMatchSources(tt.sources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toMatchSources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleProcess(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource v1.EventMatchResource
+		want     karmorv1.ProcessType
+	}{
+		{
+			name:     "paths and patterns",
+			resource: v1.EventMatchResource{Path: []string{"/bin/sh", "/bin/ls"}, Pattern: []string{"/usr/*/curl"}},
+			want: karmorv1.ProcessType{
+				MatchPaths:    []karmorv1.ProcessPathType{{Path: "/bin/sh"}, {Path: "/bin/ls"}},
+				MatchPatterns: []karmorv1.ProcessPatternType{{Pattern: "/usr/*/curl"}},
+			},
+		},
+		{
+			name:     "path owner only from sources",
+			resource: v1.EventMatchResource{Path: []string{"/bin/sh"}, OwnerOnly: true, FromSource: fromBash},
+			want: karmorv1.ProcessType{
+				MatchPaths: []karmorv1.ProcessPathType{{Path: "/bin/sh", OwnerOnly: true, FromSource: bashSources}},
+			},
+		},
+		{
+			name:     "pattern owner only",
+			resource: v1.EventMatchResource{Pattern: []string{"/usr/*/curl"}, OwnerOnly: true},
+			want: karmorv1.ProcessType{
+				MatchPatterns: []karmorv1.ProcessPatternType{{Pattern: "/usr/*/curl", OwnerOnly: true}},
+			},
+		},
+		{
+			name:     "recursive dir owner only from sources",
+			resource: v1.EventMatchResource{Dir: "/usr/bin/", Recursive: true, OwnerOnly: true, FromSource: fromBash},
+			want: karmorv1.ProcessType{
+				MatchDirectories: []karmorv1.ProcessDirectoryType{{Directory: "/usr/bin/", Recursive: true, OwnerOnly: true, FromSource: bashSources}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ksp := &karmorv1.KubeArmorPolicy{}
+			handleProcess(ksp, v1.ActionPoint{SubType: "process", Resource: tt.resource})
+			if !reflect.DeepEqual(ksp.Spec.Process, tt.want) {
+				t.Errorf("handleProcess() = %+v, want %+v", ksp.Spec.Process, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource v1.EventMatchResource
+		want     karmorv1.FileType
+	}{
+		{
+			name:     "paths and patterns",
+			resource: v1.EventMatchResource{Path: []string{"/etc/passwd", "/etc/shadow"}, Pattern: []string{"/etc/*.conf"}},
+			want: karmorv1.FileType{
+				MatchPaths:    []karmorv1.FilePathType{{Path: "/etc/passwd"}, {Path: "/etc/shadow"}},
+				MatchPatterns: []karmorv1.FilePatternType{{Pattern: "/etc/*.conf"}},
+			},
+		},
+		{
+			name:     "read only path",
+			resource: v1.EventMatchResource{Path: []string{"/etc/resolv.conf"}, ReadOnly: true},
+			want: karmorv1.FileType{
+				MatchPaths: []karmorv1.FilePathType{{Path: "/etc/resolv.conf", ReadOnly: true}},
+			},
+		},
+		{
+			name:     "path owner only from sources",
+			resource: v1.EventMatchResource{Path: []string{"/etc/hosts"}, OwnerOnly: true, FromSource: fromBash},
+			want: karmorv1.FileType{
+				MatchPaths: []karmorv1.FilePathType{{Path: "/etc/hosts", OwnerOnly: true, FromSource: bashSources}},
+			},
+		},
+		{
+			name:     "read only pattern owner only",
+			resource: v1.EventMatchResource{Pattern: []string{"/etc/*.conf"}, ReadOnly: true, OwnerOnly: true},
+			want: karmorv1.FileType{
+				MatchPatterns: []karmorv1.FilePatternType{{Pattern: "/etc/*.conf", ReadOnly: true, OwnerOnly: true}},
+			},
+		},
+		{
+			name:     "read only recursive dir owner only from sources",
+			resource: v1.EventMatchResource{Dir: "/var/log/", ReadOnly: true, Recursive: true, OwnerOnly: true, FromSource: fromBash},
+			want: karmorv1.FileType{
+				MatchDirectories: []karmorv1.FileDirectoryType{{Directory: "/var/log/", ReadOnly: true, Recursive: true, OwnerOnly: true, FromSource: bashSources}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ksp := &karmorv1.KubeArmorPolicy{}
+			handleFile(ksp, v1.ActionPoint{SubType: "file", Resource: tt.resource})
+			if !reflect.DeepEqual(ksp.Spec.File, tt.want) {
+				t.Errorf("handleFile() = %+v, want %+v", ksp.Spec.File, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource v1.EventMatchResource
+		want     []karmorv1.MatchNetworkProtocolType
+	}{
+		{name: "no protocol"},
+		{
+			name:     "protocol",
+			resource: v1.EventMatchResource{Protocol: "tcp"},
+			want:     []karmorv1.MatchNetworkProtocolType{{Protocol: "tcp"}},
+		},
+		{
+			name:     "protocol from sources",
+			resource: v1.EventMatchResource{Protocol: "udp", FromSource: fromBash},
+			want:     []karmorv1.MatchNetworkProtocolType{{Protocol: "udp", FromSource: bashSources}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ksp := &karmorv1.KubeArmorPolicy{}
+			handleNetwork(ksp, v1.ActionPoint{SubType: "network", Resource: tt.resource})
+			if !reflect.DeepEqual(ksp.Spec.Network.MatchProtocols, tt.want) {
+				t.Errorf("handleNetwork() = %+v, want %+v", ksp.Spec.Network.MatchProtocols, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverter(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	kap := newPolicy(
+		selects(web, v1.Rule{Action: "Block", ActionPoint: []v1.ActionPoint{
+			{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/etc/resolv.conf"}, ReadOnly: true}},
+			{SubType: "file", Resource: v1.EventMatchResource{Dir: "/var/log/", Recursive: true, OwnerOnly: true, FromSource: fromBash}},
+			{SubType: "process", Resource: v1.EventMatchResource{Dir: "/usr/bin/", Recursive: true, FromSource: fromBash}},
+		}}),
+		selects(web, v1.Rule{Action: "Audit", ActionPoint: []v1.ActionPoint{
+			{SubType: "network", Resource: v1.EventMatchResource{Protocol: "icmp", FromSource: fromBash}},
+		}}),
+	)
+
+	ksps, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), kap)
+	if err != nil {
+		t.Fatalf("Converter() error = %v", err)
+	}
+
+	labels := map[string]string{OriginPolicyLabel: "ksp-web", OriginNamespaceLabel: "shop"}
+	lease := []karmorv1.MatchCapabilitiesType{{Capability: "lease"}}
+	want := []*karmorv1.KubeArmorPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ksp-web", Namespace: "shop", Labels: labels},
+			Spec: karmorv1.KubeArmorPolicySpec{
+				Selector: karmorv1.SelectorType{MatchLabels: web},
+				Process: karmorv1.ProcessType{
+					MatchDirectories: []karmorv1.ProcessDirectoryType{{Directory: "/usr/bin/", Recursive: true, FromSource: bashSources}},
+				},
+				File: karmorv1.FileType{
+					MatchPaths:       []karmorv1.FilePathType{{Path: "/etc/resolv.conf", ReadOnly: true}},
+					MatchDirectories: []karmorv1.FileDirectoryType{{Directory: "/var/log/", Recursive: true, OwnerOnly: true, FromSource: bashSources}},
+				},
+				Network:      karmorv1.NetworkType{MatchProtocols: []karmorv1.MatchNetworkProtocolType{{Protocol: "raw"}}},
+				Capabilities: karmorv1.CapabilitiesType{MatchCapabilities: lease},
+				Action:       "Block",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ksp-web-1", Namespace: "shop", Labels: labels},
+			Spec: karmorv1.KubeArmorPolicySpec{
+				Selector:     karmorv1.SelectorType{MatchLabels: web},
+				Network:      karmorv1.NetworkType{MatchProtocols: []karmorv1.MatchNetworkProtocolType{{Protocol: "icmp", FromSource: bashSources}}},
+				Capabilities: karmorv1.CapabilitiesType{MatchCapabilities: lease},
+				Action:       "Audit",
+			},
+		},
+	}
+	if !reflect.DeepEqual(ksps, want) {
+		t.Errorf("Converter() = %+v, want %+v", ksps, want)
+	}
+}
+
+func TestConverterScope(t *testing.T) {
+	kap := newPolicy(selects(map[string]string{"app": "web"}, v1.Rule{Action: "Block"}))
+	kap.Spec.Scope = v1.ScopeCluster
+	if _, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), kap); err == nil {
+		t.Error("Converter() error = nil, want an error for a cluster scoped policy")
+	}
+}
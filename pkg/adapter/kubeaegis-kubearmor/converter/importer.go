@@ -0,0 +1,225 @@
+package converter
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/importer"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+// Import converts a KubeArmorPolicy back into a KubeAegisPolicy with a single
+// system intent. Matchers that set options the converter never sets, such as
+// execname or severity, or an action other than the policy's, are left out,
+// and every field that was not imported is reported. The policy is nil when
+// nothing could be imported.
+func Import(ksp *karmorv1.KubeArmorPolicy) (*v1.KubeAegisPolicy, validator.ResultList, error) {
+	mapped := importer.NewMapped()
+	spec := field.NewPath("spec")
+	sameAction := func(action karmorv1.ActionType) bool {
+		return action == "" || action == ksp.Spec.Action
+	}
+
+	intent := v1.IntentRequest{
+		Type: "system",
+		Selector: v1.Selector{
+			Match: []v1.Match{{Kind: "Pod", MatchLabels: ksp.Spec.Selector.MatchLabels}},
+		},
+		Rule: v1.Rule{Action: string(ksp.Spec.Action)},
+	}
+	mapped.Add(spec.Child("selector", "matchLabels"), spec.Child("action"))
+
+	// Process and file matchers share the form of their action points: the
+	// paths and patterns without options in one, and one per directory and
+	// per path or pattern with options.
+	process := spec.Child("process")
+	processPoint := v1.ActionPoint{SubType: "process"}
+	var processPoints []v1.ActionPoint
+	for i := range ksp.Spec.Process.MatchPaths {
+		match := &ksp.Spec.Process.MatchPaths[i]
+		if importer.OnlySet(match, "path", "ownerOnly", "fromSource", "action") && sameAction(match.Action) {
+			if match.OwnerOnly || len(match.FromSource) > 0 {
+				processPoints = append(processPoints, v1.ActionPoint{SubType: "process", Resource: v1.EventMatchResource{
+					Path:       []string{string(match.Path)},
+					OwnerOnly:  match.OwnerOnly,
+					FromSource: fromSources(match.FromSource),
+				}})
+			} else {
+				processPoint.Resource.Path = append(processPoint.Resource.Path, string(match.Path))
+			}
+			mapped.Add(process.Child("matchPaths").Index(i))
+		}
+	}
+	for i := range ksp.Spec.Process.MatchPatterns {
+		match := &ksp.Spec.Process.MatchPatterns[i]
+		if importer.OnlySet(match, "pattern", "ownerOnly", "action") && sameAction(match.Action) {
+			if match.OwnerOnly {
+				processPoints = append(processPoints, v1.ActionPoint{SubType: "process", Resource: v1.EventMatchResource{
+					Pattern:   []string{match.Pattern},
+					OwnerOnly: true,
+				}})
+			} else {
+				processPoint.Resource.Pattern = append(processPoint.Resource.Pattern, match.Pattern)
+			}
+			mapped.Add(process.Child("matchPatterns").Index(i))
+		}
+	}
+	for i := range ksp.Spec.Process.MatchDirectories {
+		match := &ksp.Spec.Process.MatchDirectories[i]
+		if importer.OnlySet(match, "dir", "recursive", "ownerOnly", "fromSource", "action") && sameAction(match.Action) {
+			processPoints = append(processPoints, v1.ActionPoint{SubType: "process", Resource: v1.EventMatchResource{
+				Dir:        string(match.Directory),
+				Recursive:  match.Recursive,
+				OwnerOnly:  match.OwnerOnly,
+				FromSource: fromSources(match.FromSource),
+			}})
+			mapped.Add(process.Child("matchDirectories").Index(i))
+		}
+	}
+
+	file := spec.Child("file")
+	filePoint := v1.ActionPoint{SubType: "file"}
+	var filePoints []v1.ActionPoint
+	for i := range ksp.Spec.File.MatchPaths {
+		match := &ksp.Spec.File.MatchPaths[i]
+		if importer.OnlySet(match, "path", "readOnly", "ownerOnly", "fromSource", "action") && sameAction(match.Action) {
+			if match.ReadOnly || match.OwnerOnly || len(match.FromSource) > 0 {
+				filePoints = append(filePoints, v1.ActionPoint{SubType: "file", Resource: v1.EventMatchResource{
+					Path:       []string{string(match.Path)},
+					ReadOnly:   match.ReadOnly,
+					OwnerOnly:  match.OwnerOnly,
+					FromSource: fromSources(match.FromSource),
+				}})
+			} else {
+				filePoint.Resource.Path = append(filePoint.Resource.Path, string(match.Path))
+			}
+			mapped.Add(file.Child("matchPaths").Index(i))
+		}
+	}
+	for i := range ksp.Spec.File.MatchPatterns {
+		match := &ksp.Spec.File.MatchPatterns[i]
+		if importer.OnlySet(match, "pattern", "readOnly", "ownerOnly", "action") && sameAction(match.Action) {
+			if match.ReadOnly || match.OwnerOnly {
+				filePoints = append(filePoints, v1.ActionPoint{SubType: "file", Resource: v1.EventMatchResource{
+					Pattern:   []string{match.Pattern},
+					ReadOnly:  match.ReadOnly,
+					OwnerOnly: match.OwnerOnly,
+				}})
+			} else {
+				filePoint.Resource.Pattern = append(filePoint.Resource.Pattern, match.Pattern)
+			}
+			mapped.Add(file.Child("matchPatterns").Index(i))
+		}
+	}
+	for i := range ksp.Spec.File.MatchDirectories {
+		match := &ksp.Spec.File.MatchDirectories[i]
+		if importer.OnlySet(match, "dir", "readOnly", "recursive", "ownerOnly", "fromSource", "action") && sameAction(match.Action) {
+			filePoints = append(filePoints, v1.ActionPoint{SubType: "file", Resource: v1.EventMatchResource{
+				Dir:        string(match.Directory),
+				ReadOnly:   match.ReadOnly,
+				Recursive:  match.Recursive,
+				OwnerOnly:  match.OwnerOnly,
+				FromSource: fromSources(match.FromSource),
+			}})
+			mapped.Add(file.Child("matchDirectories").Index(i))
+		}
+	}
+
+	syscalls := spec.Child("syscalls")
+	syscallsPoint := v1.ActionPoint{SubType: "syscalls"}
+	for i := range ksp.Spec.Syscalls.MatchSyscalls {
+		match := &ksp.Spec.Syscalls.MatchSyscalls[i]
+		if importer.OnlySet(match, "syscall") {
+			for _, syscall := range match.Syscalls {
+				syscallsPoint.Resource.Args = append(syscallsPoint.Resource.Args, string(syscall))
+			}
+			mapped.Add(syscalls.Child("matchSyscalls").Index(i))
+		}
+	}
+	for i := range ksp.Spec.Syscalls.MatchPaths {
+		match := &ksp.Spec.Syscalls.MatchPaths[i]
+		if importer.OnlySet(match, "path") {
+			syscallsPoint.Resource.Path = append(syscallsPoint.Resource.Path, string(match.Path))
+			mapped.Add(syscalls.Child("matchPaths").Index(i))
+		}
+	}
+
+	// setDefaultValues fills in a raw protocol and the lease capability when
+	// a policy has none, so those alone need no action point.
+	var networkPoints []v1.ActionPoint
+	protocols := ksp.Spec.Network.MatchProtocols
+	if len(protocols) == 1 && protocols[0].Protocol == "raw" && importer.OnlySet(&protocols[0], "protocol") {
+		mapped.Add(spec.Child("network", "matchProtocols").Index(0))
+	} else {
+		for i := range protocols {
+			if importer.OnlySet(&protocols[i], "protocol", "fromSource", "action") && sameAction(protocols[i].Action) {
+				networkPoints = append(networkPoints, v1.ActionPoint{SubType: "network", Resource: v1.EventMatchResource{
+					Protocol:   string(protocols[i].Protocol),
+					FromSource: fromSources(protocols[i].FromSource),
+				}})
+				mapped.Add(spec.Child("network", "matchProtocols").Index(i))
+			}
+		}
+	}
+
+	capabilitiesPoint := v1.ActionPoint{SubType: "capabilities"}
+	capabilities := ksp.Spec.Capabilities.MatchCapabilities
+	if len(capabilities) == 1 && capabilities[0].Capability == "lease" && importer.OnlySet(&capabilities[0], "capability") {
+		mapped.Add(spec.Child("capabilities", "matchCapabilities").Index(0))
+	} else {
+		for i := range capabilities {
+			if importer.OnlySet(&capabilities[i], "capability", "action") && sameAction(capabilities[i].Action) {
+				capabilitiesPoint.Resource.Args = append(capabilitiesPoint.Resource.Args, string(capabilities[i].Capability))
+				mapped.Add(spec.Child("capabilities", "matchCapabilities").Index(i))
+			}
+		}
+	}
+
+	// The adapter is chosen by the first action point, so process, file and
+	// syscalls points come first.
+	if len(processPoint.Resource.Path) > 0 || len(processPoint.Resource.Pattern) > 0 {
+		intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, processPoint)
+	}
+	intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, processPoints...)
+	if len(filePoint.Resource.Path) > 0 || len(filePoint.Resource.Pattern) > 0 {
+		intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, filePoint)
+	}
+	intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, filePoints...)
+	if len(syscallsPoint.Resource.Args) > 0 || len(syscallsPoint.Resource.Path) > 0 {
+		intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, syscallsPoint)
+	}
+	intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, networkPoints...)
+	if len(capabilitiesPoint.Resource.Args) > 0 {
+		intent.Rule.ActionPoint = append(intent.Rule.ActionPoint, capabilitiesPoint)
+	}
+
+	results, err := mapped.Unmapped(ksp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(intent.Rule.ActionPoint) == 0 {
+		return nil, results, nil
+	}
+
+	kap := importer.NewPolicy(ksp.Name, "ksp-", ksp.Namespace)
+	kap.Spec.IntentRequest = []v1.IntentRequest{intent}
+	return kap, results, nil
+}
+
+// fromSources converts the sources of a KubeArmor matcher into the fromSource
+// of an action point.
+func fromSources(sources []karmorv1.MatchSourceType) []v1.FromSource {
+	if len(sources) == 0 {
+		return nil
+	}
+	fromSource := make([]v1.FromSource, 0, len(sources))
+	for _, source := range sources {
+		fromSource = append(fromSource, v1.FromSource{
+			Path:      string(source.Path),
+			Dir:       string(source.Directory),
+			Recursive: source.Recursive,
+		})
+	}
+	return fromSource
+}
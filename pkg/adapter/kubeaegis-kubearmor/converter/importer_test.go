@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
+)
+
+func TestFromSources(t *testing.T) {
+	if got := fromSources(nil); got != nil {
+		t.Errorf("fromSources(nil) = %+v, want nil", got)
+	}
+	if got := fromSources(bashSources); !reflect.DeepEqual(got, fromBash) {
+		t.Errorf("fromSources() = %+v, want %+v", got, fromBash)
+	}
+}
+
+// TestImportRoundTrip checks that the KubeArmorPolicy the converter renders
+// imports back into the action points it came from.
+func TestImportRoundTrip(t *testing.T) {
+	points := []v1.ActionPoint{
+		{SubType: "process", Resource: v1.EventMatchResource{Path: []string{"/bin/sh"}, Pattern: []string{"/usr/*/curl"}}},
+		{SubType: "process", Resource: v1.EventMatchResource{Path: []string{"/bin/ls"}, OwnerOnly: true, FromSource: fromBash}},
+		{SubType: "process", Resource: v1.EventMatchResource{Dir: "/usr/bin/", Recursive: true, FromSource: fromBash}},
+		{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/etc/passwd"}}},
+		{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/etc/resolv.conf"}, ReadOnly: true}},
+		{SubType: "file", Resource: v1.EventMatchResource{Pattern: []string{"/etc/*.conf"}, ReadOnly: true, OwnerOnly: true}},
+		{SubType: "file", Resource: v1.EventMatchResource{Dir: "/var/log/", ReadOnly: true, Recursive: true, OwnerOnly: true, FromSource: fromBash}},
+		{SubType: "syscalls", Resource: v1.EventMatchResource{Args: []string{"unlink"}}},
+		{SubType: "network", Resource: v1.EventMatchResource{Protocol: "icmp", FromSource: fromBash}},
+		{SubType: "capabilities", Resource: v1.EventMatchResource{Args: []string{"net_raw"}}},
+	}
+	kap := newPolicy(selects(map[string]string{"app": "web"}, v1.Rule{Action: "Block", ActionPoint: points}))
+
+	ksps, err := Converter(context.Background(), newFakeClient(t), logr.Discard(), kap)
+	if err != nil {
+		t.Fatalf("Converter() error = %v", err)
+	}
+	if len(ksps) != 1 {
+		t.Fatalf("Converter() returned %d policies, want 1", len(ksps))
+	}
+
+	imported, results, err := Import(ksps[0])
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(results) > 0 {
+		t.Errorf("Import() unmapped = %+v, want none", results)
+	}
+	if imported.Name != "web" || imported.Namespace != "shop" {
+		t.Errorf("Import() name = %s/%s, want shop/web", imported.Namespace, imported.Name)
+	}
+	if len(imported.Spec.IntentRequest) != 1 {
+		t.Fatalf("Import() intents = %+v, want 1", imported.Spec.IntentRequest)
+	}
+	intent := imported.Spec.IntentRequest[0]
+	if intent.Type != "system" || intent.Rule.Action != "Block" {
+		t.Errorf("Import() intent type = %s, action = %s, want system and Block", intent.Type, intent.Rule.Action)
+	}
+	if !reflect.DeepEqual(intent.Rule.ActionPoint, points) {
+		t.Errorf("Import() action points = %+v, want %+v", intent.Rule.ActionPoint, points)
+	}
+}
+
+func TestImportUnmapped(t *testing.T) {
+	ksp := &karmorv1.KubeArmorPolicy{Spec: karmorv1.KubeArmorPolicySpec{
+		Selector: karmorv1.SelectorType{MatchLabels: map[string]string{"app": "web"}},
+		File: karmorv1.FileType{MatchPaths: []karmorv1.FilePathType{
+			{Path: "/etc/resolv.conf", ReadOnly: true},
+			{Path: "/etc/shadow", Severity: 5},
+		}},
+		Network:      karmorv1.NetworkType{MatchProtocols: []karmorv1.MatchNetworkProtocolType{{Protocol: "raw"}}},
+		Capabilities: karmorv1.CapabilitiesType{MatchCapabilities: []karmorv1.MatchCapabilitiesType{{Capability: "lease"}}},
+		Action:       "Block",
+	}}
+	ksp.Name, ksp.Namespace = "ksp-web", "shop"
+
+	imported, results, err := Import(ksp)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	var fields []string
+	for _, result := range results {
+		fields = append(fields, result.Field)
+	}
+	if want := []string{"spec.file.matchPaths[1]"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Import() unmapped = %v, want %v", fields, want)
+	}
+	want := []v1.ActionPoint{{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/etc/resolv.conf"}, ReadOnly: true}}}
+	if got := imported.Spec.IntentRequest[0].Rule.ActionPoint; !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() action points = %+v, want %+v", got, want)
+	}
+}
@@ -3,10 +3,12 @@ package converter
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
@@ -14,83 +16,142 @@ import (
 	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
 )
 
-func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*karmorv1.KubeArmorPolicy, error) {
+// Labels set on every KubeArmorPolicy of a KubeAegisPolicy. Copies in other
+// namespaces cannot carry an owner reference to it and are found through them.
+const (
+	OriginPolicyLabel    = "kubeaegis.cclab.com/policy"
+	OriginNamespaceLabel = "kubeaegis.cclab.com/namespace"
+)
+
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"system": {"process", "file", "syscalls"},
+}
+
+// Scopes are the policy scopes the converter renders. KubeArmorPolicies are
+// namespaced, and a namespaceSelector already reaches other namespaces.
+var Scopes = []string{v1.ScopeNamespace}
+
+// Converter returns the KubeArmorPolicies of a KubeAegisPolicy.
+// KubeArmorPolicies only select pods in their own namespace, so a
+// namespaceSelector fans the policy out to every matching namespace. A
+// KubeArmorPolicy has a single selector and action, so intents that differ in
+// either get a KubeArmorPolicy of their own.
+func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]*karmorv1.KubeArmorPolicy, error) {
 	logger.Info("KubeArmorPolicy started to transfer")
 
-	kubeArmorPolicy := &karmorv1.KubeArmorPolicy{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      generateKubeArmorPolicyName(kap.Name),
-			Namespace: kap.Namespace,
-		},
-		Spec: karmorv1.KubeArmorPolicySpec{},
+	if kap.Spec.Scope != "" && kap.Spec.Scope != v1.ScopeNamespace {
+		err := fmt.Errorf("scope %s is not supported; the KubeArmor adapter only renders namespaced policies", kap.Spec.Scope)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, err
 	}
 
+	var kubeArmorPolicies []*karmorv1.KubeArmorPolicy
+	bySelector := map[string]*karmorv1.KubeArmorPolicy{}
+	countByNamespace := map[string]int{}
+
 	// Assuming that there might be multiple IntentRequests, we iterate through them.
 	for _, intentRequest := range kap.Spec.IntentRequest {
-		matchLabels, err := extractSelector(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
+		namespaces, err := processor.SelectNamespaces(ctx, k8sClient, kap.Namespace, intentRequest.Selector)
 		if err != nil {
-			logger.Error(err, "failed to extract selector")
+			logger.Error(err, "failed to select namespaces")
 			return nil, err
 		}
-		if len(matchLabels) > 0 {
-			kubeArmorPolicy.Spec.Selector.MatchLabels = matchLabels
-		} else if len(matchLabels) == 0 {
-			continue
-		}
 
-		kubeArmorPolicy.Spec.Action = karmorv1.ActionType(intentRequest.Rule.Action)
-
-		for _, point := range intentRequest.Rule.ActionPoint {
-			switch point.SubType {
-			case "process":
-				handleProcess(kubeArmorPolicy, point)
-			case "file":
-				handleFile(kubeArmorPolicy, point)
-			case "network":
-				handleNetwork(kubeArmorPolicy, point)
-			case "capabilities":
-				handleCapabilities(kubeArmorPolicy, point)
-			case "syscalls":
-				handleSyscalls(kubeArmorPolicy, point)
+		for _, namespace := range namespaces {
+			matchLabels, err := extractSelector(ctx, k8sClient, namespace, intentRequest.Selector)
+			if err != nil {
+				logger.Error(err, "failed to extract selector", "Namespace", namespace)
+				return nil, err
+			}
+			if matchLabels == nil {
+				continue
+			}
+
+			key := namespace + "/" + labels.Set(matchLabels).String() + "/" + intentRequest.Rule.Action
+			kubeArmorPolicy, ok := bySelector[key]
+			if !ok {
+				kubeArmorPolicy = newKubeArmorPolicy(kap, namespace, countByNamespace[namespace])
+				kubeArmorPolicy.Spec.Selector.MatchLabels = matchLabels
+				kubeArmorPolicy.Spec.Action = karmorv1.ActionType(intentRequest.Rule.Action)
+				bySelector[key] = kubeArmorPolicy
+				countByNamespace[namespace]++
+				kubeArmorPolicies = append(kubeArmorPolicies, kubeArmorPolicy)
+			}
+
+			for _, point := range intentRequest.Rule.ActionPoint {
+				switch point.SubType {
+				case "process":
+					handleProcess(kubeArmorPolicy, point)
+				case "file":
+					handleFile(kubeArmorPolicy, point)
+				case "network":
+					handleNetwork(kubeArmorPolicy, point)
+				case "capabilities":
+					handleCapabilities(kubeArmorPolicy, point)
+				case "syscalls":
+					handleSyscalls(kubeArmorPolicy, point)
+				}
 			}
 		}
 	}
-	setDefaultValues(kubeArmorPolicy)
+	if len(kubeArmorPolicies) == 0 {
+		kubeArmorPolicies = append(kubeArmorPolicies, newKubeArmorPolicy(kap, kap.Namespace, 0))
+	}
+	for _, kubeArmorPolicy := range kubeArmorPolicies {
+		setDefaultValues(kubeArmorPolicy)
+	}
 
-	logger.Info("KubeArmorPolicy converted")
-	return kubeArmorPolicy, nil
+	logger.Info("KubeArmorPolicy converted", "Count", len(kubeArmorPolicies))
+	return kubeArmorPolicies, nil
 }
 
-// extractSelector extracts match labels from a Selector.
-func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
-	matchLabels := make(map[string]string) // Initialize map for match labels.
-
-	// Process CEL expressions.
-	if len(selector.CEL) > 0 {
-		celExpressions := selector.CEL
-		celMatchLabels, err := processor.ProcessCEL(ctx, k8sClient, namespace, celExpressions)
-		if err != nil {
-			return nil, fmt.Errorf("error processing CEL: %v", err)
-		}
-		for k, v := range celMatchLabels {
-			matchLabels[k] = v
-		}
+// newKubeArmorPolicy returns the index-th KubeArmorPolicy of a KubeAegisPolicy
+// in namespace. The first is named after the policy alone, the others get
+// their index as a suffix.
+func newKubeArmorPolicy(kap *v1.KubeAegisPolicy, namespace string, index int) *karmorv1.KubeArmorPolicy {
+	name := generateKubeArmorPolicyName(kap.Name)
+	if index > 0 {
+		name += "-" + strconv.Itoa(index)
 	}
+	return &karmorv1.KubeArmorPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				OriginPolicyLabel:    generateKubeArmorPolicyName(kap.Name),
+				OriginNamespaceLabel: kap.Namespace,
+			},
+		},
+		Spec: karmorv1.KubeArmorPolicySpec{},
+	}
+}
 
-	// Process Match fields.
-	if len(selector.Match) > 0 {
-		processedMatchLabels, err := processor.ProcessMatchLabels(selector.Match)
-		if err != nil {
-			return nil, errors.Wrap(err, "error processing matchLabels")
-		}
-		for key, value := range processedMatchLabels {
-			matchLabels[key] = value
-		}
+// extractSelector compiles a Selector into the single matchLabels map the
+// engine supports. It returns nil for an empty Selector.
+func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
+	}
+	if compiled.IsEmpty() {
+		return nil, nil
 	}
 
+	matchLabels, err := compiled.MatchLabels(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
 	return matchLabels, nil
 }
 
 func generateKubeArmorPolicyName(kapName string) string {
 	return "ksp-" + kapName
 }
+
+// OriginPolicyName returns the value of the OriginPolicyLabel on the
+// KubeArmorPolicies of a KubeAegisPolicy.
+func OriginPolicyName(kapName string) string {
+	return generateKubeArmorPolicyName(kapName)
+}
@@ -22,7 +22,9 @@ func handleProcess(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
 	if len(point.Resource.Path) > 0 {
 		for _, path := range point.Resource.Path {
 			ksp.Spec.Process.MatchPaths = append(ksp.Spec.Process.MatchPaths, karmorv1.ProcessPathType{
-				Path: karmorv1.MatchPathType(path),
+				Path:       karmorv1.MatchPathType(path),
+				OwnerOnly:  point.Resource.OwnerOnly,
+				FromSource: toMatchSources(point.Resource.FromSource),
 			})
 		}
 	}
@@ -30,17 +32,19 @@ func handleProcess(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
 	if len(point.Resource.Pattern) > 0 {
 		for _, pattern := range point.Resource.Pattern {
 			ksp.Spec.Process.MatchPatterns = append(ksp.Spec.Process.MatchPatterns, karmorv1.ProcessPatternType{
-				Pattern: pattern,
+				Pattern:   pattern,
+				OwnerOnly: point.Resource.OwnerOnly,
 			})
 		}
 	}
 
 	if len(point.Resource.Dir) > 0 {
-		for _, dir := range point.Resource.Dir {
-			ksp.Spec.Process.MatchDirectories = append(ksp.Spec.Process.MatchDirectories, karmorv1.ProcessDirectoryType{
-				Directory: karmorv1.MatchDirectoryType(dir),
-			})
-		}
+		ksp.Spec.Process.MatchDirectories = append(ksp.Spec.Process.MatchDirectories, karmorv1.ProcessDirectoryType{
+			Directory:  karmorv1.MatchDirectoryType(point.Resource.Dir),
+			Recursive:  point.Resource.Recursive,
+			OwnerOnly:  point.Resource.OwnerOnly,
+			FromSource: toMatchSources(point.Resource.FromSource),
+		})
 	}
 }
 
@@ -48,7 +52,10 @@ func handleFile(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
 	if len(point.Resource.Path) > 0 {
 		for _, path := range point.Resource.Path {
 			ksp.Spec.File.MatchPaths = append(ksp.Spec.File.MatchPaths, karmorv1.FilePathType{
-				Path: karmorv1.MatchPathType(path),
+				Path:       karmorv1.MatchPathType(path),
+				ReadOnly:   point.Resource.ReadOnly,
+				OwnerOnly:  point.Resource.OwnerOnly,
+				FromSource: toMatchSources(point.Resource.FromSource),
 			})
 		}
 	}
@@ -56,17 +63,21 @@ func handleFile(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
 	if len(point.Resource.Pattern) > 0 {
 		for _, pattern := range point.Resource.Pattern {
 			ksp.Spec.File.MatchPatterns = append(ksp.Spec.File.MatchPatterns, karmorv1.FilePatternType{
-				Pattern: pattern,
+				Pattern:   pattern,
+				ReadOnly:  point.Resource.ReadOnly,
+				OwnerOnly: point.Resource.OwnerOnly,
 			})
 		}
 	}
 
 	if len(point.Resource.Dir) > 0 {
-		for _, dir := range point.Resource.Dir {
-			ksp.Spec.File.MatchDirectories = append(ksp.Spec.File.MatchDirectories, karmorv1.FileDirectoryType{
-				Directory: karmorv1.MatchDirectoryType(dir),
-			})
-		}
+		ksp.Spec.File.MatchDirectories = append(ksp.Spec.File.MatchDirectories, karmorv1.FileDirectoryType{
+			Directory:  karmorv1.MatchDirectoryType(point.Resource.Dir),
+			ReadOnly:   point.Resource.ReadOnly,
+			Recursive:  point.Resource.Recursive,
+			OwnerOnly:  point.Resource.OwnerOnly,
+			FromSource: toMatchSources(point.Resource.FromSource),
+		})
 	}
 }
 
@@ -75,12 +86,28 @@ func handleNetwork(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
 		if ksp.Spec.Network.MatchProtocols == nil {
 			ksp.Spec.Network.MatchProtocols = []karmorv1.MatchNetworkProtocolType{}
 		}
-		for _, net := range point.Resource.Protocol {
-			ksp.Spec.Network.MatchProtocols = append(ksp.Spec.Network.MatchProtocols, karmorv1.MatchNetworkProtocolType{
-				Protocol: karmorv1.MatchNetworkProtocolStringType(net),
-			})
-		}
+		ksp.Spec.Network.MatchProtocols = append(ksp.Spec.Network.MatchProtocols, karmorv1.MatchNetworkProtocolType{
+			Protocol:   karmorv1.MatchNetworkProtocolStringType(point.Resource.Protocol),
+			FromSource: toMatchSources(point.Resource.FromSource),
+		})
+	}
+}
+
+// toMatchSources converts the fromSource of an action point. KubeArmor
+// patterns take no sources, so the validator rejects them there.
+func toMatchSources(sources []v1.FromSource) []karmorv1.MatchSourceType {
+	if len(sources) == 0 {
+		return nil
+	}
+	matchSources := make([]karmorv1.MatchSourceType, 0, len(sources))
+	for _, source := range sources {
+		matchSources = append(matchSources, karmorv1.MatchSourceType{
+			Path:      karmorv1.MatchPathType(source.Path),
+			Directory: karmorv1.MatchDirectoryType(source.Dir),
+			Recursive: source.Recursive,
+		})
 	}
+	return matchSources
 }
 
 func handleCapabilities(ksp *karmorv1.KubeArmorPolicy, point v1.ActionPoint) {
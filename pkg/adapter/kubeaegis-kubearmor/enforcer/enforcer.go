@@ -6,9 +6,11 @@ import (
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/adapter/kubeaegis-kubearmor/converter"
 	"github.com/cclab-inu/KubeAegis/pkg/statusmanager"
 	karmorv1 "github.com/kubearmor/KubeArmor/pkg/KubeArmorController/api/security.kubearmor.com/v1"
 )
@@ -22,9 +24,13 @@ func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 		return "", err
 	}
 
-	if err := statusmanager.SetOwnerReferencesKSP(ctx, k8sClient, kap, kubeArmorPolicy); err != nil {
-		logger.Error(err, "failed to set KubeAegisPolicy as owner of KubeArmorPolicy")
-		return "", err
+	// Owner references cannot cross namespaces; copies fanned out to other
+	// namespaces are removed through their origin labels instead.
+	if kubeArmorPolicy.Namespace == kap.Namespace {
+		if err := statusmanager.SetOwnerReferencesKSP(ctx, k8sClient, kap, kubeArmorPolicy); err != nil {
+			logger.Error(err, "failed to set KubeAegisPolicy as owner of KubeArmorPolicy")
+			return "", err
+		}
 	}
 
 	// Update if exists, create otherwise
@@ -36,12 +42,79 @@ func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 		}
 	} else {
 		logger.Info("KubeArmorPolicy updated", "PolicyName", kubeArmorPolicy.Name, "KubeArmor.Namespace", kubeArmorPolicy.Namespace)
+		before := existingPolicy.DeepCopy()
 		existingPolicy.Spec = kubeArmorPolicy.Spec
+		existingPolicy.Labels = kubeArmorPolicy.Labels
 		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
 			logger.Error(err, "failed to update KubeArmorPolicy", "KubeArmor.Name", kubeArmorPolicy.Name, "KubeArmor.Namespace", kubeArmorPolicy.Namespace)
 			return "", err
 		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "KubeArmorPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to KubeArmorPolicy", "KubeArmor.Name", kubeArmorPolicy.Name, "KubeArmor.Namespace", kubeArmorPolicy.Namespace)
+		}
 	}
 
 	return kubeArmorPolicy.Name, nil
 }
+
+// Prune deletes the KubeArmorPolicies of a KubeAegisPolicy that are not in
+// keep: those it owns in its own namespace, and the copies fanned out to
+// other namespaces.
+func Prune(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy, keep []types.NamespacedName) error {
+	var policies karmorv1.KubeArmorPolicyList
+	if err := k8sClient.List(ctx, &policies, client.InNamespace(kap.Namespace)); err != nil {
+		logger.Error(err, "failed to list KubeArmorPolicies", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(client.ObjectKeyFromObject(policy)) || !ownedBy(policy, kap) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete KubeArmorPolicy", "KubeArmor.Name", policy.Name, "KubeArmor.Namespace", policy.Namespace)
+			return err
+		}
+		logger.Info("KubeArmorPolicy deleted", "KubeArmor.Name", policy.Name, "KubeArmor.Namespace", policy.Namespace)
+	}
+
+	return PruneFanOut(ctx, k8sClient, logger, converter.OriginPolicyName(kap.Name), kap.Namespace, keep)
+}
+
+// PruneFanOut deletes the KubeArmorPolicies labeled with a policy that are not
+// in keep.
+func PruneFanOut(ctx context.Context, k8sClient client.Client, logger logr.Logger, policyName, kapNamespace string, keep []types.NamespacedName) error {
+	var policies karmorv1.KubeArmorPolicyList
+	if err := k8sClient.List(ctx, &policies, client.MatchingLabels{
+		converter.OriginPolicyLabel:    policyName,
+		converter.OriginNamespaceLabel: kapNamespace,
+	}); err != nil {
+		logger.Error(err, "failed to list fanned-out KubeArmorPolicies", "KubeArmor.Name", policyName)
+		return err
+	}
+
+	keepSet := sets.New(keep...)
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if keepSet.Has(client.ObjectKeyFromObject(policy)) {
+			continue
+		}
+		if err := k8sClient.Delete(ctx, policy); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to delete KubeArmorPolicy", "KubeArmor.Name", policy.Name, "KubeArmor.Namespace", policy.Namespace)
+			return err
+		}
+		logger.Info("KubeArmorPolicy deleted", "KubeArmor.Name", policy.Name, "KubeArmor.Namespace", policy.Namespace)
+	}
+	return nil
+}
+
+func ownedBy(policy *karmorv1.KubeArmorPolicy, kap *v1.KubeAegisPolicy) bool {
+	for _, owner := range policy.GetOwnerReferences() {
+		if owner.Kind == "KubeAegisPolicy" && owner.UID == kap.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -39,35 +40,49 @@ func Run(ctx context.Context, logger logr.Logger, KapName string, KapNamespace s
 	}
 	logger.Info("KubeAegisPolicy fetched", "KubeAegis.Name", kap.Name, "KubeAegis.Namespace", kap.Namespace)
 
-	ksp, err := converter.Converter(ctx, k8sClient, logger, kap)
+	ksps, err := converter.Converter(ctx, k8sClient, logger, kap)
 	if err != nil {
 		return "", err
 	}
 
-	if kspname, err = enforcer.Enforcer(ctx, k8sClient, logger, ksp, kap); err != nil {
-		return "", err
-	}
+	var resourceNames []string
+	var keep []types.NamespacedName
+	for _, ksp := range ksps {
+		if kspname, err = enforcer.Enforcer(ctx, k8sClient, logger, ksp, kap); err != nil {
+			return "", err
+		}
+		keep = append(keep, client.ObjectKeyFromObject(ksp))
 
-	podList := &corev1.PodList{}
-	if err := k8sClient.List(ctx, podList, client.InNamespace(KapNamespace), client.MatchingLabels(ksp.Spec.Selector.MatchLabels)); err != nil {
-		logger.Error(err, "failed to list Pods matching the policy")
-		return "", err
-	}
+		podList := &corev1.PodList{}
+		if err := k8sClient.List(ctx, podList, client.InNamespace(ksp.Namespace), client.MatchingLabels(ksp.Spec.Selector.MatchLabels)); err != nil {
+			logger.Error(err, "failed to list Pods matching the policy")
+			return "", err
+		}
 
-	var resourceNames []string
-	for _, pod := range podList.Items {
-		if err := statusmanager.SetOwnerReferencesPodfromKSP(ctx, k8sClient, ksp, &pod); err != nil {
-			logger.Error(err, "failed to set OwnerReferences for Pod", "Pod.Name", pod.Name)
+		for _, pod := range podList.Items {
+			if pod.Namespace == KapNamespace {
+				resourceNames = append(resourceNames, pod.Name)
+			} else {
+				resourceNames = append(resourceNames, pod.Namespace+"/"+pod.Name)
+			}
+		}
+
+		if err := statusmanager.UpdateKapStatusAfterPolicywithResource(ctx, k8sClient, kspname, KapName, KapNamespace, resourceNames); err != nil {
+			logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
 			return "", err
 		}
-		// currResourceFullName := fmt.Sprintf("%s", pod.Name)
-		resourceNames = append(resourceNames, pod.Name)
+		resourceNames = nil
 	}
 
-	if err := statusmanager.UpdateKapStatusAfterPolicywithResource(ctx, k8sClient, kspname, KapName, KapNamespace, resourceNames); err != nil {
-		logger.Error(err, "failed to update KubeAegisPolicy status", "KubeAegis.Name", KapName, "KubeAegis.Namespace", KapNamespace)
+	if err := enforcer.Prune(ctx, k8sClient, logger, kap, keep); err != nil {
 		return "", err
 	}
 
 	return kspname, nil
 }
+
+// Cleanup deletes the KubeArmorPolicies fanned out to other namespaces for a
+// deleted KubeAegisPolicy. Those in its own namespace are garbage collected.
+func Cleanup(ctx context.Context, logger logr.Logger, policyName string, kapNamespace string) error {
+	return enforcer.PruneFanOut(ctx, k8sClient, logger, policyName, kapNamespace, nil)
+}
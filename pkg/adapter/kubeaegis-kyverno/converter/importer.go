@@ -0,0 +1,326 @@
+package converter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/importer"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+	kyvernov1 "github.com/kyverno/kyverno/api/kyverno/v1"
+)
+
+// Import converts a Kyverno ClusterPolicy back into a KubeAegisPolicy in
+// namespace, with one cluster intent per rule. Operations the converter
+// cannot produce are left out, and every field that was not imported is
+// reported. Fields the API server defaults to what Kyverno does anyway are
+// not. The policy is nil when no rule could be imported.
+func Import(policy *kyvernov1.ClusterPolicy, namespace string) (*v1.KubeAegisPolicy, validator.ResultList, error) {
+	mapped := importer.NewMapped()
+	spec := field.NewPath("spec")
+
+	action := "Audit"
+	if strings.EqualFold(string(policy.Spec.ValidationFailureAction), "enforce") {
+		action = "Enforce"
+	}
+	mapped.Add(spec.Child("validationFailureAction"), spec.Child("background"), spec.Child("admission"))
+
+	var intents []v1.IntentRequest
+	for i := range policy.Spec.Rules {
+		rule := &policy.Spec.Rules[i]
+		rulePath := spec.Child("rules").Index(i)
+
+		selector, ok := importMatch(rule.MatchResources)
+		if !ok {
+			continue
+		}
+		points, paths := importOperations(rule, rulePath)
+		if len(points) == 0 {
+			continue
+		}
+
+		// Rule names are generated from the policy name and intent type.
+		mapped.Add(rulePath.Child("name"), rulePath.Child("match"), rulePath.Child("skipBackgroundRequests"))
+		mapped.Add(paths...)
+		intents = append(intents, v1.IntentRequest{
+			Type:     "cluster",
+			Selector: selector,
+			Rule:     v1.Rule{Action: action, ActionPoint: points},
+		})
+	}
+
+	results, err := mapped.Unmapped(policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(intents) == 0 {
+		return nil, results, nil
+	}
+
+	kap := importer.NewPolicy(policy.Name, "kyverno-", namespace)
+	kap.Spec.IntentRequest = intents
+	return kap, results, nil
+}
+
+// importMatch converts the any and all resource filters of a rule into Match
+// entries, the inverse of processor.ProcessMatch. Filters of several kinds or
+// namespaces are split into one entry each, which only keeps their meaning
+// under any. The namespaceSelector must be the same for every filter.
+func importMatch(match kyvernov1.MatchResources) (v1.Selector, bool) {
+	if !importer.OnlySet(&match, "any", "all") || (len(match.Any) == 0 && len(match.All) == 0) {
+		return v1.Selector{}, false
+	}
+
+	var selector v1.Selector
+	first := true
+	for _, condition := range []string{"any", "all"} {
+		filters := match.Any
+		if condition == "all" {
+			filters = match.All
+		}
+
+		for i := range filters {
+			filter := &filters[i]
+			description := &filter.ResourceDescription
+			if !importer.OnlySet(filter, "resources") ||
+				!importer.OnlySet(description, "kinds", "namespaces", "name", "selector", "namespaceSelector") ||
+				len(description.Kinds) == 0 {
+				return v1.Selector{}, false
+			}
+			if first {
+				selector.NamespaceSelector = description.NamespaceSelector
+				first = false
+			} else if !reflect.DeepEqual(selector.NamespaceSelector, description.NamespaceSelector) {
+				return v1.Selector{}, false
+			}
+
+			namespaces := description.Namespaces
+			if len(namespaces) == 0 {
+				namespaces = []string{""}
+			}
+			if condition == "all" && (len(description.Kinds) > 1 || len(namespaces) > 1) {
+				return v1.Selector{}, false
+			}
+
+			labelSelector := description.Selector
+			if labelSelector == nil {
+				labelSelector = &metav1.LabelSelector{}
+			}
+			for _, kind := range description.Kinds {
+				for _, namespace := range namespaces {
+					selector.Match = append(selector.Match, v1.Match{
+						Kind:             kind,
+						Condition:        condition,
+						Namespace:        namespace,
+						Name:             description.Name,
+						MatchLabels:      labelSelector.MatchLabels,
+						MatchExpressions: labelSelector.MatchExpressions,
+					})
+				}
+			}
+		}
+	}
+	return selector, true
+}
+
+// importOperations converts the mutation, validation and image verification
+// of a rule into action points, and returns the paths of the fields it
+// carried over. Image verification cannot be combined with anything else,
+// since the converter reads image references from every action point.
+func importOperations(rule *kyvernov1.Rule, rulePath *field.Path) ([]v1.ActionPoint, []*field.Path) {
+	var points []v1.ActionPoint
+	var paths []*field.Path
+
+	if rule.Mutation != nil {
+		if point, ok := importMutation(rule.Mutation); ok {
+			points = append(points, point)
+			paths = append(paths, rulePath.Child("mutate", "patchStrategicMerge"))
+		}
+	}
+
+	if rule.Validation != nil {
+		if point, variant, ok := importValidation(rule.Validation); ok {
+			points = append(points, v1.ActionPoint{SubType: "validate"}, point)
+			paths = append(paths, rulePath.Child("validate", variant), rulePath.Child("validate", "allowExistingViolations"))
+		}
+	}
+
+	if len(rule.VerifyImages) == 1 && rule.Mutation == nil && rule.Validation == nil {
+		if point, ok := importImageVerification(&rule.VerifyImages[0]); ok {
+			points = append(points, point)
+			verifyPath := rulePath.Child("verifyImages").Index(0)
+			for _, name := range []string{"imageReferences", "attestors", "type", "mutateDigest", "verifyDigest", "required", "useCache"} {
+				paths = append(paths, verifyPath.Child(name))
+			}
+		}
+	}
+	return points, paths
+}
+
+// importMutation converts a strategic merge patch that only sets annotations
+// or only sets labels.
+func importMutation(mutation *kyvernov1.Mutation) (v1.ActionPoint, bool) {
+	if !importer.OnlySet(mutation, "patchStrategicMerge") || mutation.RawPatchStrategicMerge == nil {
+		return v1.ActionPoint{}, false
+	}
+
+	var patch map[string]map[string]map[string]string
+	if err := json.Unmarshal(mutation.RawPatchStrategicMerge.Raw, &patch); err != nil || len(patch) != 1 || len(patch["metadata"]) != 1 {
+		return v1.ActionPoint{}, false
+	}
+	for key, kind := range map[string]string{"annotations": "annotations", "labels": "label"} {
+		if values, ok := patch["metadata"][key]; ok && len(values) > 0 {
+			return v1.ActionPoint{SubType: "mutate", Resource: v1.EventMatchResource{Kind: kind, Details: []map[string]string{values}}}, true
+		}
+	}
+	return v1.ActionPoint{}, false
+}
+
+// importValidation converts a validation that uses one of CEL, pod security,
+// deny conditions or a flat pattern, and returns the name of the field it
+// used. The message is not carried over.
+func importValidation(validation *kyvernov1.Validation) (v1.ActionPoint, string, bool) {
+	switch {
+	case validation.CEL != nil:
+		cel := validation.CEL
+		if !importer.OnlySet(validation, "cel", "message", "allowExistingViolations") ||
+			!importer.OnlySet(cel, "expressions") || len(cel.Expressions) != 1 ||
+			!importer.OnlySet(&cel.Expressions[0], "expression", "message") {
+			return v1.ActionPoint{}, "", false
+		}
+		return v1.ActionPoint{SubType: "cel", Resource: v1.EventMatchResource{Details: []map[string]string{{
+			"expression": cel.Expressions[0].Expression,
+			"message":    cel.Expressions[0].Message,
+		}}}}, "cel", true
+
+	case validation.PodSecurity != nil:
+		podSecurity := validation.PodSecurity
+		if !importer.OnlySet(validation, "podSecurity", "message", "allowExistingViolations") ||
+			!importer.OnlySet(podSecurity, "level", "version") {
+			return v1.ActionPoint{}, "", false
+		}
+		return v1.ActionPoint{SubType: "podSecurity", Resource: v1.EventMatchResource{Details: []map[string]string{{
+			"level":   string(podSecurity.Level),
+			"version": podSecurity.Version,
+		}}}}, "podSecurity", true
+
+	case validation.Deny != nil:
+		if !importer.OnlySet(validation, "deny", "message", "allowExistingViolations") || !importer.OnlySet(validation.Deny, "conditions") {
+			return v1.ActionPoint{}, "", false
+		}
+		filters, ok := importDenyConditions(validation.Deny)
+		if !ok {
+			return v1.ActionPoint{}, "", false
+		}
+		return v1.ActionPoint{SubType: "deny", Resource: v1.EventMatchResource{Filter: filters}}, "deny", true
+
+	case validation.RawPattern != nil:
+		if !importer.OnlySet(validation, "pattern", "message", "allowExistingViolations") {
+			return v1.ActionPoint{}, "", false
+		}
+		var pattern map[string]string
+		if err := json.Unmarshal(validation.RawPattern.Raw, &pattern); err != nil || len(pattern) == 0 {
+			return v1.ActionPoint{}, "", false
+		}
+		return v1.ActionPoint{SubType: "pattern", Resource: v1.EventMatchResource{Details: []map[string]string{pattern}}}, "pattern", true
+	}
+	return v1.ActionPoint{}, "", false
+}
+
+// importDenyConditions converts any and all conditions whose key is a string
+// and whose value is a list of strings, as the converter writes them.
+func importDenyConditions(deny *kyvernov1.Deny) ([]v1.EventFilter, bool) {
+	if deny.RawAnyAllConditions == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(deny.RawAnyAllConditions.Conditions)
+	if err != nil {
+		return nil, false
+	}
+	var conditions map[string][]kyvernov1.Condition
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return nil, false
+	}
+
+	var filters []v1.EventFilter
+	for name, list := range conditions {
+		if name != "any" && name != "all" {
+			return nil, false
+		}
+		for i := range list {
+			condition := &list[i]
+			if !importer.OnlySet(condition, "key", "operator", "value") || condition.RawKey == nil || condition.RawValue == nil {
+				return nil, false
+			}
+			filter := v1.EventFilter{Condition: name, Operator: string(condition.Operator)}
+			if err := json.Unmarshal(condition.RawKey.Raw, &filter.Key); err != nil {
+				return nil, false
+			}
+			if err := json.Unmarshal(condition.RawValue.Raw, &filter.Value); err != nil {
+				return nil, false
+			}
+			filters = append(filters, filter)
+		}
+	}
+	return filters, len(filters) > 0
+}
+
+// importImageVerification converts the image references and a single set of
+// KMS keys, variable public keys and keyless attestors.
+func importImageVerification(verification *kyvernov1.ImageVerification) (v1.ActionPoint, bool) {
+	if !importer.OnlySet(verification, "imageReferences", "attestors", "type", "mutateDigest", "verifyDigest", "required", "useCache") ||
+		(verification.Type != "" && verification.Type != kyvernov1.Cosign) ||
+		len(verification.ImageReferences) == 0 || len(verification.Attestors) > 1 {
+		return v1.ActionPoint{}, false
+	}
+
+	point := v1.ActionPoint{SubType: "verifyImage"}
+	for _, reference := range verification.ImageReferences {
+		point.Resource.Details = append(point.Resource.Details, map[string]string{reference: ""})
+	}
+	if len(verification.Attestors) == 0 {
+		return point, true
+	}
+
+	attestorSet := &verification.Attestors[0]
+	if !importer.OnlySet(attestorSet, "entries") {
+		return v1.ActionPoint{}, false
+	}
+	for i := range attestorSet.Entries {
+		entry := &attestorSet.Entries[i]
+		switch {
+		case entry.Keys != nil && importer.OnlySet(entry, "keys"):
+			keys := entry.Keys
+			if !importer.OnlySet(keys, "publicKeys", "kms", "signatureAlgorithm") ||
+				(keys.SignatureAlgorithm != "" && keys.SignatureAlgorithm != "sha256") {
+				return v1.ActionPoint{}, false
+			}
+			switch {
+			case strings.HasPrefix(keys.KMS, "kms:") && keys.PublicKeys == "":
+				point.Resource.Keys = append(point.Resource.Keys, keys.KMS)
+			case strings.HasPrefix(keys.PublicKeys, "{{") && keys.KMS == "":
+				point.Resource.Keys = append(point.Resource.Keys, keys.PublicKeys)
+			default:
+				return v1.ActionPoint{}, false
+			}
+		case entry.Keyless != nil && importer.OnlySet(entry, "keyless"):
+			keyless := entry.Keyless
+			if !importer.OnlySet(keyless, "issuer", "subject", "rekor") ||
+				(keyless.Rekor != nil && !importer.OnlySet(keyless.Rekor, "url")) {
+				return v1.ActionPoint{}, false
+			}
+			imported := v1.Keyless{Issuer: keyless.Issuer, Subject: keyless.Subject}
+			if keyless.Rekor != nil {
+				imported.Url = keyless.Rekor.URL
+			}
+			point.Resource.Keyless = append(point.Resource.Keyless, imported)
+		default:
+			return v1.ActionPoint{}, false
+		}
+	}
+	return point, true
+}
@@ -2,6 +2,7 @@ package converter
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -13,8 +14,23 @@ import (
 	kyvernov1 "github.com/kyverno/kyverno/api/kyverno/v1"
 )
 
+// SupportedTypes are the intent types and subtypes the adapter advertises to
+// the controller through GetInfo.
+var SupportedTypes = map[string][]string{
+	"cluster": {"mutate", "validate", "verifyImage"},
+}
+
+// Scopes are the policy scopes the converter renders. The ClusterPolicy
+// matches the namespaces the selector names, as for a namespaced policy.
+var Scopes = []string{v1.ScopeNamespace}
+
 func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (*kyvernov1.ClusterPolicy, error) {
 	logger.Info("Converting KubeAegisPolicy to KyvernoPolicy")
+	if kap.Spec.Scope != "" && kap.Spec.Scope != v1.ScopeNamespace {
+		err := fmt.Errorf("scope %s is not supported; the Kyverno adapter only renders namespaced policies", kap.Spec.Scope)
+		logger.Error(err, "failed to convert KubeAegisPolicy")
+		return nil, err
+	}
 	background := true
 	hasValidateSubType := false
 	kyvernoPolicy := &kyvernov1.ClusterPolicy{
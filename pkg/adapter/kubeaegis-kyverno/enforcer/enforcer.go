@@ -36,11 +36,15 @@ func Enforcer(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 		}
 	} else {
 		logger.Info("KyvernoPolicy updated", "PolicyName", kyvernoPolicy.Name, "Kyverno.Namespace", kyvernoPolicy.Namespace)
+		before := existingPolicy.DeepCopy()
 		existingPolicy.Spec = kyvernoPolicy.Spec
 		if err := k8sClient.Update(ctx, existingPolicy); err != nil {
 			logger.Error(err, "failed to update KyvernoPolicy", "Kyverno.Name", kyvernoPolicy.Name, "Kyverno.Namespace", kyvernoPolicy.Namespace)
 			return "", err
 		}
+		if err := statusmanager.RecordPolicyDiff(ctx, k8sClient, kap, "ClusterPolicy", before, existingPolicy); err != nil {
+			logger.Error(err, "failed to record the changes to ClusterPolicy", "Kyverno.Name", kyvernoPolicy.Name, "Kyverno.Namespace", kyvernoPolicy.Namespace)
+		}
 	}
 
 	return kyvernoPolicy.Name, nil
@@ -34,7 +34,7 @@ func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 			logger.Error(err, "failed to extract selector")
 			return nil, err
 		}
-		if len(matchLabels) == 0 {
+		if matchLabels == nil {
 			continue
 		}
 
@@ -60,33 +60,21 @@ func Converter(ctx context.Context, k8sClient client.Client, logger logr.Logger,
 	return policy, nil
 }
 
-// extractSelector extracts match labels from a Selector.
+// extractSelector compiles a Selector into the single matchLabels map the
+// engine supports. It returns nil for an empty Selector.
 func extractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
-	matchLabels := make(map[string]string) // Initialize map for match labels.
-
-	// Process CEL expressions.
-	if len(selector.CEL) > 0 {
-		celExpressions := selector.CEL
-		celMatchLabels, err := processor.ProcessCEL(ctx, k8sClient, namespace, celExpressions)
-		if err != nil {
-			return nil, fmt.Errorf("error processing CEL: %v", err)
-		}
-		for k, v := range celMatchLabels {
-			matchLabels[k] = v
-		}
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
 	}
-
-	// Process Match fields.
-	if len(selector.Match) > 0 {
-		processedMatchLabels, err := processor.ProcessMatchLabels(selector.Match)
-		if err != nil {
-			return nil, errors.Wrap(err, "error processing matchLabels")
-		}
-		for key, value := range processedMatchLabels {
-			matchLabels[key] = value
-		}
+	if compiled.IsEmpty() {
+		return nil, nil
 	}
 
+	matchLabels, err := compiled.MatchLabels(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
 	return matchLabels, nil
 }
 
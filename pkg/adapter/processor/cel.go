@@ -0,0 +1,257 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Variables exposed to Selector.CEL expressions. Every expression is evaluated
+// once per Pod:
+//
+//	object             the whole Pod as an unstructured map
+//	metadata           object.metadata
+//	labels             metadata.labels, never null
+//	annotations        metadata.annotations, never null
+//	namespaceName      metadata.namespace; "namespace" itself is a reserved
+//	                   word in CEL and cannot be used as a variable name
+//	spec               object.spec
+//	serviceAccountName spec.serviceAccountName
+const (
+	celVarObject             = "object"
+	celVarMetadata           = "metadata"
+	celVarLabels             = "labels"
+	celVarAnnotations        = "annotations"
+	celVarNamespace          = "namespaceName"
+	celVarSpec               = "spec"
+	celVarServiceAccountName = "serviceAccountName"
+)
+
+var (
+	celEnvOnce sync.Once
+	celEnv     *cel.Env
+	celEnvErr  error
+)
+
+// CELEnv returns the CEL environment shared by the validator and the adapters
+// for evaluating Selector.CEL expressions against Pods.
+func CELEnv() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		celEnv, celEnvErr = cel.NewEnv(
+			cel.OptionalTypes(),
+			cel.Variable(celVarObject, cel.DynType),
+			cel.Variable(celVarMetadata, cel.DynType),
+			cel.Variable(celVarLabels, cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable(celVarAnnotations, cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable(celVarNamespace, cel.StringType),
+			cel.Variable(celVarSpec, cel.DynType),
+			cel.Variable(celVarServiceAccountName, cel.StringType),
+		)
+	})
+	return celEnv, celEnvErr
+}
+
+// CompileCEL compiles the given expressions and checks that each of them
+// evaluates to a bool. Expressions over the dyn-typed object, metadata and
+// spec variables are only known to be bool when they are evaluated, so
+// MatchPodCEL checks those.
+func CompileCEL(expressions []string) ([]cel.Program, error) {
+	env, err := CELEnv()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create CEL environment")
+	}
+
+	programs := make([]cel.Program, 0, len(expressions))
+	for _, expr := range expressions {
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("CEL compile error in %q: %s", expr, issues.Err())
+		}
+		if outputType := ast.OutputType(); !outputType.IsExactType(cel.BoolType) && !outputType.IsExactType(cel.DynType) {
+			return nil, fmt.Errorf("CEL expression %q must evaluate to bool, got %s", expr, ast.OutputType())
+		}
+
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CEL program for %q: %w", expr, err)
+		}
+		programs = append(programs, prg)
+	}
+
+	return programs, nil
+}
+
+// MatchPodCEL reports whether the pod satisfies every program. A runtime error,
+// such as indexing a label the pod does not carry, counts as a non-match. A
+// result that is not a bool is an error.
+func MatchPodCEL(programs []cel.Program, pod *corev1.Pod) (bool, error) {
+	activation, err := podActivation(pod)
+	if err != nil {
+		return false, err
+	}
+
+	for _, prg := range programs {
+		out, _, err := prg.Eval(activation)
+		if err != nil {
+			return false, nil
+		}
+		matched, ok := out.Value().(bool)
+		if !ok {
+			return false, fmt.Errorf("CEL expression must evaluate to bool, got %s for pod %s/%s", out.Type().TypeName(), pod.Namespace, pod.Name)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// EvaluateCEL returns the pods in namespace that satisfy all expressions.
+// An empty namespace evaluates the expressions against pods in every namespace.
+func EvaluateCEL(ctx context.Context, k8sClient client.Client, namespace string, expressions []string) ([]corev1.Pod, error) {
+	_, matched, err := evaluateCEL(ctx, k8sClient, namespace, expressions)
+	return matched, err
+}
+
+// evaluateCEL returns every pod in namespace along with those satisfying all expressions.
+func evaluateCEL(ctx context.Context, k8sClient client.Client, namespace string, expressions []string) ([]corev1.Pod, []corev1.Pod, error) {
+	programs, err := CompileCEL(expressions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var podList corev1.PodList
+	if err := k8sClient.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return nil, nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	var matched []corev1.Pod
+	for i := range podList.Items {
+		ok, err := MatchPodCEL(programs, &podList.Items[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			matched = append(matched, podList.Items[i])
+		}
+	}
+
+	return podList.Items, matched, nil
+}
+
+// controllerLabels are set on pods by their controllers and change with each
+// rollout or pod, so selectors built from the labels of live pods leave them
+// out.
+var controllerLabels = []string{
+	"pod-template-hash",
+	"controller-revision-hash",
+	"pod-template-generation",
+	"statefulset.kubernetes.io/pod-name",
+	"apps.kubernetes.io/pod-index",
+	"batch.kubernetes.io/controller-uid",
+	"controller-uid",
+	"batch.kubernetes.io/job-name",
+	"job-name",
+	"batch.kubernetes.io/job-completion-index",
+}
+
+// WorkloadLabels returns the labels of a pod without the controller-managed
+// ones.
+func WorkloadLabels(podLabels map[string]string) map[string]string {
+	workloadLabels := map[string]string{}
+	for key, value := range podLabels {
+		if !contains(controllerLabels, key) {
+			workloadLabels[key] = value
+		}
+	}
+	return workloadLabels
+}
+
+// CommonLabels returns the labels shared by every pod, ignoring the
+// controller-managed ones.
+func CommonLabels(pods []corev1.Pod) map[string]string {
+	if len(pods) == 0 {
+		return map[string]string{}
+	}
+
+	common := WorkloadLabels(pods[0].Labels)
+	for _, pod := range pods[1:] {
+		for key, value := range common {
+			if pod.Labels[key] != value {
+				delete(common, key)
+			}
+		}
+	}
+
+	return common
+}
+
+// podActivation builds the CEL variables for a single pod.
+func podActivation(pod *corev1.Pod) (map[string]interface{}, error) {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert pod %s/%s", pod.Namespace, pod.Name)
+	}
+
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	spec, _ := object["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	podLabels := pod.Labels
+	if podLabels == nil {
+		podLabels = map[string]string{}
+	}
+	podAnnotations := pod.Annotations
+	if podAnnotations == nil {
+		podAnnotations = map[string]string{}
+	}
+
+	return map[string]interface{}{
+		celVarObject:             object,
+		celVarMetadata:           metadata,
+		celVarLabels:             podLabels,
+		celVarAnnotations:        podAnnotations,
+		celVarNamespace:          pod.Namespace,
+		celVarSpec:               spec,
+		celVarServiceAccountName: pod.Spec.ServiceAccountName,
+	}, nil
+}
+
+// podNames returns the sorted namespace/name of each pod, for error messages.
+func podNames(pods []corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectsExactly reports whether selector picks exactly the matched pods out of candidates.
+func selectsExactly(selector labels.Selector, candidates, matched []corev1.Pod) (bool, []corev1.Pod) {
+	want := map[string]bool{}
+	for _, pod := range matched {
+		want[pod.Namespace+"/"+pod.Name] = true
+	}
+
+	var extra []corev1.Pod
+	for _, pod := range candidates {
+		if selector.Matches(labels.Set(pod.Labels)) && !want[pod.Namespace+"/"+pod.Name] {
+			extra = append(extra, pod)
+		}
+	}
+	return len(extra) == 0, extra
+}
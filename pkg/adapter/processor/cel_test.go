@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompileCEL(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: `labels["app"] == "web"`},
+		{expr: `object.spec.hostNetwork`},
+		{expr: `metadata.name.startsWith("web-")`},
+		{expr: `spec.containers.exists(c, c.image.startsWith("nginx"))`},
+		{expr: `labels["app"]`, wantErr: true},
+		{expr: `namespaceName + "x"`, wantErr: true},
+		{expr: `labels[`, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := CompileCEL([]string{tt.expr})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CompileCEL(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchPodCEL(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			HostNetwork:        true,
+			ServiceAccountName: "web",
+			Containers:         []corev1.Container{{Name: "web", Image: "nginx:1.27"}},
+		},
+	}
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{expr: `labels["app"] == "web"`, want: true},
+		{expr: `labels["tier"] == "frontend"`, want: false},
+		{expr: `object.spec.hostNetwork`, want: true},
+		{expr: `object.spec.dnsPolicy == "None"`, want: false},
+		{expr: `metadata.name.startsWith("web-") && serviceAccountName == "web"`, want: true},
+		{expr: `spec.containers.exists(c, c.image.startsWith("nginx"))`, want: true},
+		{expr: `object.metadata.name`, wantErr: true},
+	}
+	for _, tt := range tests {
+		programs, err := CompileCEL([]string{tt.expr})
+		if err != nil {
+			t.Fatalf("CompileCEL(%q): %v", tt.expr, err)
+		}
+		got, err := MatchPodCEL(programs, pod)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MatchPodCEL(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MatchPodCEL(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+)
+
+// compileCELLabels translates CEL expressions that only test pod labels into
+// terms. ok is false when any expression uses something else, such as spec or
+// annotations, and has to be evaluated against live pods instead.
+//
+// Indexing a missing label is an evaluation error, which never matches, so
+// a translated != also requires the label to exist.
+func compileCELLabels(expressions []string) ([]Term, bool, error) {
+	env, err := CELEnv()
+	if err != nil {
+		return nil, false, err
+	}
+
+	terms := []Term{{}}
+	for _, expr := range expressions {
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, false, fmt.Errorf("CEL compile error in %q: %s", expr, issues.Err())
+		}
+		outputType := ast.OutputType()
+		if outputType.IsExactType(cel.DynType) {
+			// Only evaluation tells whether the result is a bool.
+			return nil, false, nil
+		}
+		if !outputType.IsExactType(cel.BoolType) {
+			return nil, false, fmt.Errorf("CEL expression %q must evaluate to bool, got %s", expr, outputType)
+		}
+
+		exprTerms, ok := celToTerms(ast.NativeRep().Expr())
+		if !ok {
+			return nil, false, nil
+		}
+		terms = andTerms(terms, exprTerms)
+	}
+
+	return terms, true, nil
+}
+
+func celToTerms(e celast.Expr) ([]Term, bool) {
+	switch e.Kind() {
+	case celast.LiteralKind:
+		if b, ok := e.AsLiteral().(types.Bool); ok {
+			if b {
+				return []Term{{}}, true
+			}
+			return nil, true
+		}
+		return nil, false
+	case celast.CallKind:
+	default:
+		return nil, false
+	}
+
+	call := e.AsCall()
+	args := call.Args()
+	switch call.FunctionName() {
+	case operators.LogicalAnd:
+		left, ok := celToTerms(args[0])
+		if !ok {
+			return nil, false
+		}
+		right, ok := celToTerms(args[1])
+		if !ok {
+			return nil, false
+		}
+		return andTerms(left, right), true
+	case operators.LogicalOr:
+		left, ok := celToTerms(args[0])
+		if !ok {
+			return nil, false
+		}
+		right, ok := celToTerms(args[1])
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	case operators.LogicalNot:
+		return celNegationToTerms(args[0])
+	case operators.Equals, operators.NotEquals:
+		key, value, ok := labelComparison(args[0], args[1])
+		if !ok {
+			return nil, false
+		}
+		if call.FunctionName() == operators.Equals {
+			return []Term{{{Key: key, Operator: OpIn, Values: []string{value}}}}, true
+		}
+		return []Term{{
+			{Key: key, Operator: OpExists},
+			{Key: key, Operator: OpNotIn, Values: []string{value}},
+		}}, true
+	case operators.In:
+		// "key" in labels
+		if key, ok := stringLiteral(args[0]); ok && isLabelsIdent(args[1]) {
+			return []Term{{{Key: key, Operator: OpExists}}}, true
+		}
+		// labels["key"] in ["a", "b"]
+		key, ok := labelIndex(args[0])
+		if !ok || args[1].Kind() != celast.ListKind {
+			return nil, false
+		}
+		var values []string
+		for _, element := range args[1].AsList().Elements() {
+			value, ok := stringLiteral(element)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		return []Term{{{Key: key, Operator: OpIn, Values: values}}}, true
+	case "startsWith", "endsWith":
+		if !call.IsMemberFunction() || len(args) != 1 {
+			return nil, false
+		}
+		key, ok := labelIndex(call.Target())
+		if !ok {
+			return nil, false
+		}
+		value, ok := stringLiteral(args[0])
+		if !ok {
+			return nil, false
+		}
+		op := OpStartsWith
+		if call.FunctionName() == "endsWith" {
+			op = OpEndsWith
+		}
+		return []Term{{{Key: key, Operator: op, Values: []string{value}}}}, true
+	}
+
+	return nil, false
+}
+
+// celNegationToTerms handles !("key" in labels) and !(labels["key"] == "value").
+func celNegationToTerms(e celast.Expr) ([]Term, bool) {
+	if e.Kind() != celast.CallKind {
+		return nil, false
+	}
+	call := e.AsCall()
+	args := call.Args()
+	switch call.FunctionName() {
+	case operators.In:
+		if key, ok := stringLiteral(args[0]); ok && isLabelsIdent(args[1]) {
+			return []Term{{{Key: key, Operator: OpDoesNotExist}}}, true
+		}
+	case operators.Equals:
+		if key, value, ok := labelComparison(args[0], args[1]); ok {
+			return []Term{{
+				{Key: key, Operator: OpExists},
+				{Key: key, Operator: OpNotIn, Values: []string{value}},
+			}}, true
+		}
+	}
+	return nil, false
+}
+
+// labelComparison matches labels["key"] compared with a string literal, in either order.
+func labelComparison(a, b celast.Expr) (string, string, bool) {
+	if key, ok := labelIndex(a); ok {
+		value, ok := stringLiteral(b)
+		return key, value, ok
+	}
+	if key, ok := labelIndex(b); ok {
+		value, ok := stringLiteral(a)
+		return key, value, ok
+	}
+	return "", "", false
+}
+
+// labelIndex matches labels["key"].
+func labelIndex(e celast.Expr) (string, bool) {
+	if e.Kind() != celast.CallKind {
+		return "", false
+	}
+	call := e.AsCall()
+	if call.FunctionName() != operators.Index || !isLabelsIdent(call.Args()[0]) {
+		return "", false
+	}
+	return stringLiteral(call.Args()[1])
+}
+
+func isLabelsIdent(e celast.Expr) bool {
+	return e.Kind() == celast.IdentKind && e.AsIdent() == celVarLabels
+}
+
+func stringLiteral(e celast.Expr) (string, bool) {
+	if e.Kind() != celast.LiteralKind {
+		return "", false
+	}
+	s, ok := e.AsLiteral().(types.String)
+	return string(s), ok
+}
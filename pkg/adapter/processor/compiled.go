@@ -0,0 +1,478 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+// Operator is a label requirement operator. In, NotIn, Exists and DoesNotExist
+// mirror Kubernetes set-based selectors; StartsWith and EndsWith can only be
+// expressed natively by engines with string selectors such as Calico.
+type Operator string
+
+const (
+	OpIn           Operator = "In"
+	OpNotIn        Operator = "NotIn"
+	OpExists       Operator = "Exists"
+	OpDoesNotExist Operator = "DoesNotExist"
+	OpStartsWith   Operator = "StartsWith"
+	OpEndsWith     Operator = "EndsWith"
+)
+
+// ErrNotExpressible is returned when a selector uses an operator the target
+// engine cannot express. Callers fall back to CompiledSelector.Resolve.
+var ErrNotExpressible = errors.New("selector cannot be expressed as a Kubernetes label selector")
+
+// noMatchError is returned when a selector is valid but nothing in the
+// cluster matches it.
+type noMatchError struct {
+	error
+}
+
+func noMatchf(format string, args ...interface{}) error {
+	return noMatchError{fmt.Errorf(format, args...)}
+}
+
+// IsNoMatch reports whether err only says that the cluster holds nothing a
+// selector matches: no namespace or pod, or no workload of the given name.
+func IsNoMatch(err error) bool {
+	var noMatch noMatchError
+	return errors.As(err, &noMatch) || apierrors.IsNotFound(err)
+}
+
+// Requirement is a single label predicate.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Term is a conjunction of requirements. An empty term selects every pod.
+type Term []Requirement
+
+// CompiledSelector is the engine-neutral form of a KAP Selector: a disjunction
+// of terms. A selector without terms selects nothing.
+type CompiledSelector struct {
+	Terms []Term
+
+	// Resolved is set when the terms were derived from the labels of live pods
+	// instead of from the selector itself.
+	Resolved bool
+
+	empty bool
+}
+
+// CompileSelector turns the Match entries and CEL expressions of a KAP Selector
+// into a CompiledSelector.
+//
+// Match entries with condition "all" constrain every pod; every other entry is
+// an alternative. Named workloads are resolved as described in ResolveMatch.
+// CEL expressions are ANDed with the Match result. Expressions that only test
+// labels keep their set-based meaning; anything else is evaluated against the
+// pods in namespace and replaced by their labels.
+func CompileSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (*CompiledSelector, error) {
+	matchTerms, err := compileMatches(ctx, k8sClient, namespace, selector.Match)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &CompiledSelector{Terms: matchTerms, empty: len(selector.Match) == 0 && len(selector.CEL) == 0 && selector.NamespaceSelector == nil}
+
+	if len(selector.CEL) == 0 {
+		return compiled, nil
+	}
+
+	celTerms, ok, err := compileCELLabels(selector.CEL)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		candidates, matched, err := evaluateCEL(ctx, k8sClient, namespace, selector.CEL)
+		if err != nil {
+			return nil, err
+		}
+		if len(matched) == 0 {
+			return nil, noMatchf("no pods in namespace %q match the CEL expressions %v", namespace, selector.CEL)
+		}
+		celTerms, err = podIdentityTerms(candidates, matched)
+		if err != nil {
+			return nil, errors.Wrapf(err, "CEL expressions %v", selector.CEL)
+		}
+		compiled.Resolved = true
+	}
+
+	compiled.Terms = andTerms(compiled.Terms, celTerms)
+	return compiled, nil
+}
+
+// ExtractMatchLabels compiles the selector and reduces it to a single
+// matchLabels map, for engines that support nothing else.
+func ExtractMatchLabels(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
+	compiled, err := CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.MatchLabels(ctx, k8sClient, namespace)
+}
+
+// IsEmpty reports whether the KAP Selector had no Match entries, CEL
+// expressions or namespaceSelector.
+func (s *CompiledSelector) IsEmpty() bool {
+	return s.empty
+}
+
+// Matches reports whether a pod with the given labels is selected.
+func (s *CompiledSelector) Matches(set labels.Set) bool {
+	for _, term := range s.Terms {
+		if term.matches(set) {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelSelectors returns one Kubernetes LabelSelector per term. Single-value In
+// requirements become matchLabels, everything else matchExpressions.
+func (s *CompiledSelector) LabelSelectors() ([]metav1.LabelSelector, error) {
+	selectors := make([]metav1.LabelSelector, 0, len(s.Terms))
+	for _, term := range s.Terms {
+		selector := metav1.LabelSelector{}
+		for _, req := range term {
+			var op metav1.LabelSelectorOperator
+			switch req.Operator {
+			case OpIn:
+				if len(req.Values) == 1 && !hasKey(selector.MatchLabels, req.Key) {
+					if selector.MatchLabels == nil {
+						selector.MatchLabels = map[string]string{}
+					}
+					selector.MatchLabels[req.Key] = req.Values[0]
+					continue
+				}
+				op = metav1.LabelSelectorOpIn
+			case OpNotIn:
+				op = metav1.LabelSelectorOpNotIn
+			case OpExists:
+				op = metav1.LabelSelectorOpExists
+			case OpDoesNotExist:
+				op = metav1.LabelSelectorOpDoesNotExist
+			default:
+				return nil, errors.Wrapf(ErrNotExpressible, "operator %s on %q", req.Operator, req.Key)
+			}
+			selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key:      req.Key,
+				Operator: op,
+				Values:   req.Values,
+			})
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+// CalicoSelector renders the selector in Calico selector syntax. Calico
+// strings cannot escape quotes, so a value holding both kinds of quote is an
+// error, as is a key that is not a valid label key.
+func (s *CompiledSelector) CalicoSelector() (string, error) {
+	if len(s.Terms) == 0 {
+		return "!all()", nil
+	}
+
+	terms := make([]string, 0, len(s.Terms))
+	for _, term := range s.Terms {
+		if len(term) == 0 {
+			return "all()", nil
+		}
+		reqs := make([]string, 0, len(term))
+		for _, req := range term {
+			rendered, err := req.calico()
+			if err != nil {
+				return "", err
+			}
+			reqs = append(reqs, rendered)
+		}
+		terms = append(terms, strings.Join(reqs, " && "))
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	for i := range terms {
+		terms[i] = "(" + terms[i] + ")"
+	}
+	return strings.Join(terms, " || "), nil
+}
+
+// Resolve evaluates the selector against the pods in namespace and returns an
+// equivalent selector built only from the labels of the matching pods.
+func (s *CompiledSelector) Resolve(ctx context.Context, k8sClient client.Client, namespace string) (*CompiledSelector, error) {
+	var podList corev1.PodList
+	if err := k8sClient.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	var matched []corev1.Pod
+	for _, pod := range podList.Items {
+		if s.Matches(pod.Labels) {
+			matched = append(matched, pod)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, noMatchf("no pods in namespace %q match the selector", namespace)
+	}
+
+	terms, err := podIdentityTerms(podList.Items, matched)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledSelector{Terms: terms, Resolved: true}, nil
+}
+
+// LabelSelectorsOrResolve is LabelSelectors, resolving the selector against
+// live pods first when it uses operators Kubernetes cannot express.
+func (s *CompiledSelector) LabelSelectorsOrResolve(ctx context.Context, k8sClient client.Client, namespace string) ([]metav1.LabelSelector, error) {
+	selectors, err := s.LabelSelectors()
+	if !errors.Is(err, ErrNotExpressible) {
+		return selectors, err
+	}
+
+	resolved, err := s.Resolve(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.LabelSelectors()
+}
+
+// MatchLabels reduces the selector to a single matchLabels map. Selectors that
+// are a single term of equality requirements convert directly; anything else
+// is resolved to the labels shared by the matching pods, provided those labels
+// select no other pod.
+func (s *CompiledSelector) MatchLabels(ctx context.Context, k8sClient client.Client, namespace string) (map[string]string, error) {
+	if len(s.Terms) == 1 {
+		if matchLabels, ok := s.Terms[0].equalities(); ok {
+			return matchLabels, nil
+		}
+	}
+
+	var podList corev1.PodList
+	if err := k8sClient.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	var matched []corev1.Pod
+	for _, pod := range podList.Items {
+		if s.Matches(pod.Labels) {
+			matched = append(matched, pod)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, noMatchf("no pods in namespace %q match the selector", namespace)
+	}
+
+	matchLabels := CommonLabels(matched)
+	if exact, extra := selectsExactly(labels.SelectorFromSet(matchLabels), podList.Items, matched); !exact {
+		return nil, fmt.Errorf("selector matches pods %v, but their common labels %v also select %v",
+			podNames(matched), matchLabels, podNames(extra))
+	}
+	return matchLabels, nil
+}
+
+func (t Term) matches(set labels.Set) bool {
+	for _, req := range t {
+		if !req.matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalities returns the term as matchLabels if it only holds single-value In requirements.
+func (t Term) equalities() (map[string]string, bool) {
+	matchLabels := map[string]string{}
+	for _, req := range t {
+		if req.Operator != OpIn || len(req.Values) != 1 {
+			return nil, false
+		}
+		if value, exists := matchLabels[req.Key]; exists && value != req.Values[0] {
+			return nil, false
+		}
+		matchLabels[req.Key] = req.Values[0]
+	}
+	return matchLabels, true
+}
+
+func (r Requirement) matches(set labels.Set) bool {
+	value, exists := set[r.Key]
+	switch r.Operator {
+	case OpIn:
+		return exists && contains(r.Values, value)
+	case OpNotIn:
+		return !exists || !contains(r.Values, value)
+	case OpExists:
+		return exists
+	case OpDoesNotExist:
+		return !exists
+	case OpStartsWith:
+		return exists && strings.HasPrefix(value, r.Values[0])
+	case OpEndsWith:
+		return exists && strings.HasSuffix(value, r.Values[0])
+	}
+	return false
+}
+
+func (r Requirement) calico() (string, error) {
+	if errs := validation.IsQualifiedName(r.Key); len(errs) > 0 {
+		return "", fmt.Errorf("label key %q cannot be used in a Calico selector: %s", r.Key, strings.Join(errs, "; "))
+	}
+	values := make([]string, 0, len(r.Values))
+	for _, value := range r.Values {
+		quoted, err := calicoQuote(value)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, quoted)
+	}
+
+	switch r.Operator {
+	case OpIn:
+		if len(values) == 1 {
+			return fmt.Sprintf("%s == %s", r.Key, values[0]), nil
+		}
+		return fmt.Sprintf("%s in {%s}", r.Key, strings.Join(values, ", ")), nil
+	case OpNotIn:
+		if len(values) == 1 {
+			return fmt.Sprintf("%s != %s", r.Key, values[0]), nil
+		}
+		return fmt.Sprintf("%s not in {%s}", r.Key, strings.Join(values, ", ")), nil
+	case OpExists:
+		return fmt.Sprintf("has(%s)", r.Key), nil
+	case OpDoesNotExist:
+		return fmt.Sprintf("!has(%s)", r.Key), nil
+	case OpStartsWith:
+		return fmt.Sprintf("%s starts with %s", r.Key, values[0]), nil
+	case OpEndsWith:
+		return fmt.Sprintf("%s ends with %s", r.Key, values[0]), nil
+	}
+	return "", fmt.Errorf("operator %s on %q cannot be used in a Calico selector", r.Operator, r.Key)
+}
+
+// calicoQuote quotes a value for a Calico selector, with double quotes when
+// it holds a single quote.
+func calicoQuote(value string) (string, error) {
+	switch {
+	case !strings.Contains(value, "'"):
+		return "'" + value + "'", nil
+	case !strings.Contains(value, `"`):
+		return `"` + value + `"`, nil
+	default:
+		return "", fmt.Errorf("value %q holds both kinds of quote and cannot be used in a Calico selector", value)
+	}
+}
+
+// compileMatches converts Match entries into terms. Entries with condition
+// "all" are added to every alternative.
+func compileMatches(ctx context.Context, k8sClient client.Client, namespace string, matches []v1.Match) ([]Term, error) {
+	common := []Term{{}}
+	var alternatives []Term
+	for _, match := range matches {
+		terms, err := ResolveMatch(ctx, k8sClient, namespace, match)
+		if err != nil {
+			return nil, err
+		}
+		if match.Condition == "all" {
+			common = andTerms(common, terms)
+		} else {
+			alternatives = append(alternatives, terms...)
+		}
+	}
+
+	if len(alternatives) == 0 {
+		return common, nil
+	}
+	return andTerms(common, alternatives), nil
+}
+
+// equalityTerm converts matchLabels into In requirements, ordered by key.
+func equalityTerm(matchLabels map[string]string) Term {
+	keys := make([]string, 0, len(matchLabels))
+	for key := range matchLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	term := make(Term, 0, len(keys))
+	for _, key := range keys {
+		term = append(term, Requirement{Key: key, Operator: OpIn, Values: []string{matchLabels[key]}})
+	}
+	return term
+}
+
+// andTerms returns the disjunctive normal form of (a1 || a2 ...) && (b1 || b2 ...).
+func andTerms(a, b []Term) []Term {
+	terms := make([]Term, 0, len(a)*len(b))
+	for _, left := range a {
+		for _, right := range b {
+			term := make(Term, 0, len(left)+len(right))
+			term = append(term, left...)
+			term = append(term, right...)
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// podIdentityTerms returns one equality term per distinct label set among the
+// matched pods, and fails if those terms would also select other candidates.
+func podIdentityTerms(candidates, matched []corev1.Pod) ([]Term, error) {
+	seen := map[string]bool{}
+	identity := &CompiledSelector{}
+	for _, pod := range matched {
+		podLabels := WorkloadLabels(pod.Labels)
+		fingerprint := labels.Set(podLabels).String()
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		identity.Terms = append(identity.Terms, equalityTerm(podLabels))
+	}
+
+	want := map[string]bool{}
+	for _, pod := range matched {
+		want[pod.Namespace+"/"+pod.Name] = true
+	}
+	var extra []corev1.Pod
+	for _, pod := range candidates {
+		if identity.Matches(pod.Labels) && !want[pod.Namespace+"/"+pod.Name] {
+			extra = append(extra, pod)
+		}
+	}
+	if len(extra) > 0 {
+		return nil, fmt.Errorf("pods %v cannot be told apart by labels from %v", podNames(matched), podNames(extra))
+	}
+
+	return identity.Terms, nil
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func contains(slice []string, str string) bool {
+	for _, v := range slice {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newPod(name string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: podLabels}}
+}
+
+func TestCompileSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector v1.Selector
+		want     string
+		empty    bool
+	}{
+		{
+			name:  "empty selector",
+			want:  "all()",
+			empty: true,
+		},
+		{
+			name:     "single matchLabels entry",
+			selector: v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "web", "tier": "frontend"}}}},
+			want:     "app == 'web' && tier == 'frontend'",
+		},
+		{
+			name: "alternatives are ORed",
+			selector: v1.Selector{Match: []v1.Match{
+				{MatchLabels: map[string]string{"app": "web"}},
+				{MatchLabels: map[string]string{"app": "api"}},
+			}},
+			want: "(app == 'web') || (app == 'api')",
+		},
+		{
+			name: "condition all is added to every alternative",
+			selector: v1.Selector{Match: []v1.Match{
+				{Condition: "all", MatchLabels: map[string]string{"env": "prod"}},
+				{MatchLabels: map[string]string{"app": "web"}},
+				{MatchLabels: map[string]string{"app": "api"}},
+			}},
+			want: "(env == 'prod' && app == 'web') || (env == 'prod' && app == 'api')",
+		},
+		{
+			name: "matchExpressions",
+			selector: v1.Selector{Match: []v1.Match{{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+				{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}}}},
+			want: "app in {'web', 'api'} && !has(canary)",
+		},
+		{
+			name:     "label-only CEL",
+			selector: v1.Selector{CEL: []string{`labels["app"] == "web" || labels["tier"].startsWith("front")`}},
+			want:     "(app == 'web') || (tier starts with 'front')",
+		},
+		{
+			name:     "CEL inequality requires the label",
+			selector: v1.Selector{CEL: []string{`labels["app"] != "web"`}},
+			want:     "has(app) && app != 'web'",
+		},
+		{
+			name:     "CEL is ANDed with match entries",
+			selector: v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"env": "prod"}}}, CEL: []string{`"app" in labels`}},
+			want:     "env == 'prod' && has(app)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := CompileSelector(context.Background(), newFakeClient(t), "default", tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compiled.IsEmpty() != tt.empty {
+				t.Errorf("IsEmpty() = %v, want %v", compiled.IsEmpty(), tt.empty)
+			}
+			if compiled.Resolved {
+				t.Errorf("selector was resolved against pods")
+			}
+			got, err := compiled.CalicoSelector()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("CalicoSelector() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileSelectorResolvesCEL(t *testing.T) {
+	web := newPod("web-7d4b9c-abcde", map[string]string{"app": "web", "pod-template-hash": "7d4b9c"})
+	web.Spec.HostNetwork = true
+	api := newPod("api-0", map[string]string{"app": "api", "controller-revision-hash": "api-5f6d", "statefulset.kubernetes.io/pod-name": "api-0"})
+	k8sClient := newFakeClient(t, web, api)
+
+	compiled, err := CompileSelector(context.Background(), k8sClient, "default", v1.Selector{CEL: []string{"object.spec.hostNetwork"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compiled.Resolved {
+		t.Errorf("selector with spec fields was not resolved against pods")
+	}
+	want := []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}}
+	if !reflect.DeepEqual(compiled.Terms, want) {
+		t.Errorf("Terms = %v, want %v", compiled.Terms, want)
+	}
+
+	if _, err := CompileSelector(context.Background(), k8sClient, "default", v1.Selector{CEL: []string{`object.spec.dnsPolicy == "None"`}}); err == nil {
+		t.Errorf("CEL matching no pods did not fail")
+	}
+}
+
+func TestPodIdentityTerms(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*corev1.Pod
+		matched    []int
+		want       []Term
+		wantErr    bool
+	}{
+		{
+			name: "controller-managed labels are dropped",
+			candidates: []*corev1.Pod{
+				newPod("web-0", map[string]string{"app": "web", "controller-revision-hash": "web-5f6d", "statefulset.kubernetes.io/pod-name": "web-0", "apps.kubernetes.io/pod-index": "0"}),
+				newPod("web-1", map[string]string{"app": "web", "controller-revision-hash": "web-5f6d", "statefulset.kubernetes.io/pod-name": "web-1", "apps.kubernetes.io/pod-index": "1"}),
+				newPod("db-0", map[string]string{"app": "db"}),
+			},
+			matched: []int{0, 1},
+			want:    []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}},
+		},
+		{
+			name: "job labels are dropped",
+			candidates: []*corev1.Pod{
+				newPod("migrate-x7k2p", map[string]string{"app": "migrate", "batch.kubernetes.io/controller-uid": "1234", "controller-uid": "1234", "batch.kubernetes.io/job-name": "migrate", "job-name": "migrate"}),
+			},
+			matched: []int{0},
+			want:    []Term{{{Key: "app", Operator: OpIn, Values: []string{"migrate"}}}},
+		},
+		{
+			name: "one term per distinct label set",
+			candidates: []*corev1.Pod{
+				newPod("web", map[string]string{"app": "web", "pod-template-generation": "3"}),
+				newPod("api", map[string]string{"app": "api", "pod-template-hash": "abc"}),
+			},
+			matched: []int{0, 1},
+			want: []Term{
+				{{Key: "app", Operator: OpIn, Values: []string{"web"}}},
+				{{Key: "app", Operator: OpIn, Values: []string{"api"}}},
+			},
+		},
+		{
+			name: "pods told apart only by controller labels",
+			candidates: []*corev1.Pod{
+				newPod("web-0", map[string]string{"app": "web", "statefulset.kubernetes.io/pod-name": "web-0"}),
+				newPod("web-1", map[string]string{"app": "web", "statefulset.kubernetes.io/pod-name": "web-1"}),
+			},
+			matched: []int{0},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var candidates, matched []corev1.Pod
+			for _, pod := range tt.candidates {
+				candidates = append(candidates, *pod)
+			}
+			for _, i := range tt.matched {
+				matched = append(matched, *tt.candidates[i])
+			}
+			got, err := podIdentityTerms(candidates, matched)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("terms = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonLabels(t *testing.T) {
+	pods := []corev1.Pod{
+		*newPod("web-a", map[string]string{"app": "web", "tier": "frontend", "pod-template-hash": "a"}),
+		*newPod("web-b", map[string]string{"app": "web", "tier": "frontend", "pod-template-hash": "a", "canary": "true"}),
+	}
+	want := map[string]string{"app": "web", "tier": "frontend"}
+	if got := CommonLabels(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("CommonLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestCalicoSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		terms   []Term
+		want    string
+		wantErr bool
+	}{
+		{name: "no terms", want: "!all()"},
+		{name: "empty term", terms: []Term{{}}, want: "all()"},
+		{
+			name: "operators",
+			terms: []Term{{
+				{Key: "app", Operator: OpNotIn, Values: []string{"web"}},
+				{Key: "tier", Operator: OpNotIn, Values: []string{"a", "b"}},
+				{Key: "zone", Operator: OpExists},
+				{Key: "version", Operator: OpEndsWith, Values: []string{"-rc"}},
+			}},
+			want: "app != 'web' && tier not in {'a', 'b'} && has(zone) && version ends with '-rc'",
+		},
+		{
+			name:  "single quote uses double quotes",
+			terms: []Term{{{Key: "owner", Operator: OpStartsWith, Values: []string{"o'neil"}}}},
+			want:  `owner starts with "o'neil"`,
+		},
+		{
+			name:    "both quotes are rejected",
+			terms:   []Term{{{Key: "owner", Operator: OpStartsWith, Values: []string{`o'neil "x"`}}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key is rejected",
+			terms:   []Term{{{Key: "app') || all() || ('", Operator: OpExists}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&CompiledSelector{Terms: tt.terms}).CalicoSelector()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CalicoSelector() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelSelectors(t *testing.T) {
+	compiled := &CompiledSelector{Terms: []Term{
+		{
+			{Key: "app", Operator: OpIn, Values: []string{"web"}},
+			{Key: "app", Operator: OpIn, Values: []string{"api"}},
+			{Key: "zone", Operator: OpExists},
+		},
+	}}
+	got, err := compiled.LabelSelectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []metav1.LabelSelector{{
+		MatchLabels: map[string]string{"app": "web"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"api"}},
+			{Key: "zone", Operator: metav1.LabelSelectorOpExists},
+		},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LabelSelectors() = %v, want %v", got, want)
+	}
+
+	prefix := &CompiledSelector{Terms: []Term{{{Key: "app", Operator: OpStartsWith, Values: []string{"web"}}}}}
+	if _, err := prefix.LabelSelectors(); err == nil {
+		t.Errorf("StartsWith was converted to a Kubernetes label selector")
+	}
+}
+
+func TestMatchLabels(t *testing.T) {
+	k8sClient := newFakeClient(t,
+		newPod("web", map[string]string{"app": "web", "tier": "frontend", "pod-template-hash": "a"}),
+		newPod("web-canary", map[string]string{"app": "web", "tier": "frontend", "track": "canary"}),
+		newPod("api", map[string]string{"app": "api", "tier": "backend"}),
+	)
+	tests := []struct {
+		name    string
+		terms   []Term
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "equalities convert directly",
+			terms: []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}},
+			want:  map[string]string{"app": "web"},
+		},
+		{
+			name:  "prefix resolves to common labels",
+			terms: []Term{{{Key: "app", Operator: OpStartsWith, Values: []string{"we"}}}},
+			want:  map[string]string{"app": "web", "tier": "frontend"},
+		},
+		{
+			name:    "common labels that select more pods",
+			terms:   []Term{{{Key: "track", Operator: OpDoesNotExist}, {Key: "tier", Operator: OpIn, Values: []string{"frontend"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&CompiledSelector{Terms: tt.terms}).MatchLabels(context.Background(), k8sClient, "default")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithNamespaceLabels(t *testing.T) {
+	compiled := &CompiledSelector{Terms: []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}}}
+	scoped := compiled.WithNamespaceLabels(&metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}, CalicoNamespaceLabelPrefix)
+	got, err := scoped.CalicoSelector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "app == 'web' && pcns.team == 'a'"; got != want {
+		t.Errorf("CalicoSelector() = %q, want %q", got, want)
+	}
+	if compiled.WithNamespaceLabels(nil, CalicoNamespaceLabelPrefix) != compiled {
+		t.Errorf("nil namespaceSelector changed the selector")
+	}
+}
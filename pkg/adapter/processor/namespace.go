@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+// Prefixes under which engines expose the labels of a pod's namespace on the
+// pod itself, so that namespaced policies can select on them.
+const (
+	CiliumNamespaceLabelPrefix = "io.cilium.k8s.namespace.labels."
+	CalicoNamespaceLabelPrefix = "pcns."
+)
+
+// MatchSelector converts the matchLabels and matchExpressions of a Match entry
+// into a labels.Selector, validating the expressions.
+func MatchSelector(match v1.Match) (labels.Selector, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      match.MatchLabels,
+		MatchExpressions: match.MatchExpressions,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid matchExpressions")
+	}
+	return selector, nil
+}
+
+// SelectNamespaces returns the namespaces a Selector applies to: those matching
+// its NamespaceSelector, or namespace alone when it has none.
+func SelectNamespaces(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) ([]string, error) {
+	if selector.NamespaceSelector == nil {
+		return []string{namespace}, nil
+	}
+
+	namespaceSelector, err := metav1.LabelSelectorAsSelector(selector.NamespaceSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid namespaceSelector")
+	}
+
+	var namespaceList corev1.NamespaceList
+	if err := k8sClient.List(ctx, &namespaceList, client.MatchingLabelsSelector{Selector: namespaceSelector}); err != nil {
+		return nil, fmt.Errorf("error listing namespaces: %v", err)
+	}
+	if len(namespaceList.Items) == 0 {
+		return nil, noMatchf("no namespaces match the namespaceSelector %s", namespaceSelector)
+	}
+
+	namespaces := make([]string, 0, len(namespaceList.Items))
+	for _, ns := range namespaceList.Items {
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+// NamespaceRequirements converts a namespaceSelector into pod label
+// requirements on the namespace labels an engine exposes under prefix.
+func NamespaceRequirements(namespaceSelector *metav1.LabelSelector, prefix string) []metav1.LabelSelectorRequirement {
+	if namespaceSelector == nil {
+		return nil
+	}
+
+	var requirements []metav1.LabelSelectorRequirement
+	for _, req := range labelSelectorTerm(namespaceSelector) {
+		requirements = append(requirements, metav1.LabelSelectorRequirement{
+			Key:      prefix + req.Key,
+			Operator: metav1.LabelSelectorOperator(req.Operator),
+			Values:   req.Values,
+		})
+	}
+	return requirements
+}
+
+// WithNamespaceLabels returns a copy of the selector that also requires the
+// pod's namespace to match namespaceSelector, for engines that expose
+// namespace labels on pods under prefix.
+func (s *CompiledSelector) WithNamespaceLabels(namespaceSelector *metav1.LabelSelector, prefix string) *CompiledSelector {
+	if namespaceSelector == nil {
+		return s
+	}
+
+	var namespaceTerm Term
+	for _, req := range labelSelectorTerm(namespaceSelector) {
+		req.Key = prefix + req.Key
+		namespaceTerm = append(namespaceTerm, req)
+	}
+
+	compiled := *s
+	compiled.Terms = andTerms(s.Terms, []Term{namespaceTerm})
+	return &compiled
+}
@@ -0,0 +1,200 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PortSpec is one entry of the port field of a network rule: a single port, a
+// range of ports, or a named container port.
+type PortSpec struct {
+	// Port is the port, or the first port of a range. It is 0 for named ports.
+	Port int32
+
+	// EndPort is the last port of a range, and 0 otherwise.
+	EndPort int32
+
+	// Name is the name of a container port.
+	Name string
+}
+
+// ParsePorts parses the port field of a network rule. It accepts port numbers
+// ("80"), ranges ("8000-8080"), container port names ("http") and
+// comma-separated lists of these. An empty field yields no ports.
+func ParsePorts(port string) ([]PortSpec, error) {
+	var specs []PortSpec
+	for _, entry := range strings.Split(port, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if start, end, isRange := strings.Cut(entry, "-"); isRange && isNumber(start) {
+			first, err := parsePortNumber(start)
+			if err != nil {
+				return nil, err
+			}
+			last, err := parsePortNumber(end)
+			if err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, errors.Errorf("invalid port range %q: end port is lower than start port", entry)
+			}
+			if last == first {
+				specs = append(specs, PortSpec{Port: first})
+				continue
+			}
+			specs = append(specs, PortSpec{Port: first, EndPort: last})
+			continue
+		}
+
+		if isNumber(entry) {
+			number, err := parsePortNumber(entry)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, PortSpec{Port: number})
+			continue
+		}
+
+		if errs := validation.IsValidPortName(entry); len(errs) > 0 {
+			return nil, errors.Errorf("invalid port name %q: %s", entry, strings.Join(errs, ", "))
+		}
+		specs = append(specs, PortSpec{Name: entry})
+	}
+	return specs, nil
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePortNumber(s string) (int32, error) {
+	number, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || number < 1 || number > 65535 {
+		return 0, errors.Errorf("invalid port %q: must be between 1 and 65535", s)
+	}
+	return int32(number), nil
+}
+
+// IsRange reports whether the spec covers more than one port.
+func (p PortSpec) IsRange() bool {
+	return p.EndPort != 0
+}
+
+// Contains reports whether a port number falls within the spec. Named specs
+// contain no numbers.
+func (p PortSpec) Contains(port int32) bool {
+	if p.Name != "" {
+		return false
+	}
+	if p.IsRange() {
+		return port >= p.Port && port <= p.EndPort
+	}
+	return port == p.Port
+}
+
+func (p PortSpec) String() string {
+	switch {
+	case p.Name != "":
+		return p.Name
+	case p.IsRange():
+		return fmt.Sprintf("%d-%d", p.Port, p.EndPort)
+	}
+	return strconv.Itoa(int(p.Port))
+}
+
+// ContainerPortsFor returns the container ports of the pods that a spec refers
+// to. A name refers to the container ports of that name, or, through a Service
+// that selects the pod and has a port of that name, to the port's targetPort.
+func ContainerPortsFor(ctx context.Context, k8sClient client.Client, pods []corev1.Pod, spec PortSpec, protocol string) ([]corev1.ContainerPort, error) {
+	services := map[string][]corev1.Service{}
+	if spec.Name != "" {
+		for _, pod := range pods {
+			if _, listed := services[pod.Namespace]; listed {
+				continue
+			}
+			var list corev1.ServiceList
+			if err := k8sClient.List(ctx, &list, client.InNamespace(pod.Namespace)); err != nil {
+				return nil, errors.Wrap(err, "error listing services")
+			}
+			services[pod.Namespace] = list.Items
+		}
+	}
+
+	var matched []corev1.ContainerPort
+	for _, pod := range pods {
+		serviceTargets := serviceTargetsFor(services[pod.Namespace], pod, spec.Name)
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				// Ports read from manifests rather than the API server have no
+				// defaulted protocol.
+				portProtocol := string(port.Protocol)
+				if portProtocol == "" {
+					portProtocol = string(corev1.ProtocolTCP)
+				}
+				if protocol != "" && !strings.EqualFold(portProtocol, protocol) {
+					continue
+				}
+				if spec.Contains(port.ContainerPort) || (spec.Name != "" && port.Name == spec.Name) || targetsPort(serviceTargets, port) {
+					matched = append(matched, port)
+				}
+			}
+		}
+	}
+	return matched, nil
+}
+
+// serviceTargetsFor returns the targetPorts of the Service ports named name,
+// among the Services that select the pod. Services without a selector route
+// to endpoints managed elsewhere and are left out.
+func serviceTargetsFor(services []corev1.Service, pod corev1.Pod, name string) []intstr.IntOrString {
+	var targets []intstr.IntOrString
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		for _, port := range service.Spec.Ports {
+			if port.Name != name {
+				continue
+			}
+			target := port.TargetPort
+			if target.Type == intstr.Int && target.IntVal == 0 {
+				// An unset targetPort defaults to the Service port.
+				target = intstr.FromInt32(port.Port)
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func targetsPort(targets []intstr.IntOrString, port corev1.ContainerPort) bool {
+	for _, target := range targets {
+		if target.Type == intstr.Int && target.IntVal == port.ContainerPort {
+			return true
+		}
+		if target.Type == intstr.String && target.StrVal != "" && target.StrVal == port.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		port    string
+		want    []PortSpec
+		wantErr bool
+	}{
+		{port: ""},
+		{port: "80", want: []PortSpec{{Port: 80}}},
+		{port: "8000-8080", want: []PortSpec{{Port: 8000, EndPort: 8080}}},
+		{port: "443-443", want: []PortSpec{{Port: 443}}},
+		{port: "http", want: []PortSpec{{Name: "http"}}},
+		{port: "80, 443,http-metrics", want: []PortSpec{{Port: 80}, {Port: 443}, {Name: "http-metrics"}}},
+		{port: "0", wantErr: true},
+		{port: "65536", wantErr: true},
+		{port: "8080-8000", wantErr: true},
+		{port: "80-http", wantErr: true},
+		{port: "Not_A_Name", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePorts(tt.port)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePorts(%q) error = %v, wantErr %v", tt.port, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePorts(%q) = %v, want %v", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestPortSpecContains(t *testing.T) {
+	tests := []struct {
+		spec PortSpec
+		port int32
+		want bool
+	}{
+		{spec: PortSpec{Port: 80}, port: 80, want: true},
+		{spec: PortSpec{Port: 80}, port: 81},
+		{spec: PortSpec{Port: 8000, EndPort: 8080}, port: 8080, want: true},
+		{spec: PortSpec{Port: 8000, EndPort: 8080}, port: 8081},
+		{spec: PortSpec{Name: "http"}, port: 80},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.Contains(tt.port); got != tt.want {
+			t.Errorf("%s.Contains(%d) = %v, want %v", tt.spec, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestContainerPortsFor(t *testing.T) {
+	pod := *newPod("web", map[string]string{"app": "web"})
+	pod.Spec.Containers = []corev1.Container{{
+		Name: "web",
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: 8080},
+			{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+			{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+		},
+	}}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "web"},
+			Ports: []corev1.ServicePort{
+				{Name: "web", Port: 80, TargetPort: intstr.FromString("http")},
+				{Name: "prom", Port: 9090},
+			},
+		},
+	}
+	// Services that do not select the pod must not resolve its port names.
+	other := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "api"},
+			Ports:    []corev1.ServicePort{{Name: "api", Port: 80, TargetPort: intstr.FromInt32(8080)}},
+		},
+	}
+	external := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "db", Port: 9090}}},
+	}
+	k8sClient := newFakeClient(t, service, other, external)
+
+	tests := []struct {
+		name     string
+		spec     PortSpec
+		protocol string
+		want     []int32
+	}{
+		{name: "number", spec: PortSpec{Port: 8080}, want: []int32{8080}},
+		{name: "range", spec: PortSpec{Port: 50, EndPort: 9000}, want: []int32{8080, 53}},
+		{name: "protocol", spec: PortSpec{Port: 50, EndPort: 9000}, protocol: "UDP", want: []int32{53}},
+		{name: "container port name", spec: PortSpec{Name: "metrics"}, want: []int32{9090}},
+		{name: "service port name with named target", spec: PortSpec{Name: "web"}, want: []int32{8080}},
+		{name: "service port name without target", spec: PortSpec{Name: "prom"}, want: []int32{9090}},
+		{name: "port name of a service selecting other pods", spec: PortSpec{Name: "api"}},
+		{name: "port name of a service without selector", spec: PortSpec{Name: "db"}},
+		{name: "no match", spec: PortSpec{Port: 443}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ports, err := ContainerPortsFor(context.Background(), k8sClient, []corev1.Pod{pod}, tt.spec, tt.protocol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int32
+			for _, port := range ports {
+				got = append(got, port.ContainerPort)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ContainerPortsFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -27,28 +27,28 @@ func ProcessMatchLabels(match []v1.Match) (map[string]string, error) {
 	return matchLabels, nil
 }
 
+// ProcessCEL evaluates the CEL expressions against the pods in namespace and
+// returns matchLabels that select exactly the matching pods. It fails when no
+// pod matches, or when every label set shared by the matching pods would also
+// select a pod the expressions reject.
 func ProcessCEL(ctx context.Context, k8sClient client.Client, namespace string, expressions []string) (map[string]string, error) {
 	logger := log.FromContext(ctx)
 
-	// Retrieve pod list
-	var podList corev1.PodList
-	if err := k8sClient.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
-		logger.Error(err, "Error listing pods in namespace", "Namespace", namespace)
-		return nil, fmt.Errorf("error listing pods: %v", err)
+	candidates, matched, err := evaluateCEL(ctx, k8sClient, namespace, expressions)
+	if err != nil {
+		logger.Error(err, "Error evaluating CEL expressions", "Namespace", namespace)
+		return nil, err
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no pods in namespace %q match the CEL expressions %v", namespace, expressions)
 	}
 
-	matchLabels := make(map[string]string)
-
-	// Parse and evaluate label expressions
-	for _, expr := range expressions {
-		isNegated := checkNegation(expr)
-		expr = preprocessExpression(expr)
-
-		labels := extractLabelsFromExpression(expr, podList, isNegated)
-		for k, v := range labels {
-			matchLabels[k] = v
-		}
+	matchLabels := CommonLabels(matched)
+	if exact, extra := selectsExactly(labels.SelectorFromSet(matchLabels), candidates, matched); !exact {
+		return nil, fmt.Errorf("CEL expressions %v match pods %v, but their common labels %v also select %v",
+			expressions, podNames(matched), matchLabels, podNames(extra))
 	}
+
 	return matchLabels, nil
 }
 
@@ -65,8 +65,7 @@ func ProcessMatch(selector v1.Selector) (kyvernov1.MatchResources, error) {
 		}
 	}
 
-	processedMatchLabels, _ := ProcessMatchLabels(selector.Match)
-
+	// Each entry keeps its own labels; Kyverno combines the filters with any/all.
 	for _, m := range selector.Match {
 		resourceFilter := kyvernov1.ResourceFilter{
 			ResourceDescription: kyvernov1.ResourceDescription{
@@ -74,10 +73,16 @@ func ProcessMatch(selector v1.Selector) (kyvernov1.MatchResources, error) {
 				Namespaces: []string{m.Namespace},
 				Name:       m.Name,
 				Selector: &metav1.LabelSelector{
-					MatchLabels: processedMatchLabels,
+					MatchLabels:      m.MatchLabels,
+					MatchExpressions: m.MatchExpressions,
 				},
+				NamespaceSelector: selector.NamespaceSelector,
 			},
 		}
+		// Without an explicit namespace the namespaceSelector decides.
+		if m.Namespace == "" && selector.NamespaceSelector != nil {
+			resourceFilter.Namespaces = nil
+		}
 
 		if m.Condition == "any" {
 			match.Any = append(match.Any, resourceFilter)
@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+// WorkloadKinds are the Match kinds that, given a name, resolve to the pods
+// of the named object rather than to matchLabels alone.
+var WorkloadKinds = []string{"Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "Service", "ServiceAccount", "Namespace"}
+
+// NodeKind is the Match kind that selects nodes instead of pods.
+const NodeKind = "Node"
+
+// SelectsNodes reports whether a selector selects nodes. Node entries cannot
+// be mixed with entries of other kinds, CEL expressions or a
+// namespaceSelector, which all select pods.
+func SelectsNodes(selector v1.Selector) (bool, error) {
+	nodes := 0
+	for _, match := range selector.Match {
+		if match.Kind == NodeKind {
+			nodes++
+		}
+	}
+	if nodes == 0 {
+		return false, nil
+	}
+	if nodes < len(selector.Match) || len(selector.CEL) > 0 || selector.NamespaceSelector != nil {
+		return false, fmt.Errorf("a selector with %s entries cannot also select pods", NodeKind)
+	}
+	return true, nil
+}
+
+// ResolveMatch compiles a single Match entry. A named workload resolves to its
+// pod template selector, a Service to its spec.selector, a ServiceAccount or
+// Namespace to the pods running under it, and a Node to its hostname label.
+// matchLabels and matchExpressions further narrow the result. namespace is
+// used when the entry does not set one.
+func ResolveMatch(ctx context.Context, k8sClient client.Client, namespace string, match v1.Match) ([]Term, error) {
+	labelsTerm := labelSelectorTerm(&metav1.LabelSelector{
+		MatchLabels:      match.MatchLabels,
+		MatchExpressions: match.MatchExpressions,
+	})
+	if match.Name == "" {
+		return []Term{labelsTerm}, nil
+	}
+	if match.Namespace != "" {
+		namespace = match.Namespace
+	}
+	key := types.NamespacedName{Name: match.Name, Namespace: namespace}
+
+	var terms []Term
+	switch match.Kind {
+	case "Pod":
+		var pod corev1.Pod
+		if err := k8sClient.Get(ctx, key, &pod); err != nil {
+			return nil, errors.Wrapf(err, "error fetching pod %s", key)
+		}
+		candidates, err := listPods(ctx, k8sClient, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if terms, err = podIdentityTerms(candidates, []corev1.Pod{pod}); err != nil {
+			return nil, err
+		}
+	case "Deployment":
+		var deployment appsv1.Deployment
+		if err := k8sClient.Get(ctx, key, &deployment); err != nil {
+			return nil, errors.Wrapf(err, "error fetching deployment %s", key)
+		}
+		terms = []Term{workloadTerm(deployment.Spec.Selector, deployment.Spec.Template.Labels)}
+	case "StatefulSet":
+		var statefulSet appsv1.StatefulSet
+		if err := k8sClient.Get(ctx, key, &statefulSet); err != nil {
+			return nil, errors.Wrapf(err, "error fetching statefulset %s", key)
+		}
+		terms = []Term{workloadTerm(statefulSet.Spec.Selector, statefulSet.Spec.Template.Labels)}
+	case "DaemonSet":
+		var daemonSet appsv1.DaemonSet
+		if err := k8sClient.Get(ctx, key, &daemonSet); err != nil {
+			return nil, errors.Wrapf(err, "error fetching daemonset %s", key)
+		}
+		terms = []Term{workloadTerm(daemonSet.Spec.Selector, daemonSet.Spec.Template.Labels)}
+	case "ReplicaSet":
+		var replicaSet appsv1.ReplicaSet
+		if err := k8sClient.Get(ctx, key, &replicaSet); err != nil {
+			return nil, errors.Wrapf(err, "error fetching replicaset %s", key)
+		}
+		terms = []Term{workloadTerm(replicaSet.Spec.Selector, replicaSet.Spec.Template.Labels)}
+	case "Job":
+		var job batchv1.Job
+		if err := k8sClient.Get(ctx, key, &job); err != nil {
+			return nil, errors.Wrapf(err, "error fetching job %s", key)
+		}
+		terms = []Term{workloadTerm(job.Spec.Selector, job.Spec.Template.Labels)}
+	case "Service":
+		var service corev1.Service
+		if err := k8sClient.Get(ctx, key, &service); err != nil {
+			return nil, errors.Wrapf(err, "error fetching service %s", key)
+		}
+		if len(service.Spec.Selector) == 0 {
+			return nil, fmt.Errorf("service %s has no selector", key)
+		}
+		terms = []Term{equalityTerm(service.Spec.Selector)}
+	case "ServiceAccount":
+		var serviceAccount corev1.ServiceAccount
+		if err := k8sClient.Get(ctx, key, &serviceAccount); err != nil {
+			return nil, errors.Wrapf(err, "error fetching serviceaccount %s", key)
+		}
+		candidates, err := listPods(ctx, k8sClient, namespace)
+		if err != nil {
+			return nil, err
+		}
+		var matched []corev1.Pod
+		for _, pod := range candidates {
+			if pod.Spec.ServiceAccountName == match.Name {
+				matched = append(matched, pod)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, noMatchf("no pods run under serviceaccount %s", key)
+		}
+		if terms, err = podIdentityTerms(candidates, matched); err != nil {
+			return nil, err
+		}
+	case NodeKind:
+		terms = []Term{equalityTerm(map[string]string{corev1.LabelHostname: match.Name})}
+	case "Namespace":
+		// Namespaced engine policies only select pods in their own namespace.
+		if match.Name != namespace {
+			return nil, fmt.Errorf("a policy in namespace %q cannot select the pods of namespace %q", namespace, match.Name)
+		}
+		terms = []Term{{}}
+	default:
+		terms = []Term{{}}
+	}
+
+	return andTerms(terms, []Term{labelsTerm}), nil
+}
+
+// workloadTerm converts a pod template selector into a term, falling back to
+// the template labels for objects created without one.
+func workloadTerm(selector *metav1.LabelSelector, templateLabels map[string]string) Term {
+	if selector == nil {
+		return equalityTerm(templateLabels)
+	}
+	return labelSelectorTerm(selector)
+}
+
+// labelSelectorTerm converts a Kubernetes LabelSelector into a term.
+func labelSelectorTerm(selector *metav1.LabelSelector) Term {
+	term := equalityTerm(selector.MatchLabels)
+	for _, expr := range selector.MatchExpressions {
+		term = append(term, Requirement{Key: expr.Key, Operator: Operator(expr.Operator), Values: expr.Values})
+	}
+	return term
+}
+
+func listPods(ctx context.Context, k8sClient client.Client, namespace string) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := k8sClient.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+	return podList.Items, nil
+}
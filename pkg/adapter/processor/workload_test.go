@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestResolveMatch(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web", "version": "v2"}}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "api"}},
+	}
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"}}
+	batchPod := newPod("batch-x7k2p", map[string]string{"app": "batch", "job-name": "batch"})
+	batchPod.Spec.ServiceAccountName = "batch"
+	webPod := newPod("web-7d4b9c-abcde", map[string]string{"app": "web", "pod-template-hash": "7d4b9c"})
+	k8sClient := newFakeClient(t, deployment, service, serviceAccount, batchPod, webPod)
+
+	tests := []struct {
+		name    string
+		match   v1.Match
+		want    []Term
+		wantErr bool
+	}{
+		{
+			name:  "labels only",
+			match: v1.Match{Kind: "Pod", MatchLabels: map[string]string{"app": "web"}},
+			want:  []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}},
+		},
+		{
+			name:  "deployment resolves to its selector",
+			match: v1.Match{Kind: "Deployment", Name: "web"},
+			want:  []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}},
+		},
+		{
+			name:  "labels narrow a workload",
+			match: v1.Match{Kind: "Deployment", Name: "web", MatchLabels: map[string]string{"version": "v2"}},
+			want: []Term{{
+				{Key: "app", Operator: OpIn, Values: []string{"web"}},
+				{Key: "version", Operator: OpIn, Values: []string{"v2"}},
+			}},
+		},
+		{
+			name:  "service resolves to its selector",
+			match: v1.Match{Kind: "Service", Name: "api"},
+			want:  []Term{{{Key: "app", Operator: OpIn, Values: []string{"api"}}}},
+		},
+		{
+			name:  "serviceaccount resolves to the labels of its pods",
+			match: v1.Match{Kind: "ServiceAccount", Name: "batch"},
+			want:  []Term{{{Key: "app", Operator: OpIn, Values: []string{"batch"}}}},
+		},
+		{
+			name:  "pod resolves to its workload labels",
+			match: v1.Match{Kind: "Pod", Name: "web-7d4b9c-abcde"},
+			want:  []Term{{{Key: "app", Operator: OpIn, Values: []string{"web"}}}},
+		},
+		{
+			name:  "own namespace",
+			match: v1.Match{Kind: "Namespace", Name: "default"},
+			want:  []Term{{}},
+		},
+		{
+			name:    "other namespace",
+			match:   v1.Match{Kind: "Namespace", Name: "kube-system"},
+			wantErr: true,
+		},
+		{
+			name:    "missing workload",
+			match:   v1.Match{Kind: "StatefulSet", Name: "db"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveMatch(context.Background(), k8sClient, "default", tt.match)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectsNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector v1.Selector
+		want     bool
+		wantErr  bool
+	}{
+		{name: "pods", selector: v1.Selector{Match: []v1.Match{{Kind: "Pod"}}}},
+		{name: "nodes", selector: v1.Selector{Match: []v1.Match{{Kind: NodeKind, Name: "worker-1"}}}, want: true},
+		{name: "nodes and pods", selector: v1.Selector{Match: []v1.Match{{Kind: NodeKind}, {Kind: "Pod"}}}, wantErr: true},
+		{name: "nodes and CEL", selector: v1.Selector{Match: []v1.Match{{Kind: NodeKind}}, CEL: []string{"true"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SelectsNodes(tt.selector)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: SelectsNodes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,7 +25,10 @@ import (
 type AdapterConfig struct {
 	Address        string              `json:"address"`
 	SupportedTypes map[string][]string `json:"supportedTypes"`
-	Status         string              `json:"status"`
+	// Scopes are the policy scopes the adapter renders. An adapter without
+	// scopes gets policies of every scope.
+	Scopes []string `json:"scopes,omitempty"`
+	Status string   `json:"status"`
 }
 
 // DispatchPolicyToAdapters sends the policy to the appropriate adapters based on the type and subtype.
@@ -33,26 +39,14 @@ func DispatchPolicyToAdapters(ctx context.Context, k8sClient client.Client, logg
 		return err
 	}
 
+	// Adapters are checked once per dispatch, even when several intents are routed to them.
+	adapterInfos := map[string]*pb.InfoResponse{}
+	compatibility := map[string]string{}
+
 	// Iterate over the intentRequests and dispatch them to the supported adapters.
 	for _, intentRequest := range kap.Spec.IntentRequest {
-		var adaptersToNotify []string
-		var subType string
-
-		switch intentRequest.Type {
-		case "system", "cluster":
-			// System type handling
-			subType = intentRequest.Rule.ActionPoint[0].SubType
-			adaptersToNotify = GetSupportedAdapters(adapterConfigs, logger, intentRequest.Type, subType)
-		case "network":
-			// Network type handling. Ensure From and To slices are not empty before accessing.
-			if len(intentRequest.Rule.From) > 0 {
-				subType = intentRequest.Rule.From[0].Kind
-				adaptersToNotify = GetSupportedAdapters(adapterConfigs, logger, intentRequest.Type, subType)
-			} else if len(intentRequest.Rule.To) > 0 {
-				subType = intentRequest.Rule.To[0].Kind
-				adaptersToNotify = GetSupportedAdapters(adapterConfigs, logger, intentRequest.Type, subType)
-			}
-		}
+		subType := IntentSubType(intentRequest)
+		adaptersToNotify := GetSupportedAdapters(adapterConfigs, logger, kap.Spec.Scope, intentRequest.Type, subType)
 		for _, adapterName := range adaptersToNotify {
 			adapterConfig, exists := adapterConfigs[adapterName]
 			if !exists || adapterConfig.Status == "offline" {
@@ -61,12 +55,35 @@ func DispatchPolicyToAdapters(ctx context.Context, k8sClient client.Client, logg
 				continue
 			}
 
+			info, checked := adapterInfos[adapterName]
+			if !checked {
+				var err error
+				info, err = GetAdapterInfo(ctx, adapterConfig.Address)
+				if err != nil {
+					logger.Error(err, "error fetching adapter info", "Adapter.Name", adapterName)
+					continue
+				}
+				adapterInfos[adapterName] = info
+				compatibility[adapterName] = ""
+				if err := checkAdapterCompatibility(info); err != nil {
+					compatibility[adapterName] = err.Error()
+				}
+			}
+			if err := checkAdapterCompatibility(info); err != nil {
+				logger.Info("Adapter is incompatible, policy not dispatched", "Adapter.Name", adapterName, "Reason", err.Error())
+				continue
+			}
+			if !supportsIntent(info, intentRequest.Type, subType) {
+				logger.Info("Adapter does not advertise this intent, policy not dispatched", "Adapter.Name", adapterName, "Type", intentRequest.Type, "SubType", subType)
+				continue
+			}
+
 			response, err := DispatchPolicy(ctx, adapterConfig.Address, kap)
 			if err != nil {
 				logger.Error(err, "error sending policy to adapter", "Adapter.Name", adapterName)
-			} else {
-				logger.Info("Policy dispatched to adapter", "Adapter.Name", adapterName)
+				continue
 			}
+			logger.Info("Policy dispatched to adapter", "Adapter.Name", adapterName)
 			if response.Success {
 				adapterPolicy := response.AdapterPolicyName
 				if err := statusmanager.NotifyReporter(ctx, kap, configMap, adapterPolicy); err != nil {
@@ -76,13 +93,88 @@ func DispatchPolicyToAdapters(ctx context.Context, k8sClient client.Client, logg
 		}
 	}
 
+	if len(compatibility) > 0 {
+		if err := statusmanager.SetAdapterCompatibility(ctx, k8sClient, kap.Name, kap.Namespace, compatibility); err != nil {
+			logger.Error(err, "failed to update adapter compatibility condition", "KubeAegis.Name", kap.Name)
+		}
+	}
+
 	return nil
 }
 
-// getSupportedAdapters returns a slice of adapter names that support the given type and subtype.
-func GetSupportedAdapters(adapterConfigs map[string]AdapterConfig, logger logr.Logger, intentType, subType string) []string {
+// GetAdapterInfo asks the adapter listening on address for its build and protocol versions.
+// Adapters built before GetInfo existed are reported with protocol version 0.
+func GetAdapterInfo(ctx context.Context, address string) (*pb.InfoResponse, error) {
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect gRPC")
+	}
+	defer conn.Close()
+
+	client := pb.NewPolicyServiceClient(conn)
+
+	info, err := client.GetInfo(ctx, &pb.InfoRequest{ProtocolVersion: pb.ProtocolVersion})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return &pb.InfoResponse{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to get adapter info")
+	}
+
+	return info, nil
+}
+
+// checkAdapterCompatibility returns an error describing why the controller must not
+// dispatch to the adapter, or nil if both sides speak a compatible protocol.
+func checkAdapterCompatibility(info *pb.InfoResponse) error {
+	if !pb.IsCompatible(info.GetProtocolVersion(), info.GetMinProtocolVersion()) {
+		return fmt.Errorf("adapter %q (build %q) speaks protocol v%d (min v%d), controller speaks v%d (min v%d)",
+			info.GetAdapterName(), info.GetBuildVersion(), info.GetProtocolVersion(), info.GetMinProtocolVersion(),
+			pb.ProtocolVersion, pb.MinProtocolVersion)
+	}
+	return nil
+}
+
+// supportsIntent reports whether the adapter advertises the given type and subtype.
+// Adapters that advertise nothing are trusted to match the adapter-config entry.
+func supportsIntent(info *pb.InfoResponse, intentType, subType string) bool {
+	if len(info.GetSupportedTypes()) == 0 {
+		return true
+	}
+	return slices.Contains(info.GetSupportedTypes()[intentType].GetSubTypes(), subType)
+}
+
+// IntentSubType returns the subtype that adapters are chosen by: the subType of
+// the first action point of system and cluster intents, or the kind of the
+// first from or to rule of network intents.
+func IntentSubType(intentRequest v1.IntentRequest) string {
+	switch intentRequest.Type {
+	case "system", "cluster":
+		if len(intentRequest.Rule.ActionPoint) > 0 {
+			return intentRequest.Rule.ActionPoint[0].SubType
+		}
+	case "network":
+		if len(intentRequest.Rule.From) > 0 {
+			return intentRequest.Rule.From[0].Kind
+		}
+		if len(intentRequest.Rule.To) > 0 {
+			return intentRequest.Rule.To[0].Kind
+		}
+	}
+	return ""
+}
+
+// GetSupportedAdapters returns a slice of adapter names that support the given scope, type and subtype.
+func GetSupportedAdapters(adapterConfigs map[string]AdapterConfig, logger logr.Logger, scope, intentType, subType string) []string {
+	if scope == "" {
+		scope = v1.ScopeNamespace
+	}
+
 	var supportedAdapters []string
 	for adapterName, config := range adapterConfigs {
+		if len(config.Scopes) > 0 && !slices.Contains(config.Scopes, scope) {
+			continue
+		}
 		supportedTypes, ok := config.SupportedTypes[intentType]
 		if !ok {
 			continue
@@ -115,6 +207,7 @@ func DispatchPolicy(ctx context.Context, address string, kap *v1.KubeAegisPolicy
 	req := &pb.PolicyRequest{
 		PolicyName:      kap.Name,
 		PolicyNamespace: kap.Namespace,
+		ProtocolVersion: pb.ProtocolVersion,
 	}
 
 	response, err := client.DispatchPolicy(ctx, req)
@@ -153,6 +246,24 @@ func retryDispatchPolicy(ctx context.Context, k8sClient client.Client, logger lo
 				continue
 			}
 
+			info, err := GetAdapterInfo(ctx, adapterConfig.Address)
+			if err != nil {
+				logger.Error(err, "failed to fetch adapter info on retry", "Adapter.Name", adapterName)
+				continue
+			}
+			// Only this adapter was checked, so the results of the others are kept.
+			reason := ""
+			if err := checkAdapterCompatibility(info); err != nil {
+				reason = err.Error()
+			}
+			if err := statusmanager.SetAdapterCompatibility(ctx, k8sClient, kap.Name, kap.Namespace, map[string]string{adapterName: reason}); err != nil {
+				logger.Error(err, "failed to update adapter compatibility condition", "KubeAegis.Name", kap.Name)
+			}
+			if reason != "" {
+				logger.Info("Adapter is incompatible, giving up on retry", "Adapter.Name", adapterName, "Reason", reason)
+				return
+			}
+
 			_, err = DispatchPolicy(ctx, adapterConfig.Address, kap)
 			if err != nil {
 				logger.Error(err, "failed to dispatch policy to adapter on retry", "Adapter.Name", adapterName)
@@ -187,6 +298,7 @@ func NotifyAdapterOfPolicyDeletion(ctx context.Context, namespacedName client.Ob
 		req := &pb.PolicyDeletionRequest{
 			PolicyName:      "ksp-" + namespacedName.Name,
 			PolicyNamespace: namespacedName.Namespace,
+			ProtocolVersion: pb.ProtocolVersion,
 		}
 
 		_, err = client.NotifyPolicyDeletion(ctx, req)
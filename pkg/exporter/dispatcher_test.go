@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestGetSupportedAdapters(t *testing.T) {
+	adapterConfigs := map[string]AdapterConfig{
+		"kubeaegis-kubearmor": {
+			SupportedTypes: map[string][]string{"system": {"process", "file", "syscalls"}},
+		},
+		"kubeaegis-cilium": {
+			SupportedTypes: map[string][]string{"network": {"endpoint", "cidr", "port"}},
+			Scopes:         []string{v1.ScopeNamespace},
+		},
+		"kubeaegis-calico": {
+			SupportedTypes: map[string][]string{"network": {"pod", "cidr", "port"}},
+			Scopes:         []string{v1.ScopeNamespace, v1.ScopeCluster},
+		},
+		"kubeaegis-anp": {
+			SupportedTypes: map[string][]string{"network": {"endpoint", "pod", "cidr", "port"}},
+			Scopes:         []string{v1.ScopeCluster, v1.ScopeBaseline},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		scope      string
+		intentType string
+		subType    string
+		want       []string
+	}{
+		{
+			name:       "namespaced network intent",
+			intentType: "network",
+			subType:    "cidr",
+			want:       []string{"kubeaegis-calico", "kubeaegis-cilium"},
+		},
+		{
+			name:       "explicit Namespace scope",
+			scope:      v1.ScopeNamespace,
+			intentType: "network",
+			subType:    "endpoint",
+			want:       []string{"kubeaegis-cilium"},
+		},
+		{
+			name:       "Cluster scope",
+			scope:      v1.ScopeCluster,
+			intentType: "network",
+			subType:    "port",
+			want:       []string{"kubeaegis-anp", "kubeaegis-calico"},
+		},
+		{
+			name:       "Baseline scope",
+			scope:      v1.ScopeBaseline,
+			intentType: "network",
+			subType:    "pod",
+			want:       []string{"kubeaegis-anp"},
+		},
+		{
+			name:       "adapters without scopes get every scope",
+			scope:      v1.ScopeCluster,
+			intentType: "system",
+			subType:    "file",
+			want:       []string{"kubeaegis-kubearmor"},
+		},
+		{
+			name:       "unsupported subtype",
+			intentType: "network",
+			subType:    "fqdns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetSupportedAdapters(adapterConfigs, logr.Discard(), tt.scope, tt.intentType, tt.subType)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
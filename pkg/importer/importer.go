@@ -0,0 +1,187 @@
+// Package importer holds what the adapters share to convert engine policies
+// back into KubeAegisPolicies. The engine-specific mappings live next to the
+// converters they invert.
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+// Mapped records the fields of an engine policy that an importer carried
+// over into a KubeAegisPolicy. A field is mapped together with everything
+// below it.
+type Mapped struct {
+	paths map[string]bool
+}
+
+func NewMapped() *Mapped {
+	return &Mapped{paths: map[string]bool{}}
+}
+
+// Add marks the fields at paths as mapped.
+func (m *Mapped) Add(paths ...*field.Path) {
+	for _, path := range paths {
+		m.paths[path.String()] = true
+	}
+}
+
+// Unmapped returns a FieldUnsupported warning for every field of the engine
+// policy that is set but was not mapped. Fields are reported at the highest
+// level that holds nothing mapped. The metadata and status are not looked at.
+func (m *Mapped) Unmapped(object runtime.Object) (validator.ResultList, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(content, key)
+	}
+
+	var results validator.ResultList
+	m.walk(nil, content, &results)
+	return results, nil
+}
+
+func (m *Mapped) walk(path *field.Path, value interface{}, results *validator.ResultList) {
+	if isEmpty(value) {
+		return
+	}
+	if path != nil {
+		if m.covers(path.String()) {
+			return
+		}
+		if !m.below(path.String()) {
+			*results = append(*results, validator.Warningf(path, validator.CodeFieldUnsupported,
+				"%s has no KubeAegisPolicy equivalent and was not imported", describe(value)))
+			return
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			m.walk(child(path, key), v[key], results)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			m.walk(path.Index(i), elem, results)
+		}
+	}
+}
+
+// covers reports whether path or one of its parents is mapped.
+func (m *Mapped) covers(path string) bool {
+	for mapped := range m.paths {
+		if path == mapped || strings.HasPrefix(path, mapped+".") || strings.HasPrefix(path, mapped+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// below reports whether a field under path is mapped.
+func (m *Mapped) below(path string) bool {
+	for mapped := range m.paths {
+		if strings.HasPrefix(mapped, path+".") || strings.HasPrefix(mapped, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// OnlySet reports whether the struct value points to sets no fields but the
+// allowed ones, named as in its JSON form. Importers use it to skip engine
+// rules they can only carry over in part, which would widen or narrow what the
+// rule allows or denies.
+func OnlySet(value interface{}, allowed ...string) bool {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(value)
+	if err != nil {
+		return false
+	}
+	for key, fieldValue := range content {
+		if !isEmpty(fieldValue) && !contains(allowed, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewPolicy returns an empty KubeAegisPolicy for an engine policy generated
+// from a KAP named after it, that is with prefix trimmed from the name.
+func NewPolicy(name, prefix, namespace string) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1.GroupVersion.String(),
+			Kind:       "KubeAegisPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      strings.TrimPrefix(name, prefix),
+			Namespace: namespace,
+		},
+	}
+}
+
+func child(path *field.Path, key string) *field.Path {
+	if path == nil {
+		return field.NewPath(key)
+	}
+	return path.Child(key)
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "field"
+	case []interface{}:
+		return "list"
+	}
+	return fmt.Sprintf("value %v", value)
+}
+
+// isEmpty treats missing, null, empty and zero-valued fields alike.
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+func contains(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
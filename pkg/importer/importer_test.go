@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/cclab-inu/KubeAegis/pkg/validator"
+)
+
+func TestUnmapped(t *testing.T) {
+	tcp := corev1.ProtocolTCP
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}}},
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp}},
+			}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	spec := field.NewPath("spec")
+	ingress := spec.Child("ingress").Index(0)
+
+	tests := []struct {
+		name   string
+		mapped []*field.Path
+		want   []string
+	}{
+		{
+			name: "nothing mapped",
+			want: []string{"spec"},
+		},
+		{
+			name:   "everything mapped",
+			mapped: []*field.Path{spec},
+		},
+		{
+			name:   "unmapped fields are reported at the highest level",
+			mapped: []*field.Path{spec.Child("podSelector"), ingress.Child("from")},
+			want:   []string{"spec.ingress[0].ports", "spec.policyTypes"},
+		},
+		{
+			name:   "unmapped leaf",
+			mapped: []*field.Path{spec.Child("podSelector"), ingress.Child("from"), ingress.Child("ports").Index(0).Child("port"), spec.Child("policyTypes")},
+			want:   []string{"spec.ingress[0].ports[0].protocol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMapped()
+			m.Add(tt.mapped...)
+			results, err := m.Unmapped(policy)
+			if err != nil {
+				t.Fatalf("Unmapped() error = %v", err)
+			}
+			var got []string
+			for _, result := range results {
+				if result.Severity != validator.SeverityWarning || result.Code != validator.CodeFieldUnsupported {
+					t.Errorf("Unmapped() result = %+v, want a %s warning", result, validator.CodeFieldUnsupported)
+				}
+				got = append(got, result.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmapped() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlySet(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		allowed []string
+		want    bool
+	}{
+		{
+			name:    "only allowed fields",
+			value:   &networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}},
+			allowed: []string{"podSelector"},
+			want:    true,
+		},
+		{
+			name:    "empty fields are ignored",
+			value:   &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{}},
+			allowed: []string{"cidr"},
+			want:    true,
+		},
+		{
+			name:    "field not allowed",
+			value:   &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}},
+			allowed: []string{"cidr"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OnlySet(tt.value, tt.allowed...); got != tt.want {
+				t.Errorf("OnlySet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	kap := NewPolicy("cnp-web", "cnp-", "default")
+	if kap.Name != "web" || kap.Namespace != "default" || kap.Kind != "KubeAegisPolicy" {
+		t.Errorf("NewPolicy() = %s/%s of kind %s, want default/web of kind KubeAegisPolicy", kap.Namespace, kap.Name, kap.Kind)
+	}
+}
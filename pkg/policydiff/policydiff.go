@@ -0,0 +1,239 @@
+// Package policydiff compares engine policies by the rules they enforce rather
+// than by their YAML. Metadata the API server maintains, status and the order
+// of list elements are ignored.
+package policydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ChangeType string
+
+const (
+	Added    ChangeType = "Added"
+	Removed  ChangeType = "Removed"
+	Modified ChangeType = "Modified"
+)
+
+// Change is one difference between a live and a rendered policy. Path is the
+// field of the rendered policy, or of the live policy for removed fields.
+type Change struct {
+	Path string
+	Type ChangeType
+	Old  interface{}
+	New  interface{}
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", c.Path, format(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", c.Path, format(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, format(c.Old), format(c.New))
+	}
+}
+
+// Objects compares the enforced parts of two policies of the same kind: every
+// top-level field but metadata and status, and the labels. A nil live policy
+// means it does not exist, and the whole rendered policy is reported as added.
+func Objects(live, rendered client.Object) ([]Change, error) {
+	renderedContent, err := enforced(rendered)
+	if err != nil {
+		return nil, err
+	}
+	liveContent := map[string]interface{}{}
+	if live != nil && !reflect.ValueOf(live).IsNil() {
+		if liveContent, err = enforced(live); err != nil {
+			return nil, err
+		}
+	}
+
+	// Top-level fields are compared one by one, so that a missing live policy
+	// is reported field by field rather than at an empty path.
+	var changes []Change
+	compareMaps("", liveContent, renderedContent, &changes)
+	return changes, nil
+}
+
+// Strings formats each change on its own line.
+func Strings(changes []Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
+
+func enforced(object client.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(content, key)
+	}
+	if labels := object.GetLabels(); len(labels) > 0 {
+		content["metadata"] = map[string]interface{}{"labels": toInterfaceMap(labels)}
+	}
+	return content, nil
+}
+
+func compare(path string, old, new interface{}, changes *[]Change) {
+	if isEmpty(old) && isEmpty(new) {
+		return
+	}
+	switch {
+	case isEmpty(old):
+		*changes = append(*changes, Change{Path: path, Type: Added, New: new})
+		return
+	case isEmpty(new):
+		*changes = append(*changes, Change{Path: path, Type: Removed, Old: old})
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		compareMaps(path, oldMap, newMap, changes)
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		compareLists(path, oldList, newList, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Type: Modified, Old: old, New: new})
+	}
+}
+
+func compareMaps(path string, old, new map[string]interface{}, changes *[]Change) {
+	keys := map[string]bool{}
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range new {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		compare(join(path, key), old[key], new[key], changes)
+	}
+}
+
+// compareLists matches equal elements regardless of their position. The
+// elements left over are compared pairwise in order, so that a rule whose port
+// changed is reported as one modified field rather than a removed and an added
+// rule.
+func compareLists(path string, old, new []interface{}, changes *[]Change) {
+	matched := make([]bool, len(old))
+	var unmatchedNew []int
+	for i, newElem := range new {
+		found := false
+		for j, oldElem := range old {
+			if !matched[j] && equal(oldElem, newElem) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			unmatchedNew = append(unmatchedNew, i)
+		}
+	}
+	var unmatchedOld []int
+	for j := range old {
+		if !matched[j] {
+			unmatchedOld = append(unmatchedOld, j)
+		}
+	}
+
+	for len(unmatchedOld) > 0 && len(unmatchedNew) > 0 {
+		i, j := unmatchedNew[0], unmatchedOld[0]
+		unmatchedNew, unmatchedOld = unmatchedNew[1:], unmatchedOld[1:]
+		_, oldIsMap := old[j].(map[string]interface{})
+		_, newIsMap := new[i].(map[string]interface{})
+		if oldIsMap && newIsMap {
+			compare(fmt.Sprintf("%s[%d]", path, i), old[j], new[i], changes)
+			continue
+		}
+		*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%d]", path, i), Type: Modified, Old: old[j], New: new[i]})
+	}
+	for _, j := range unmatchedOld {
+		*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%d]", path, j), Type: Removed, Old: old[j]})
+	}
+	for _, i := range unmatchedNew {
+		*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%d]", path, i), Type: Added, New: new[i]})
+	}
+}
+
+// equal reports whether two values differ in no way compare would report.
+func equal(old, new interface{}) bool {
+	var changes []Change
+	compare("", old, new, &changes)
+	return len(changes) == 0
+}
+
+// isEmpty treats missing, null, empty and zero-valued fields alike, since
+// typed objects drop them or keep them depending on where they come from.
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	return path + "." + key
+}
+
+func format(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package policydiff
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newNetworkPolicy(labels map[string]string, rules ...networkingv1.NetworkPolicyIngressRule) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: labels},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Ingress:     rules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+func ingressFrom(app string, ports ...int) networkingv1.NetworkPolicyIngressRule {
+	tcp := corev1.ProtocolTCP
+	rule := networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}}},
+	}
+	for _, port := range ports {
+		p := intstr.FromInt(port)
+		rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &p})
+	}
+	return rule
+}
+
+func TestObjects(t *testing.T) {
+	owned := map[string]string{"kubeaegis.cclab.com/policy": "web"}
+
+	tests := []struct {
+		name     string
+		live     client.Object
+		rendered client.Object
+		want     []string
+	}{
+		{
+			name:     "identical",
+			live:     newNetworkPolicy(owned, ingressFrom("api", 80)),
+			rendered: newNetworkPolicy(owned, ingressFrom("api", 80)),
+		},
+		{
+			name: "server-maintained metadata and status are ignored",
+			live: func() client.Object {
+				policy := newNetworkPolicy(owned, ingressFrom("api", 80))
+				policy.ResourceVersion = "42"
+				policy.UID = "1234"
+				policy.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+				return policy
+			}(),
+			rendered: newNetworkPolicy(owned, ingressFrom("api", 80)),
+		},
+		{
+			name:     "reordered lists",
+			live:     newNetworkPolicy(owned, ingressFrom("api", 80, 443), ingressFrom("admin", 22)),
+			rendered: newNetworkPolicy(owned, ingressFrom("admin", 22), ingressFrom("api", 443, 80)),
+		},
+		{
+			name:     "modified port inside a rule",
+			live:     newNetworkPolicy(owned, ingressFrom("api", 80)),
+			rendered: newNetworkPolicy(owned, ingressFrom("api", 8080)),
+			want:     []string{"~ spec.ingress[0].ports[0].port: 80 -> 8080"},
+		},
+		{
+			name:     "modified port inside a reordered rule",
+			live:     newNetworkPolicy(owned, ingressFrom("api", 80), ingressFrom("admin", 22)),
+			rendered: newNetworkPolicy(owned, ingressFrom("admin", 22), ingressFrom("api", 8080)),
+			want:     []string{"~ spec.ingress[1].ports[0].port: 80 -> 8080"},
+		},
+		{
+			name:     "added rule",
+			live:     newNetworkPolicy(owned, ingressFrom("api")),
+			rendered: newNetworkPolicy(owned, ingressFrom("api"), ingressFrom("admin")),
+			want:     []string{`+ spec.ingress[1]: {"from":[{"podSelector":{"matchLabels":{"app":"admin"}}}]}`},
+		},
+		{
+			name:     "removed rule",
+			live:     newNetworkPolicy(owned, ingressFrom("admin"), ingressFrom("api")),
+			rendered: newNetworkPolicy(owned, ingressFrom("api")),
+			want:     []string{`- spec.ingress[0]: {"from":[{"podSelector":{"matchLabels":{"app":"admin"}}}]}`},
+		},
+		{
+			name:     "labels only",
+			live:     newNetworkPolicy(owned, ingressFrom("api", 80)),
+			rendered: newNetworkPolicy(map[string]string{"kubeaegis.cclab.com/policy": "api", "team": "store"}, ingressFrom("api", 80)),
+			want: []string{
+				`~ metadata.labels["kubeaegis.cclab.com/policy"]: "web" -> "api"`,
+				`+ metadata.labels.team: "store"`,
+			},
+		},
+		{
+			name:     "empty and missing fields are alike",
+			live:     &networkingv1.NetworkPolicy{Spec: networkingv1.NetworkPolicySpec{Ingress: []networkingv1.NetworkPolicyIngressRule{}, PolicyTypes: []networkingv1.PolicyType{}}},
+			rendered: &networkingv1.NetworkPolicy{},
+		},
+		{
+			name:     "nil live object",
+			live:     nil,
+			rendered: newNetworkPolicy(nil),
+			want:     []string{`+ spec: {"podSelector":{"matchLabels":{"app":"web"}},"policyTypes":["Ingress"]}`},
+		},
+		{
+			name:     "typed nil live object",
+			live:     (*networkingv1.NetworkPolicy)(nil),
+			rendered: newNetworkPolicy(owned),
+			want: []string{
+				`+ metadata: {"labels":{"kubeaegis.cclab.com/policy":"web"}}`,
+				`+ spec: {"podSelector":{"matchLabels":{"app":"web"}},"policyTypes":["Ingress"]}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Objects(tt.live, tt.rendered)
+			if err != nil {
+				t.Fatalf("Objects() error = %v", err)
+			}
+			if got := Strings(changes); len(got)+len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Objects() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{value: nil, want: true},
+		{value: "", want: true},
+		{value: false, want: true},
+		{value: int64(0), want: true},
+		{value: float64(0), want: true},
+		{value: map[string]interface{}{}, want: true},
+		{value: []interface{}{}, want: true},
+		{value: "TCP"},
+		{value: true},
+		{value: int64(80)},
+		{value: []interface{}{nil}},
+	}
+	for _, tt := range tests {
+		if got := isEmpty(tt.value); got != tt.want {
+			t.Errorf("isEmpty(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package recommandpool
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	ciliumapi "github.com/cilium/cilium/pkg/policy/api"
@@ -253,9 +256,16 @@ func CreateEgressFQDNsRule(intentRequest v1.IntentRequest) ([]ciliumapi.EgressRu
 	var egressRules []ciliumapi.EgressRule
 
 	for _, to := range intentRequest.Rule.To {
-		if to.Kind == "fqdn" && len(to.Args) > 0 {
+		if (to.Kind == "fqdn" || to.Kind == "fqdns") && len(to.Args) > 0 {
 			var fqdnRules []ciliumapi.FQDNSelector
 			for _, fqdn := range to.Args {
+				// Names with wildcards are patterns.
+				if strings.Contains(fqdn, "*") {
+					fqdnRules = append(fqdnRules, ciliumapi.FQDNSelector{
+						MatchPattern: fqdn,
+					})
+					continue
+				}
 				fqdnRules = append(fqdnRules, ciliumapi.FQDNSelector{
 					MatchName: fqdn,
 				})
@@ -958,24 +968,63 @@ func CreateHTTPRules(actionPoints []v1.ActionPoint) ([]calico.HTTPMatch, error)
 
 	for _, ap := range actionPoints {
 		if ap.SubType == "http" {
-			httpRule := calico.HTTPMatch{
-				Methods: ap.Resource.Methods,
-				Paths:   createHTTPPaths(ap.Resource.Path),
+			if len(ap.Headers) > 0 {
+				return nil, fmt.Errorf("calico cannot match HTTP headers")
+			}
+			methods, err := createHTTPMethods(ap.Resource.Methods)
+			if err != nil {
+				return nil, err
+			}
+			paths, err := createHTTPPaths(ap.Resource.Path)
+			if err != nil {
+				return nil, err
 			}
-			httpRules = append(httpRules, httpRule)
+			httpRules = append(httpRules, calico.HTTPMatch{Methods: methods, Paths: paths})
 		}
 	}
 	return httpRules, nil
 }
 
-// createHTTPPaths converts path strings to HTTPPath objects
-func createHTTPPaths(paths []string) []calico.HTTPPath {
+// createHTTPMethods checks that the method patterns are plain method names,
+// as Calico only matches methods exactly.
+func createHTTPMethods(methods []string) ([]string, error) {
+	for _, method := range methods {
+		if literal, complete := literalPattern(method); !complete || literal != method {
+			return nil, fmt.Errorf("calico cannot match the HTTP method pattern %q; only method names are supported", method)
+		}
+	}
+	return methods, nil
+}
+
+// createHTTPPaths converts path patterns to HTTPPath objects. Calico matches
+// paths exactly or by prefix, so only literal paths and literal prefixes
+// followed by .* are supported.
+func createHTTPPaths(paths []string) ([]calico.HTTPPath, error) {
 	ruleDescription = "This function converts path strings to HTTPPath objects for Calico. It processes the list of paths and creates HTTPPath objects that match the specified paths."
 	var httpPaths []calico.HTTPPath
 	for _, path := range paths {
-		httpPaths = append(httpPaths, calico.HTTPPath{Exact: path})
+		if literal, complete := literalPattern(path); complete {
+			httpPaths = append(httpPaths, calico.HTTPPath{Exact: literal})
+			continue
+		}
+		prefix := strings.TrimSuffix(path, ".*")
+		if literal, complete := literalPattern(prefix); complete && prefix != path {
+			httpPaths = append(httpPaths, calico.HTTPPath{Prefix: literal})
+			continue
+		}
+		return nil, fmt.Errorf("calico cannot match the HTTP path pattern %q; only exact paths and prefixes ending in .* are supported", path)
 	}
-	return httpPaths
+	return httpPaths, nil
+}
+
+// literalPattern returns the text a regular expression matches and whether
+// it matches nothing else.
+func literalPattern(pattern string) (string, bool) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+	return re.LiteralPrefix()
 }
 
 // ----------------------------
@@ -988,23 +1037,11 @@ func CreateIngressICMPRule(intentRequest v1.IntentRequest) ([]calico.Rule, error
 	var Rules []calico.Rule
 
 	for _, from := range intentRequest.Rule.From {
-		if from.Kind == "icmp" && len(from.Args) >= 2 {
-			icmpType, err := strconv.Atoi(from.Args[0])
-			if err != nil {
-				return nil, err
-			}
-			icmpCode, err := strconv.Atoi(from.Args[1])
+		if from.Kind == "icmp" {
+			Rule, err := createICMPRule(from)
 			if err != nil {
 				return nil, err
 			}
-
-			Rule := calico.Rule{
-				Protocol: &calicoapilib.Protocol{StrVal: "ICMP"},
-				ICMP: &calico.ICMPFields{
-					Type: &icmpType,
-					Code: &icmpCode,
-				},
-			}
 			Rules = append(Rules, Rule)
 		}
 	}
@@ -1017,29 +1054,48 @@ func CreateEgressICMPRule(intentRequest v1.IntentRequest) ([]calico.Rule, error)
 	var Rules []calico.Rule
 
 	for _, to := range intentRequest.Rule.To {
-		if to.Kind == "icmp" && len(to.Args) >= 2 {
-			icmpType, err := strconv.Atoi(to.Args[0])
-			if err != nil {
-				return nil, err
-			}
-			icmpCode, err := strconv.Atoi(to.Args[1])
+		if to.Kind == "icmp" {
+			Rule, err := createICMPRule(to)
 			if err != nil {
 				return nil, err
 			}
-
-			Rule := calico.Rule{
-				Protocol: &calicoapilib.Protocol{StrVal: "ICMP"},
-				ICMP: &calico.ICMPFields{
-					Type: &icmpType,
-					Code: &icmpCode,
-				},
-			}
 			Rules = append(Rules, Rule)
 		}
 	}
 	return Rules, nil
 }
 
+// createICMPRule generates a rule for the ICMP type and, optionally, code in
+// the args of an icmp entry. The protocol is ICMP unless ICMPv6 is set.
+func createICMPRule(detail v1.NetPolDetail) (calico.Rule, error) {
+	protocol := calicoapilib.ProtocolFromString(calicoapilib.ProtocolICMP)
+	switch detail.Protocol {
+	case "", calicoapilib.ProtocolICMP:
+	case calicoapilib.ProtocolICMPv6:
+		protocol = calicoapilib.ProtocolFromString(calicoapilib.ProtocolICMPv6)
+	default:
+		return calico.Rule{}, fmt.Errorf("icmp rule has protocol %s; must be ICMP or ICMPv6", detail.Protocol)
+	}
+
+	Rule := calico.Rule{Protocol: &protocol}
+	if len(detail.Args) == 0 {
+		return Rule, nil
+	}
+	icmpType, err := strconv.Atoi(detail.Args[0])
+	if err != nil {
+		return calico.Rule{}, fmt.Errorf("invalid ICMP type %q: %v", detail.Args[0], err)
+	}
+	Rule.ICMP = &calico.ICMPFields{Type: &icmpType}
+	if len(detail.Args) > 1 {
+		icmpCode, err := strconv.Atoi(detail.Args[1])
+		if err != nil {
+			return calico.Rule{}, fmt.Errorf("invalid ICMP code %q: %v", detail.Args[1], err)
+		}
+		Rule.ICMP.Code = &icmpCode
+	}
+	return Rule, nil
+}
+
 // ----------------------------
 // Calico Policy: Action
 // ----------------------------
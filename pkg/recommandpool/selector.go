@@ -3,6 +3,7 @@ package recommandpool
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
@@ -17,43 +18,36 @@ import (
 // ExtractSelector extracts match labels from a Selector.
 func ExtractSelector(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (map[string]string, error) {
 	ruleDescription = "This function extracts match labels from a Selector in a Kubernetes environment. It processes CEL expressions and match fields from the selector, generating a map of labels that match the specified criteria."
-	matchLabels := make(map[string]string) // Initialize map for match labels.
-
-	// Process CEL expressions.
-	if len(selector.CEL) > 0 {
-		celExpressions := selector.CEL
-		celMatchLabels, err := processor.ProcessCEL(ctx, k8sClient, namespace, celExpressions)
-		if err != nil {
-			return nil, fmt.Errorf("error processing CEL: %v", err)
-		}
-		for k, v := range celMatchLabels {
-			matchLabels[k] = v
-		}
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling selector")
 	}
-
-	// Process Match fields.
-	if len(selector.Match) > 0 {
-		processedMatchLabels, err := processor.ProcessMatchLabels(selector.Match)
-		if err != nil {
-			return nil, errors.Wrap(err, "error processing matchLabels")
-		}
-		for key, value := range processedMatchLabels {
-			matchLabels[key] = value
-		}
+	if compiled.IsEmpty() {
+		return nil, nil
 	}
 
+	matchLabels, err := compiled.MatchLabels(ctx, k8sClient, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error converting selector: %v", err)
+	}
 	return matchLabels, nil
 }
 
 // ExtractStringSelector generates a selector string from given labels.
 func ExtractGiveFormatsSelector(labels map[string]string) string {
 	ruleDescription = "This function generates a selector string from given labels. It constructs a string representation of label-based selectors, enabling selection of resources that match the specified labels."
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	var selector string
-	for key, value := range labels {
+	for _, key := range keys {
 		if selector != "" {
 			selector += " && "
 		}
-		selector += fmt.Sprintf("%s == '%s'", key, value)
+		selector += fmt.Sprintf("%s == '%s'", key, labels[key])
 	}
 	return selector
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,6 +30,19 @@ func SetOwnerReferencesCNP(ctx context.Context, k8sClient client.Client, kap *v1
 	return nil
 }
 
+// SetOwnerReferencesNetPol sets the KubeAegisPolicy as owner of the NetworkPolicy
+func SetOwnerReferencesNetPol(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy, policy *networkingv1.NetworkPolicy) error {
+	ownerRef := metav1.OwnerReference{
+		APIVersion: "cclab.kubeaegis.com/v1",
+		Kind:       "KubeAegisPolicy",
+		Name:       kap.Name,
+		UID:        kap.UID,
+	}
+
+	policy.SetOwnerReferences(append(policy.GetOwnerReferences(), ownerRef))
+	return nil
+}
+
 // SetOwnerReferences sets the KubeAegisPolicy as owner of the KubeArmorPolicy
 func SetOwnerReferencesKSP(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy, ksp *karmorv1.KubeArmorPolicy) error {
 	// Fetch the KubeAegisPolicy to use it as an owner reference
@@ -60,22 +74,6 @@ func SetOwnerReferencesKCP(ctx context.Context, k8sClient client.Client, kap *v1
 	return nil
 }
 
-// SetOwnerReferencesPod sets the AP as the owner of the Pod
-func SetOwnerReferencesPodfromKSP(ctx context.Context, k8sClient client.Client, ksp *karmorv1.KubeArmorPolicy, pod *corev1.Pod) error {
-	// Fetch the KubeAegisPolicy to use it as an owner reference
-	ownerRef := metav1.OwnerReference{
-		APIVersion: "security.kubearmor.com",
-		Kind:       "kubearmorpolicies",
-		Name:       ksp.Name,
-		UID:        ksp.UID,
-	}
-
-	// Set the KubeAegisPolicy as the owner of the KubeArmorPolicy
-	pod.SetOwnerReferences(append(ksp.GetOwnerReferences(), ownerRef))
-	k8sClient.Update(ctx, pod)
-	return nil
-}
-
 // SetOwnerReferencesPod sets the AP as the owner of the Pod
 func SetOwnerReferencesPod(ctx context.Context, k8sClient client.Client, ap client.Object, pod *corev1.Pod) error {
 	apKey := types.NamespacedName{Name: ap.GetName(), Namespace: ap.GetNamespace()}
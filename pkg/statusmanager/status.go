@@ -3,20 +3,42 @@ package statusmanager
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	pb "github.com/cclab-inu/KubeAegis/api/grpc"
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"github.com/cclab-inu/KubeAegis/pkg/policydiff"
 	"github.com/cclab-inu/KubeAegis/pkg/reporter"
 )
 
 const (
 	StatusCreated = "Created"
+
+	// ConditionAdapterCompatible reports whether the adapters a KubeAegisPolicy
+	// was routed to speak a PolicyService protocol version the controller
+	// understands.
+	ConditionAdapterCompatible = "AdapterCompatible"
+
+	ReasonProtocolCompatible   = "ProtocolCompatible"
+	ReasonProtocolIncompatible = "ProtocolIncompatible"
+
+	StatusInvalid = "Invalid"
+
+	// ConditionValidated reports whether the validators found errors in the
+	// KubeAegisPolicy. The findings themselves are in status.validationResults.
+	ConditionValidated = "Validated"
+
+	ReasonValidationPassed = "ValidationPassed"
+	ReasonValidationFailed = "ValidationFailed"
 )
 
 func UpdateKapStatus(ctx context.Context, k8sClient client.Client, kapName, kapNamespace string) error {
@@ -33,6 +55,150 @@ func UpdateKapStatus(ctx context.Context, k8sClient client.Client, kapName, kapN
 	})
 }
 
+// SetKapCondition adds or replaces a condition on the KubeAegisPolicy status.
+func SetKapCondition(ctx context.Context, k8sClient client.Client, kapName, kapNamespace string, condition metav1.Condition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		kap := &v1.KubeAegisPolicy{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: kapName, Namespace: kapNamespace}, kap); err != nil {
+			return err
+		}
+
+		condition.ObservedGeneration = kap.Generation
+		meta.SetStatusCondition(&kap.Status.Conditions, condition)
+
+		return k8sClient.Status().Update(ctx, kap)
+	})
+}
+
+// SetAdapterCompatibility merges the protocol checks of adapters into
+// status.incompatibleAdapters and sets the AdapterCompatible condition from
+// it. results maps each checked adapter to the reason it is incompatible, or
+// to "" when it is compatible. Adapters that were not checked keep their
+// earlier result.
+func SetAdapterCompatibility(ctx context.Context, k8sClient client.Client, kapName, kapNamespace string, results map[string]string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		kap := &v1.KubeAegisPolicy{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: kapName, Namespace: kapNamespace}, kap); err != nil {
+			return err
+		}
+
+		incompatible := map[string]string{}
+		for _, adapter := range kap.Status.IncompatibleAdapters {
+			incompatible[adapter.Adapter] = adapter.Reason
+		}
+		for adapterName, reason := range results {
+			if reason == "" {
+				delete(incompatible, adapterName)
+			} else {
+				incompatible[adapterName] = reason
+			}
+		}
+
+		adapterNames := make([]string, 0, len(incompatible))
+		for adapterName := range incompatible {
+			adapterNames = append(adapterNames, adapterName)
+		}
+		sort.Strings(adapterNames)
+		kap.Status.IncompatibleAdapters = nil
+		entries := make([]string, 0, len(adapterNames))
+		for _, adapterName := range adapterNames {
+			kap.Status.IncompatibleAdapters = append(kap.Status.IncompatibleAdapters, v1.AdapterIncompatibility{Adapter: adapterName, Reason: incompatible[adapterName]})
+			entries = append(entries, adapterName+": "+incompatible[adapterName])
+		}
+
+		condition := metav1.Condition{
+			Type:               ConditionAdapterCompatible,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonProtocolCompatible,
+			Message:            fmt.Sprintf("All adapters speak a compatible PolicyService protocol (controller v%d)", pb.ProtocolVersion),
+			ObservedGeneration: kap.Generation,
+		}
+		if len(entries) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonProtocolIncompatible
+			condition.Message = strings.Join(entries, "; ")
+		}
+		meta.SetStatusCondition(&kap.Status.Conditions, condition)
+
+		return k8sClient.Status().Update(ctx, kap)
+	})
+}
+
+// SetValidationResults records the validation results and the Validated
+// condition on the KubeAegisPolicy status. A policy with errors is marked
+// Invalid.
+func SetValidationResults(ctx context.Context, k8sClient client.Client, kapName, kapNamespace string, results []v1.ValidationResult, hasErrors bool, summary string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		kap := &v1.KubeAegisPolicy{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: kapName, Namespace: kapNamespace}, kap); err != nil {
+			return err
+		}
+
+		condition := metav1.Condition{
+			Type:               ConditionValidated,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonValidationPassed,
+			Message:            summary,
+			ObservedGeneration: kap.Generation,
+		}
+		if hasErrors {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonValidationFailed
+			kap.Status.Status = StatusInvalid
+			kap.Status.LastUpdated = metav1.Now()
+		}
+		meta.SetStatusCondition(&kap.Status.Conditions, condition)
+		kap.Status.ValidationResults = results
+
+		return k8sClient.Status().Update(ctx, kap)
+	})
+}
+
+// SetPolicyDiff records what an adapter changed in a generated policy. A
+// diff without changes leaves the status as it is, so that reconciling an
+// unchanged policy does not write the KubeAegisPolicy.
+func SetPolicyDiff(ctx context.Context, k8sClient client.Client, kapName, kapNamespace string, diff v1.PolicyDiff) error {
+	if len(diff.Changes) == 0 {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		kap := &v1.KubeAegisPolicy{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: kapName, Namespace: kapNamespace}, kap); err != nil {
+			return err
+		}
+
+		diff.LastUpdated = metav1.Now()
+		replaced := false
+		for i := range kap.Status.PolicyDiffs {
+			if kap.Status.PolicyDiffs[i].Kind == diff.Kind && kap.Status.PolicyDiffs[i].Name == diff.Name {
+				kap.Status.PolicyDiffs[i] = diff
+				replaced = true
+			}
+		}
+		if !replaced {
+			kap.Status.PolicyDiffs = append(kap.Status.PolicyDiffs, diff)
+		}
+
+		return k8sClient.Status().Update(ctx, kap)
+	})
+}
+
+// RecordPolicyDiff compares a generated policy before and after an adapter
+// updated it and records the changes with SetPolicyDiff. Both objects should
+// come from the API server, so that defaulted fields do not show up as
+// changes.
+func RecordPolicyDiff(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy, kind string, before, after client.Object) error {
+	changes, err := policydiff.Objects(before, after)
+	if err != nil {
+		return err
+	}
+	return SetPolicyDiff(ctx, k8sClient, kap.Name, kap.Namespace, v1.PolicyDiff{
+		Kind:    kind,
+		Name:    after.GetName(),
+		Changes: policydiff.Strings(changes),
+	})
+}
+
 func UpdateKapStatusAfterPolicy(ctx context.Context, k8sClient client.Client, currPolicyFullName, kapName, namespace string) error {
 	if retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		latestKap := &v1.KubeAegisPolicy{}
@@ -0,0 +1,88 @@
+package statusmanager
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestSetAdapterCompatibility(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	kap := &v1.KubeAegisPolicy{ObjectMeta: metav1.ObjectMeta{Name: "kap", Namespace: "default"}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kap).WithStatusSubresource(kap).Build()
+
+	steps := []struct {
+		name       string
+		results    map[string]string
+		wantStatus metav1.ConditionStatus
+		wantMsg    string
+		want       []v1.AdapterIncompatibility
+	}{
+		{
+			name:       "dispatch finds two incompatible adapters",
+			results:    map[string]string{"kubeaegis-cilium": "old protocol", "kubeaegis-calico": "old protocol", "kubeaegis-kyverno": ""},
+			wantStatus: metav1.ConditionFalse,
+			wantMsg:    "kubeaegis-calico: old protocol; kubeaegis-cilium: old protocol",
+			want:       []v1.AdapterIncompatibility{{Adapter: "kubeaegis-calico", Reason: "old protocol"}, {Adapter: "kubeaegis-cilium", Reason: "old protocol"}},
+		},
+		{
+			name:       "retry of another adapter keeps them",
+			results:    map[string]string{"kubeaegis-kubearmor": "protocol v3: too new; upgrade the controller"},
+			wantStatus: metav1.ConditionFalse,
+			wantMsg:    "kubeaegis-calico: old protocol; kubeaegis-cilium: old protocol; kubeaegis-kubearmor: protocol v3: too new; upgrade the controller",
+			want: []v1.AdapterIncompatibility{
+				{Adapter: "kubeaegis-calico", Reason: "old protocol"},
+				{Adapter: "kubeaegis-cilium", Reason: "old protocol"},
+				{Adapter: "kubeaegis-kubearmor", Reason: "protocol v3: too new; upgrade the controller"},
+			},
+		},
+		{
+			name:       "upgraded adapter is dropped",
+			results:    map[string]string{"kubeaegis-cilium": ""},
+			wantStatus: metav1.ConditionFalse,
+			wantMsg:    "kubeaegis-calico: old protocol; kubeaegis-kubearmor: protocol v3: too new; upgrade the controller",
+			want: []v1.AdapterIncompatibility{
+				{Adapter: "kubeaegis-calico", Reason: "old protocol"},
+				{Adapter: "kubeaegis-kubearmor", Reason: "protocol v3: too new; upgrade the controller"},
+			},
+		},
+		{
+			name:       "all compatible",
+			results:    map[string]string{"kubeaegis-calico": "", "kubeaegis-kubearmor": ""},
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, step := range steps {
+		if err := SetAdapterCompatibility(context.Background(), k8sClient, "kap", "default", step.results); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := &v1.KubeAegisPolicy{}
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "kap", Namespace: "default"}, got); err != nil {
+			t.Fatal(err)
+		}
+		condition := meta.FindStatusCondition(got.Status.Conditions, ConditionAdapterCompatible)
+		if condition == nil {
+			t.Fatalf("%s: no %s condition", step.name, ConditionAdapterCompatible)
+		}
+		if condition.Status != step.wantStatus {
+			t.Errorf("%s: status = %s, want %s", step.name, condition.Status, step.wantStatus)
+		}
+		if step.wantMsg != "" && condition.Message != step.wantMsg {
+			t.Errorf("%s: message = %q, want %q", step.name, condition.Message, step.wantMsg)
+		}
+		if !reflect.DeepEqual(got.Status.IncompatibleAdapters, step.want) {
+			t.Errorf("%s: incompatibleAdapters = %v, want %v", step.name, got.Status.IncompatibleAdapters, step.want)
+		}
+	}
+}
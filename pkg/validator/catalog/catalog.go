@@ -0,0 +1,261 @@
+// Package catalog holds the syscall, capability and protocol names that system
+// intents are validated against. The syscall and capability tables are
+// generated from golang.org/x/sys/unix; run "go generate" to refresh them.
+package catalog
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:generate go run gen.go
+
+var (
+	//go:embed syscalls_amd64.txt
+	syscallsAMD64 string
+	//go:embed syscalls_arm64.txt
+	syscallsARM64 string
+	//go:embed capabilities.txt
+	capabilitiesTable string
+)
+
+// Architectures lists the architectures with a syscall table.
+var Architectures = []string{"amd64", "arm64"}
+
+var (
+	syscalls = map[string]map[string]bool{
+		"amd64": parseTable(syscallsAMD64),
+		"arm64": parseTable(syscallsARM64),
+	}
+	capabilities = parseTable(capabilitiesTable)
+	protocols    = map[string]bool{"tcp": true, "udp": true, "icmp": true, "icmpv6": true, "raw": true, "sctp": true}
+)
+
+// syscallAliases groups syscalls that do the same job. Newer architectures such
+// as arm64 only provide the *at forms.
+var syscallAliases = [][]string{
+	{"open", "openat", "openat2", "creat"},
+	{"stat", "lstat", "newfstatat", "statx"},
+	{"unlink", "rmdir", "unlinkat"},
+	{"mkdir", "mkdirat"},
+	{"mknod", "mknodat"},
+	{"rename", "renameat", "renameat2"},
+	{"link", "linkat"},
+	{"symlink", "symlinkat"},
+	{"readlink", "readlinkat"},
+	{"chmod", "fchmodat", "fchmodat2"},
+	{"chown", "lchown", "fchownat"},
+	{"access", "faccessat", "faccessat2"},
+	{"utimes", "utime", "futimesat", "utimensat"},
+	{"getdents", "getdents64"},
+	{"fork", "vfork", "clone", "clone3"},
+	{"dup2", "dup3"},
+	{"pipe", "pipe2"},
+	{"poll", "ppoll"},
+	{"select", "pselect6"},
+	{"epoll_create", "epoll_create1"},
+	{"epoll_wait", "epoll_pwait", "epoll_pwait2"},
+	{"eventfd", "eventfd2"},
+	{"signalfd", "signalfd4"},
+	{"inotify_init", "inotify_init1"},
+}
+
+// syscallPrefixes are stripped from kprobe and tracepoint names.
+var syscallPrefixes = []string{"syscalls/", "__x64_sys_", "__arm64_sys_", "__ia32_sys_", "sys_enter_", "sys_exit_", "sys_"}
+
+func parseTable(table string) map[string]bool {
+	names := map[string]bool{}
+	for _, line := range strings.Split(table, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(line, " ")
+		names[name] = true
+	}
+	return names
+}
+
+// NormalizeSyscall lowercases a syscall name and strips kprobe and tracepoint
+// prefixes such as "__x64_sys_" and "sys_enter_".
+func NormalizeSyscall(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, prefix := range syscallPrefixes {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name
+}
+
+// SyscallArchitectures returns the architectures that provide the syscall.
+func SyscallArchitectures(name string) []string {
+	name = NormalizeSyscall(name)
+	var arches []string
+	for _, arch := range Architectures {
+		if syscalls[arch][name] {
+			arches = append(arches, arch)
+		}
+	}
+	return arches
+}
+
+// Alternatives returns the syscalls that do the same job as name.
+func Alternatives(name string) []string {
+	name = NormalizeSyscall(name)
+	var alternatives []string
+	for _, group := range syscallAliases {
+		if !contains(group, name) {
+			continue
+		}
+		for _, alias := range group {
+			if alias != name && !contains(alternatives, alias) {
+				alternatives = append(alternatives, alias)
+			}
+		}
+	}
+	return alternatives
+}
+
+// Error is a name rejected by the catalog.
+type Error struct {
+	// Index is the position of the name in the list given to CheckSyscalls.
+	Index int
+
+	// Unknown is set when the name does not exist at all, as opposed to not
+	// existing on one of the architectures.
+	Unknown bool
+
+	message string
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// CheckSyscalls validates the syscalls of one intent for the given
+// architectures. A syscall missing on an architecture is accepted when the
+// intent also names an alternative that exists there. With no architectures,
+// a syscall only has to exist on one of them. The errors are *Error values.
+func CheckSyscalls(names []string, arches []string) []error {
+	listed := map[string]bool{}
+	for _, name := range names {
+		listed[NormalizeSyscall(name)] = true
+	}
+
+	var errs []error
+	for i, name := range names {
+		normalized := NormalizeSyscall(name)
+		available := SyscallArchitectures(normalized)
+		if len(available) == 0 {
+			errs = append(errs, &Error{Index: i, Unknown: true, message: fmt.Sprintf("unknown syscall %q%s", name, suggest(normalized, allSyscalls()))})
+			continue
+		}
+
+		for _, arch := range arches {
+			table, known := syscalls[arch]
+			if !known || table[normalized] {
+				continue
+			}
+
+			var covered bool
+			var usable []string
+			for _, alternative := range Alternatives(normalized) {
+				if !table[alternative] {
+					continue
+				}
+				usable = append(usable, alternative)
+				covered = covered || listed[alternative]
+			}
+			if covered {
+				continue
+			}
+			if len(usable) > 0 {
+				errs = append(errs, &Error{Index: i, message: fmt.Sprintf("syscall %q does not exist on %s; add %s to cover it", name, arch, strings.Join(usable, " or "))})
+			} else {
+				errs = append(errs, &Error{Index: i, message: fmt.Sprintf("syscall %q does not exist on %s", name, arch)})
+			}
+		}
+	}
+	return errs
+}
+
+// CheckCapability validates a capability name, with or without the CAP_ prefix.
+func CheckCapability(name string) error {
+	normalized := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "cap_")
+	if capabilities[normalized] {
+		return nil
+	}
+	return &Error{Unknown: true, message: fmt.Sprintf("unknown capability %q%s", name, suggest(normalized, keys(capabilities)))}
+}
+
+// CheckProtocol validates a network protocol name.
+func CheckProtocol(name string) error {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if protocols[normalized] {
+		return nil
+	}
+	return &Error{Unknown: true, message: fmt.Sprintf("unknown protocol %q%s", name, suggest(normalized, keys(protocols)))}
+}
+
+func allSyscalls() []string {
+	names := map[string]bool{}
+	for _, table := range syscalls {
+		for name := range table {
+			names[name] = true
+		}
+	}
+	return keys(names)
+}
+
+// suggest returns a "did you mean" hint for the closest candidate within two edits.
+func suggest(name string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := distance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func keys(m map[string]bool) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(slice []string, str string) bool {
+	for _, v := range slice {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package catalog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	tables := map[string]string{
+		"syscalls_amd64.txt": syscallsAMD64,
+		"syscalls_arm64.txt": syscallsARM64,
+		"capabilities.txt":   capabilitiesTable,
+	}
+	for name, table := range tables {
+		entries := 0
+		for _, line := range strings.Split(table, "\n") {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries++
+			if fields := strings.Fields(line); len(fields) == 0 || len(fields) > 2 || fields[0] != strings.ToLower(fields[0]) {
+				t.Errorf("%s: malformed line %q", name, line)
+			}
+		}
+		if entries == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+
+	for _, arch := range Architectures {
+		if !syscalls[arch]["read"] || !syscalls[arch]["execve"] {
+			t.Errorf("%s table lacks read or execve", arch)
+		}
+	}
+	if !capabilities["net_admin"] || !capabilities["sys_admin"] {
+		t.Errorf("capability table lacks net_admin or sys_admin")
+	}
+}
+
+func TestSyscallArchitectures(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "openat", want: []string{"amd64", "arm64"}},
+		{name: "open", want: []string{"amd64"}},
+		{name: "__x64_sys_openat", want: []string{"amd64", "arm64"}},
+		{name: "sys_enter_execve", want: []string{"amd64", "arm64"}},
+		{name: " OPENAT2 ", want: []string{"amd64", "arm64"}},
+		{name: "nosuchcall"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SyscallArchitectures(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SyscallArchitectures(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlternatives(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "open", want: []string{"openat", "openat2", "creat"}},
+		{name: "openat", want: []string{"open", "openat2", "creat"}},
+		{name: "__arm64_sys_openat2", want: []string{"open", "openat", "creat"}},
+		{name: "read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Alternatives(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Alternatives(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSyscalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		arches []string
+		want   []string
+	}{
+		{
+			name:   "available everywhere",
+			names:  []string{"openat", "execve"},
+			arches: Architectures,
+		},
+		{
+			name:  "available on one architecture",
+			names: []string{"open"},
+		},
+		{
+			name:   "missing on arm64",
+			names:  []string{"open"},
+			arches: []string{"arm64"},
+			want:   []string{`syscall "open" does not exist on arm64; add openat or openat2 to cover it`},
+		},
+		{
+			name:   "covered by an alternative",
+			names:  []string{"open", "openat"},
+			arches: Architectures,
+		},
+		{
+			name:  "unknown syscall",
+			names: []string{"read", "execvee"},
+			want:  []string{`unknown syscall "execvee", did you mean "execve"?`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range CheckSyscalls(tt.names, tt.arches) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckSyscalls() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	errs := CheckSyscalls([]string{"read", "execvee", "open"}, []string{"arm64"})
+	var unknown, missing *Error
+	if len(errs) != 2 || !errors.As(errs[0], &unknown) || !errors.As(errs[1], &missing) {
+		t.Fatalf("CheckSyscalls() = %v, want two *Error values", errs)
+	}
+	if unknown.Index != 1 || !unknown.Unknown || missing.Index != 2 || missing.Unknown {
+		t.Errorf("CheckSyscalls() = %+v, %+v, want an unknown name at 1 and a missing one at 2", unknown, missing)
+	}
+}
+
+func TestCheckCapability(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "net_admin"},
+		{name: "CAP_NET_ADMIN"},
+		{name: " cap_sys_admin "},
+		{name: "CAP_NET_ADMN", wantErr: `unknown capability "CAP_NET_ADMN", did you mean "net_admin"?`},
+		{name: "superpowers", wantErr: `unknown capability "superpowers"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCapability(tt.name)
+			if got := errorString(err); got != tt.wantErr {
+				t.Errorf("CheckCapability(%q) = %q, want %q", tt.name, got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "TCP"},
+		{name: "icmpv6"},
+		{name: "tpc", wantErr: `unknown protocol "tpc", did you mean "tcp"?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorString(CheckProtocol(tt.name)); got != tt.wantErr {
+				t.Errorf("CheckProtocol(%q) = %q, want %q", tt.name, got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"open", "open", 0},
+		{"open", "opne", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
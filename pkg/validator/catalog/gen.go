@@ -0,0 +1,86 @@
+//go:build ignore
+
+// gen writes the syscall and capability tables embedded by the catalog
+// package from the constants in golang.org/x/sys/unix.
+//
+//	go run gen.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	syscallConst    = regexp.MustCompile(`^\s+SYS_([A-Z0-9_]+)\s+=\s+(\d+)$`)
+	capabilityConst = regexp.MustCompile(`^\s+CAP_([A-Z_]+)\s+=\s+0x[0-9a-f]+$`)
+)
+
+func main() {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "golang.org/x/sys").Output()
+	if err != nil {
+		fail(fmt.Errorf("locating golang.org/x/sys: %w", err))
+	}
+	unixDir := filepath.Join(strings.TrimSpace(string(out)), "unix")
+
+	for _, arch := range []string{"amd64", "arm64"} {
+		lines, err := scan(filepath.Join(unixDir, "zsysnum_linux_"+arch+".go"), syscallConst, func(m []string) string {
+			return strings.ToLower(m[1]) + " " + m[2]
+		})
+		if err != nil {
+			fail(err)
+		}
+		if err := write("syscalls_"+arch+".txt", lines); err != nil {
+			fail(err)
+		}
+	}
+
+	lines, err := scan(filepath.Join(unixDir, "zerrors_linux.go"), capabilityConst, func(m []string) string {
+		if m[1] == "LAST_CAP" {
+			return ""
+		}
+		return strings.ToLower(m[1])
+	})
+	if err != nil {
+		fail(err)
+	}
+	sort.Strings(lines)
+	if err := write("capabilities.txt", lines); err != nil {
+		fail(err)
+	}
+}
+
+func scan(path string, re *regexp.Regexp, format func([]string) string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := re.FindStringSubmatch(scanner.Text()); m != nil {
+			if line := format(m); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func write(name string, lines []string) error {
+	header := "# Code generated by gen.go from golang.org/x/sys/unix. DO NOT EDIT.\n"
+	return os.WriteFile(name, []byte(header+strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,208 @@
+// Conflict Validator
+// Check a set of policies for intents that act on the same workloads and
+// targets with opposing actions, and for the order the engines give them.
+package validator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// loggingActions only record traffic or events and never conflict with the
+// actions that decide on them.
+var loggingActions = []string{"Log", "Audit", "Trace"}
+
+// fixedPrecedence names the engines that enforce Block over Allow whatever
+// the priority, by intent type. Cluster and Baseline policies are rendered as
+// AdminNetworkPolicies, which honor the priority.
+var fixedPrecedence = map[string]string{
+	"network": "Cilium",
+	"system":  "KubeArmor",
+	"cluster": "Kyverno",
+}
+
+// ValidateConflicts compares the intents of the policies with each other. It
+// returns the results of each policy in the order of kaps. Intents conflict
+// when their selectors can pick the same pods, they share a target, and one
+// allows what the other blocks. Selectors with CEL expressions or
+// matchExpressions are assumed to overlap.
+func ValidateConflicts(kaps []*v1.KubeAegisPolicy) []ResultList {
+	results := make([]ResultList, len(kaps))
+	for a := range kaps {
+		for b := a + 1; b < len(kaps); b++ {
+			for i, intentA := range kaps[a].Spec.IntentRequest {
+				for j, intentB := range kaps[b].Spec.IntentRequest {
+					if !intentsConflict(kaps[a], intentA, kaps[b], intentB) {
+						continue
+					}
+					pathA := field.NewPath("spec", "intentRequest").Index(i)
+					pathB := field.NewPath("spec", "intentRequest").Index(j)
+					resultA, resultB := conflictResults(kaps[a], intentA, pathA, kaps[b], intentB, pathB)
+					results[a] = append(results[a], resultA...)
+					results[b] = append(results[b], resultB...)
+				}
+			}
+		}
+	}
+	return results
+}
+
+// conflictResults reports a conflict on both intents when the policies have
+// the same priority. Otherwise it reports the intent that should win but is
+// overridden by an engine with a fixed precedence.
+func conflictResults(kapA *v1.KubeAegisPolicy, intentA v1.IntentRequest, pathA *field.Path, kapB *v1.KubeAegisPolicy, intentB v1.IntentRequest, pathB *field.Path) (ResultList, ResultList) {
+	priorityA, priorityB := priorityOf(kapA), priorityOf(kapB)
+	if priorityA == priorityB {
+		return ResultList{samePriority(pathA, intentA, kapB, pathB, intentB)},
+			ResultList{samePriority(pathB, intentB, kapA, pathA, intentA)}
+	}
+
+	engine, ok := fixedPrecedence[intentA.Type]
+	if !ok || selectsClusterWide(kapA) {
+		return nil, nil
+	}
+	// Only a winning Allow can be overridden, by a Block of lower priority.
+	if priorityA < priorityB && intentA.Rule.Action == "Allow" {
+		return ResultList{priorityIgnored(pathA, engine, kapB, pathB, intentB)}, nil
+	}
+	if priorityB < priorityA && intentB.Rule.Action == "Allow" {
+		return nil, ResultList{priorityIgnored(pathB, engine, kapA, pathA, intentA)}
+	}
+	return nil, nil
+}
+
+func samePriority(path *field.Path, intent v1.IntentRequest, other *v1.KubeAegisPolicy, otherPath *field.Path, otherIntent v1.IntentRequest) Result {
+	return Warningf(path, CodePriorityConflict, "%s overlaps %s %s of %s/%s, which has the same priority; set spec.priority to order them",
+		intent.Rule.Action, otherIntent.Rule.Action, otherPath, other.Namespace, other.Name)
+}
+
+func priorityIgnored(path *field.Path, engine string, other *v1.KubeAegisPolicy, otherPath *field.Path, otherIntent v1.IntentRequest) Result {
+	return Warningf(path, CodePriorityIgnored, "takes precedence over %s %s of %s/%s, but %s enforces %s whatever the priority",
+		otherIntent.Rule.Action, otherPath, other.Namespace, other.Name, engine, otherIntent.Rule.Action)
+}
+
+// priorityOf returns the priority of a policy. Policies without one rank
+// below every policy that has one.
+func priorityOf(kap *v1.KubeAegisPolicy) int64 {
+	if kap.Spec.Priority == nil {
+		return math.MaxInt32 + 1
+	}
+	return int64(*kap.Spec.Priority)
+}
+
+func intentsConflict(kapA *v1.KubeAegisPolicy, intentA v1.IntentRequest, kapB *v1.KubeAegisPolicy, intentB v1.IntentRequest) bool {
+	if intentA.Type != intentB.Type || intentA.Rule.Action == intentB.Rule.Action {
+		return false
+	}
+	if contains(loggingActions, intentA.Rule.Action) || contains(loggingActions, intentB.Rule.Action) {
+		return false
+	}
+	if selectsClusterWide(kapA) != selectsClusterWide(kapB) {
+		return false
+	}
+	if !namespacesOverlap(kapA, intentA.Selector, kapB, intentB.Selector) || !labelsOverlap(intentA.Selector, intentB.Selector) {
+		return false
+	}
+	return sharesTarget(intentA, intentB)
+}
+
+func namespacesOverlap(kapA *v1.KubeAegisPolicy, selectorA v1.Selector, kapB *v1.KubeAegisPolicy, selectorB v1.Selector) bool {
+	if selectsClusterWide(kapA) || selectorA.NamespaceSelector != nil || selectorB.NamespaceSelector != nil {
+		return true
+	}
+	return selectorNamespace(kapA, selectorA) == selectorNamespace(kapB, selectorB)
+}
+
+func selectorNamespace(kap *v1.KubeAegisPolicy, selector v1.Selector) string {
+	for _, match := range selector.Match {
+		if match.Namespace != "" {
+			return match.Namespace
+		}
+	}
+	return kap.Namespace
+}
+
+// labelsOverlap reports whether a pod can carry the matchLabels of both
+// selectors.
+func labelsOverlap(selectorA, selectorB v1.Selector) bool {
+	if len(selectorA.CEL) > 0 || len(selectorB.CEL) > 0 {
+		return true
+	}
+	labels := map[string]string{}
+	for _, selector := range []v1.Selector{selectorA, selectorB} {
+		for _, match := range selector.Match {
+			if len(match.MatchExpressions) > 0 {
+				return true
+			}
+			for key, value := range match.MatchLabels {
+				if existing, ok := labels[key]; ok && existing != value {
+					return false
+				}
+				labels[key] = value
+			}
+		}
+	}
+	return true
+}
+
+// sharesTarget reports whether the intents have an identical from or to
+// entry, or system action points on the same resource.
+func sharesTarget(intentA, intentB v1.IntentRequest) bool {
+	for _, a := range intentA.Rule.From {
+		for _, b := range intentB.Rule.From {
+			if reflect.DeepEqual(a, b) {
+				return true
+			}
+		}
+	}
+	for _, a := range intentA.Rule.To {
+		for _, b := range intentB.Rule.To {
+			if reflect.DeepEqual(a, b) {
+				return true
+			}
+		}
+	}
+
+	if intentA.Type != "system" {
+		return false
+	}
+	targets := map[string]bool{}
+	for _, point := range intentA.Rule.ActionPoint {
+		for _, target := range pointTargets(point) {
+			targets[target] = true
+		}
+	}
+	for _, point := range intentB.Rule.ActionPoint {
+		for _, target := range pointTargets(point) {
+			if targets[target] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pointTargets returns keys for the resources a system action point acts on.
+func pointTargets(point v1.ActionPoint) []string {
+	var targets []string
+	for _, path := range point.Resource.Path {
+		targets = append(targets, fmt.Sprintf("%s path %s", point.SubType, path))
+	}
+	for _, pattern := range point.Resource.Pattern {
+		targets = append(targets, fmt.Sprintf("%s pattern %s", point.SubType, pattern))
+	}
+	if point.Resource.Dir != "" {
+		targets = append(targets, fmt.Sprintf("%s dir %s", point.SubType, point.Resource.Dir))
+	}
+	if point.Resource.Protocol != "" {
+		targets = append(targets, fmt.Sprintf("%s protocol %s", point.SubType, point.Resource.Protocol))
+	}
+	if point.Resource.Syscall != "" {
+		targets = append(targets, fmt.Sprintf("%s syscall %s", point.SubType, point.Resource.Syscall))
+	}
+	return targets
+}
@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newConflictPolicy(name, scope string, priority *int32, intents ...v1.IntentRequest) *v1.KubeAegisPolicy {
+	return &v1.KubeAegisPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.KubeAegisPolicySpec{Scope: scope, Priority: priority, IntentRequest: intents},
+	}
+}
+
+func ingressIntent(action string, podLabels map[string]string, from v1.NetPolDetail) v1.IntentRequest {
+	return v1.IntentRequest{
+		Type:     "network",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: podLabels}}},
+		Rule:     v1.Rule{Action: action, From: []v1.NetPolDetail{from}},
+	}
+}
+
+func fileIntent(action string, podLabels map[string]string, path string) v1.IntentRequest {
+	return v1.IntentRequest{
+		Type:     "system",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: podLabels}}},
+		Rule:     v1.Rule{Action: action, ActionPoint: []v1.ActionPoint{{SubType: "file", Resource: v1.EventMatchResource{Path: []string{path}}}}},
+	}
+}
+
+func TestValidateConflicts(t *testing.T) {
+	one, two := int32(1), int32(2)
+	web := map[string]string{"app": "web"}
+	scanner := v1.NetPolDetail{Kind: "endpoint", Labels: map[string]string{"app": "scanner"}, Port: "22", Protocol: "TCP"}
+
+	tests := []struct {
+		name string
+		kaps []*v1.KubeAegisPolicy
+		want [][]Code
+	}{
+		{
+			name: "same priority",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", nil, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", nil, ingressIntent("Block", web, scanner)),
+			},
+			want: [][]Code{{CodePriorityConflict}, {CodePriorityConflict}},
+		},
+		{
+			name: "allow with precedence is ignored by Cilium",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", &one, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", &two, ingressIntent("Block", web, scanner)),
+			},
+			want: [][]Code{{CodePriorityIgnored}, nil},
+		},
+		{
+			name: "a policy without a priority ranks last",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", nil, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", &two, ingressIntent("Block", web, scanner)),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "block with precedence",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("block", "", &one, ingressIntent("Block", web, scanner)),
+				newConflictPolicy("allow", "", &two, ingressIntent("Allow", web, scanner)),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "cluster policies honor the priority",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", v1.ScopeCluster, &one, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", v1.ScopeCluster, &two, ingressIntent("Block", web, scanner)),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "cluster and namespaced policies are not compared",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", v1.ScopeCluster, nil, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", nil, ingressIntent("Block", web, scanner)),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "disjoint selectors",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", nil, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", nil, ingressIntent("Block", map[string]string{"app": "api"}, scanner)),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "different targets",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", nil, ingressIntent("Allow", web, scanner)),
+				newConflictPolicy("block", "", nil, ingressIntent("Block", web, v1.NetPolDetail{Kind: "cidr", Args: []string{"10.0.0.0/8"}})),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "logging actions",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("audit", "", nil, fileIntent("Audit", web, "/etc/passwd")),
+				newConflictPolicy("block", "", nil, fileIntent("Block", web, "/etc/passwd")),
+			},
+			want: [][]Code{nil, nil},
+		},
+		{
+			name: "system intents on the same path",
+			kaps: []*v1.KubeAegisPolicy{
+				newConflictPolicy("allow", "", &one, fileIntent("Allow", web, "/etc/passwd")),
+				newConflictPolicy("block", "", &two, fileIntent("Block", nil, "/etc/passwd")),
+			},
+			want: [][]Code{{CodePriorityIgnored}, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]Code
+			for _, results := range ValidateConflicts(tt.kaps) {
+				got = append(got, codes(results))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
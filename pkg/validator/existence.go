@@ -5,165 +5,122 @@ package validator
 
 import (
 	"context"
-	"fmt"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
-	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func ValidateExistence(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy) []error {
-	var errs []error
+func ValidateExistence(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy) ResultList {
+	var results ResultList
 
 	// Iterate over each intentRequest to check resource existence
-	for _, intentRequest := range kap.Spec.IntentRequest {
-		if (intentRequest.Type == "system" && len(intentRequest.Rule.From) > 0) || (intentRequest.Type == "system" && len(intentRequest.Rule.To) > 0) {
-			errs = append(errs, errors.Errorf("Most likely you want to create a policy of network type, check the 'type' of the policy you are creating.: %v", intentRequest.Type))
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		path := field.NewPath("spec", "intentRequest").Index(i)
+		selectorPath := path.Child("selector")
+
+		if intentRequest.Type == "system" && (len(intentRequest.Rule.From) > 0 || len(intentRequest.Rule.To) > 0) {
+			results = append(results, Errorf(path.Child("type"), CodeIntentTypeMismatch, "rule.from and rule.to are network rules; most likely the type should be network, not %s", intentRequest.Type))
 		}
 
-		matchLabels, err := processor.ProcessMatchLabels(intentRequest.Selector.Match)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("error processing match labels: %v", err))
-			continue
+		if _, err := processor.SelectNamespaces(ctx, k8sClient, kap.Namespace, intentRequest.Selector); processor.IsNoMatch(err) {
+			results = append(results, Errorf(selectorPath.Child("namespaceSelector"), CodeSelectorNoMatch, "%v", err))
+		} else if err != nil {
+			results = append(results, Errorf(selectorPath.Child("namespaceSelector"), CodeSelectorInvalid, "%v", err))
 		}
-		selector := labels.SelectorFromSet(matchLabels)
 
 		if len(intentRequest.Selector.CEL) > 0 {
-			if _, err := ValidateCEL(ctx, k8sClient, kap); err != nil {
-				errs = append(errs, err)
+			pods, err := processor.EvaluateCEL(ctx, k8sClient, kap.Namespace, intentRequest.Selector.CEL)
+			if err != nil {
+				results = append(results, Errorf(selectorPath.Child("cel"), CodeSelectorInvalid, "%v", err))
+			} else if len(pods) == 0 {
+				results = append(results, Errorf(selectorPath.Child("cel"), CodeSelectorNoMatch, "no resources found matching the CEL expressions"))
 			}
 		}
 
-		if len(intentRequest.Selector.Match) > 0 {
-			for _, match := range intentRequest.Selector.Match {
+		for j, match := range intentRequest.Selector.Match {
+			matchPath := selectorPath.Child("match").Index(j)
 
-				if err := validateNamespaces(ctx, k8sClient, match); err != nil {
-					errs = append(errs, err)
-				}
-				err := validateNamespaceStatus(ctx, k8sClient, match.Namespace)
-				if err != nil {
-					errs = append(errs, err)
-				}
+			var nsResults ResultList
+			if match.Namespace != "" {
+				nsResults = validateNamespace(ctx, k8sClient, matchPath.Child("namespace"), match.Namespace)
+				results = append(results, nsResults...)
+			}
+
+			selector, err := processor.MatchSelector(match)
+			if err != nil {
+				results = append(results, Errorf(matchPath.Child("matchExpressions"), CodeSelectorInvalid, "%v", err))
+				continue
+			}
+			if len(nsResults) > 0 {
+				continue
+			}
 
-				switch match.Kind {
-				case "Pod":
-					err := checkPodExistence(ctx, k8sClient, intentRequest.Selector.Match[0].Namespace, selector)
-					if err != nil {
-						errs = append(errs, err)
-					}
-				case "Service":
-					if err := validateServices(ctx, k8sClient, match); err != nil {
-						errs = append(errs, err)
-					}
-				case "Deployment":
-					err := validateDeployments(ctx, k8sClient, match)
-					if err != nil {
-						errs = append(errs, err)
-					}
-				case "ConfigMap":
-					err := validateConfigMaps(ctx, k8sClient, match.Namespace, selector)
-					if err != nil {
-						errs = append(errs, err)
-					}
+			// Named workloads are checked by resolving them to their pods.
+			if match.Name != "" {
+				if _, err := processor.ResolveMatch(ctx, k8sClient, kap.Namespace, match); err != nil {
+					results = append(results, Errorf(matchPath.Child("name"), CodeWorkloadNotFound, "%v", err))
 				}
+				continue
 			}
+
+			results = append(results, validateMatchingObjects(ctx, k8sClient, matchPath, match, selector)...)
 		}
 	}
 
-	return errs
+	return results
 }
 
-// validatePods checks if the Pods matching the selector exist.
-func checkPodExistence(ctx context.Context, k8sClient client.Client, namespace string, selector labels.Selector) error {
-	var pods corev1.PodList
-	listOpts := []client.ListOption{
-		client.InNamespace(namespace),
-		client.MatchingLabelsSelector{Selector: selector},
-	}
-
-	if err := k8sClient.List(ctx, &pods, listOpts...); err != nil {
-		return fmt.Errorf("error listing pods: %v", err)
-	}
-
-	if len(pods.Items) == 0 {
-		return fmt.Errorf("no matching pods found in namespace %s with labels %v", namespace, selector)
-	}
-
-	return nil
+// existenceLists maps the kinds whose existence is checked to their list types.
+var existenceLists = map[string]func() client.ObjectList{
+	"Pod":        func() client.ObjectList { return &corev1.PodList{} },
+	"Service":    func() client.ObjectList { return &corev1.ServiceList{} },
+	"Deployment": func() client.ObjectList { return &appsv1.DeploymentList{} },
+	"ConfigMap":  func() client.ObjectList { return &corev1.ConfigMapList{} },
+	"Node":       func() client.ObjectList { return &corev1.NodeList{} },
 }
 
-// validateServices checks if the Services matching the selector exist.
-func validateServices(ctx context.Context, k8sClient client.Client, match v1.Match) error {
-	var services corev1.ServiceList
-	listOpts := []client.ListOption{
-		client.InNamespace(match.Namespace),
-		client.MatchingLabels(match.MatchLabels),
+// validateMatchingObjects checks that objects of the match's kind matching the
+// selector exist.
+func validateMatchingObjects(ctx context.Context, k8sClient client.Client, path *field.Path, match v1.Match, selector labels.Selector) ResultList {
+	newList, ok := existenceLists[match.Kind]
+	if !ok {
+		return nil
 	}
-	if err := k8sClient.List(ctx, &services, listOpts...); err != nil {
-		return errors.Errorf("error fetching services: %v", err)
-	}
-	if len(services.Items) == 0 {
-		return errors.Errorf("no services found matching the selector")
-	}
-	return nil
-}
 
-func validateDeployments(ctx context.Context, k8sClient client.Client, match v1.Match) error {
-	var deployments appsv1.DeploymentList
+	list := newList()
 	listOpts := []client.ListOption{
 		client.InNamespace(match.Namespace),
-		client.MatchingLabels(match.MatchLabels),
-	}
-	if err := k8sClient.List(ctx, &deployments, listOpts...); err != nil {
-		return errors.Errorf("error fetching deployments: %v", err)
-	}
-	if len(deployments.Items) == 0 {
-		return errors.Errorf("no deployments found matching the selector")
-	}
-	return nil
-}
-
-// validateConfigMaps checks if the ConfigMaps matching the selector exist.
-func validateConfigMaps(ctx context.Context, k8sClient client.Client, namespace string, selector labels.Selector) error {
-	var configMaps corev1.ConfigMapList
-	listOpts := []client.ListOption{
-		client.InNamespace(namespace),
 		client.MatchingLabelsSelector{Selector: selector},
 	}
-
-	if err := k8sClient.List(ctx, &configMaps, listOpts...); err != nil {
-		return fmt.Errorf("error listing ConfigMaps: %v", err)
+	if err := k8sClient.List(ctx, list, listOpts...); err != nil {
+		return ResultList{Errorf(path, CodeLookupFailed, "error listing %ss: %v", match.Kind, err)}
 	}
-
-	if len(configMaps.Items) == 0 {
-		return fmt.Errorf("no matching ConfigMaps found in namespace %s with labels %v", namespace, selector)
+	if meta.LenList(list) == 0 {
+		return ResultList{Errorf(path, CodeSelectorNoMatch, "no matching %ss found in namespace %s with labels %v", match.Kind, match.Namespace, selector)}
 	}
-
 	return nil
 }
 
-func validateNamespaces(ctx context.Context, k8sClient client.Client, match v1.Match) error {
-	var namespace corev1.Namespace
-	if err := k8sClient.Get(ctx, types.NamespacedName{Name: match.Namespace}, &namespace); err != nil {
-		return errors.Errorf("error fetching namespace '%s': %v", match.Namespace, err)
-	}
-	return nil
-}
-
-// validateNamespaceStatus checks the status of the namespace to ensure it is active.
-func validateNamespaceStatus(ctx context.Context, k8sClient client.Client, namespace string) error {
+// validateNamespace checks that the namespace exists and is active.
+func validateNamespace(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string) ResultList {
 	var ns corev1.Namespace
 	if err := k8sClient.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
-		return errors.Errorf("error fetching namespace '%s': %v", namespace, err)
+		if apierrors.IsNotFound(err) {
+			return ResultList{Errorf(path, CodeNamespaceNotFound, "namespace '%s' does not exist", namespace)}
+		}
+		return ResultList{Errorf(path, CodeLookupFailed, "error fetching namespace '%s': %v", namespace, err)}
 	}
 
 	if ns.Status.Phase != corev1.NamespaceActive {
-		return errors.Errorf("namespace '%s' is not in an active phase", namespace)
+		return ResultList{Errorf(path, CodeNamespaceInactive, "namespace '%s' is not in an active phase", namespace)}
 	}
 
 	return nil
@@ -0,0 +1,68 @@
+// HTTP Validator
+// Check the http action points of network intents and the ports they apply to.
+package validator
+
+import (
+	"regexp"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// validateHTTPPoints checks that the http action points of a network intent
+// have rules with TCP ports to attach to, and that their paths and methods are
+// valid regular expressions, as Cilium reads them.
+func validateHTTPPoints(path *field.Path, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	pointsPath := path.Child("rule", "actionPoint")
+
+	var points []int
+	for p, point := range intentRequest.Rule.ActionPoint {
+		if point.SubType == "http" {
+			points = append(points, p)
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	tcpPorts := 0
+	for _, r := range networkRules(path, intentRequest) {
+		if r.rule.Kind != "port" && r.rule.Port == "" {
+			continue
+		}
+		if r.rule.Protocol == "" {
+			results = append(results, Errorf(r.path.Child("protocol"), CodeHTTPPortMissing, "HTTP rules only apply to TCP; set protocol: TCP on this rule"))
+			continue
+		}
+		if r.rule.Protocol != "TCP" {
+			results = append(results, Errorf(r.path.Child("protocol"), CodeHTTPPortMissing, "HTTP rules only apply to TCP; protocol %q cannot carry them", r.rule.Protocol))
+			continue
+		}
+		tcpPorts++
+	}
+	if tcpPorts == 0 {
+		results = append(results, Errorf(pointsPath.Index(points[0]), CodeHTTPPortMissing, "http action points need a rule with a port and protocol TCP to apply to"))
+	}
+
+	for _, p := range points {
+		point := intentRequest.Rule.ActionPoint[p]
+		resourcePath := pointsPath.Index(p).Child("resource")
+		for i, method := range point.Resource.Methods {
+			if _, err := regexp.Compile(method); err != nil || method == "" {
+				results = append(results, Errorf(resourcePath.Child("methods").Index(i), CodeHTTPRuleInvalid, "invalid method %q; must be a method or a regular expression", method))
+			}
+		}
+		for i, httpPath := range point.Resource.Path {
+			if _, err := regexp.Compile(httpPath); err != nil || httpPath == "" {
+				results = append(results, Errorf(resourcePath.Child("path").Index(i), CodeHTTPRuleInvalid, "invalid path %q; must be a path or a regular expression", httpPath))
+			}
+		}
+		for i, header := range point.Headers {
+			if header.Name == "" {
+				results = append(results, Errorf(pointsPath.Index(p).Child("headers").Index(i).Child("name"), CodeFieldRequired, "header name is empty"))
+			}
+		}
+	}
+	return results
+}
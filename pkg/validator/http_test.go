@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestValidateHTTPPoints(t *testing.T) {
+	httpPoint := func(methods, paths []string, headers ...v1.EventHeader) v1.ActionPoint {
+		return v1.ActionPoint{SubType: "http", Headers: headers, Resource: v1.EventMatchResource{Methods: methods, Path: paths}}
+	}
+	port := func(port, protocol string) v1.NetPolDetail {
+		return v1.NetPolDetail{Kind: "port", Port: port, Protocol: protocol}
+	}
+
+	tests := []struct {
+		name   string
+		from   []v1.NetPolDetail
+		to     []v1.NetPolDetail
+		points []v1.ActionPoint
+		want   []string
+	}{
+		{
+			name: "no http action points",
+			to:   []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8"}}},
+		},
+		{
+			name:   "ingress TCP port",
+			from:   []v1.NetPolDetail{port("80", "TCP")},
+			points: []v1.ActionPoint{httpPoint([]string{"GET", "P(OST|UT)"}, []string{"/api/.*"}, v1.EventHeader{Name: "X-Token"})},
+		},
+		{
+			name:   "egress peer with a TCP port",
+			to:     []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "api"}, Port: "8080", Protocol: "TCP"}},
+			points: []v1.ActionPoint{httpPoint([]string{"GET"}, nil)},
+		},
+		{
+			name:   "no rule with a port",
+			to:     []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "api"}}},
+			points: []v1.ActionPoint{{SubType: "file"}, httpPoint([]string{"GET"}, nil)},
+			want:   []string{"spec.intentRequest[0].rule.actionPoint[1] HTTPPortMissing"},
+		},
+		{
+			name:   "UDP port",
+			from:   []v1.NetPolDetail{port("53", "UDP")},
+			points: []v1.ActionPoint{httpPoint([]string{"GET"}, nil)},
+			want: []string{
+				"spec.intentRequest[0].rule.from[0].protocol HTTPPortMissing",
+				"spec.intentRequest[0].rule.actionPoint[0] HTTPPortMissing",
+			},
+		},
+		{
+			name:   "port without a protocol next to a TCP port",
+			to:     []v1.NetPolDetail{port("80", ""), port("443", "TCP")},
+			points: []v1.ActionPoint{httpPoint([]string{"GET"}, nil)},
+			want:   []string{"spec.intentRequest[0].rule.to[0].protocol HTTPPortMissing"},
+		},
+		{
+			name:   "invalid methods, paths and headers",
+			from:   []v1.NetPolDetail{port("80", "TCP")},
+			points: []v1.ActionPoint{httpPoint([]string{"GET", "", "(POST"}, []string{"/[", ""}, v1.EventHeader{Value: "1"})},
+			want: []string{
+				"spec.intentRequest[0].rule.actionPoint[0].resource.methods[1] HTTPRuleInvalid",
+				"spec.intentRequest[0].rule.actionPoint[0].resource.methods[2] HTTPRuleInvalid",
+				"spec.intentRequest[0].rule.actionPoint[0].resource.path[0] HTTPRuleInvalid",
+				"spec.intentRequest[0].rule.actionPoint[0].resource.path[1] HTTPRuleInvalid",
+				"spec.intentRequest[0].rule.actionPoint[0].headers[0].name FieldRequired",
+			},
+		},
+	}
+
+	path := field.NewPath("spec", "intentRequest").Index(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Allow", From: tt.from, To: tt.to, ActionPoint: tt.points}}
+			if got := fieldCodes(validateHTTPPoints(path, intentRequest)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateHTTPPoints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateHTTPPointsProtocolMessage(t *testing.T) {
+	intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{
+		Action:      "Allow",
+		From:        []v1.NetPolDetail{{Kind: "port", Port: "80"}, {Kind: "port", Port: "53", Protocol: "UDP"}},
+		ActionPoint: []v1.ActionPoint{{SubType: "http", Resource: v1.EventMatchResource{Methods: []string{"GET"}}}},
+	}}
+
+	results := validateHTTPPoints(field.NewPath("spec"), intentRequest)
+	want := []string{
+		"HTTP rules only apply to TCP; set protocol: TCP on this rule",
+		`HTTP rules only apply to TCP; protocol "UDP" cannot carry them`,
+		"http action points need a rule with a port and protocol TCP to apply to",
+	}
+	if len(results) != len(want) {
+		t.Fatalf("validateHTTPPoints() = %v, want %d results", results, len(want))
+	}
+	for i, result := range results {
+		if !strings.HasPrefix(result.Message, want[i]) {
+			t.Errorf("validateHTTPPoints() message = %q, want %q", result.Message, want[i])
+		}
+	}
+}
@@ -8,23 +8,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func KapValidator(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) ([]error, error) {
-	var validationErrors []error
-	logger.Info("Step 1: Check for the existence of a resource")
-	if errs := ValidateExistence(ctx, k8sClient, kap); len(errs) > 0 {
-		validationErrors = append(validationErrors, errs...)
-		if len(validationErrors) > 0 {
-			return validationErrors, nil
-		}
-	}
+// KapValidator runs every validation step and returns all of their results,
+// so that every problem in the policy is reported at once.
+func KapValidator(ctx context.Context, k8sClient client.Client, logger logr.Logger, kap *v1.KubeAegisPolicy) (ResultList, error) {
+	var results ResultList
+	logger.Info("Step 1: Check the fields of the spec")
+	results = append(results, ValidateSpec(kap)...)
 
-	logger.Info("Step 2: Check resource status and properties")
-	if errs := ValidatePrecondition(ctx, k8sClient, kap); len(errs) > 0 {
-		validationErrors = append(validationErrors, errs...)
-		if len(validationErrors) > 0 {
-			return validationErrors, nil
-		}
-	}
+	logger.Info("Step 2: Check for the existence of a resource")
+	results = append(results, ValidateExistence(ctx, k8sClient, kap)...)
 
-	return validationErrors, nil
+	logger.Info("Step 3: Check resource status and properties")
+	results = append(results, ValidatePrecondition(ctx, k8sClient, kap)...)
+
+	return results, nil
 }
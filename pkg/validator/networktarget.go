@@ -0,0 +1,234 @@
+// Network Target Validator
+// Check the CIDRs, except ranges and FQDNs that network rules point at.
+package validator
+
+import (
+	"context"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fqdnLabel is the syntax Cilium accepts for a label of a toFQDNs matchName
+// and, with wildcards, matchPattern.
+var fqdnLabel = regexp.MustCompile(`^[-a-zA-Z0-9_*]+$`)
+
+// clusterRange is an address range used by the cluster itself.
+type clusterRange struct {
+	prefix netip.Prefix
+	source string
+}
+
+// validateNetworkTargets checks the targets of the from and to rules of a
+// network intent.
+func validateNetworkTargets(ctx context.Context, k8sClient client.Client, path *field.Path, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	var clusterRanges []clusterRange
+	discovered := false
+
+	for _, r := range networkRules(path, intentRequest) {
+		switch r.rule.Kind {
+		case "cidr":
+			if !discovered {
+				clusterRanges = discoverClusterRanges(ctx, k8sClient)
+				discovered = true
+			}
+			results = append(results, validateCIDRRule(r, clusterRanges)...)
+		case "fqdn", "fqdns":
+			results = append(results, validateFQDNRule(r)...)
+		default:
+			if len(r.rule.Except) > 0 {
+				results = append(results, Errorf(r.path.Child("except"), CodeExceptInvalid, "except only applies to cidr rules, not %s", r.rule.Kind))
+			}
+		}
+	}
+
+	return results
+}
+
+func validateCIDRRule(r networkRule, clusterRanges []clusterRange) ResultList {
+	var results ResultList
+	if len(r.rule.Args) == 0 {
+		return ResultList{Errorf(r.path.Child("args"), CodeCIDRInvalid, "cidr rule lists no CIDRs")}
+	}
+
+	var prefixes []netip.Prefix
+	for i, arg := range r.rule.Args {
+		argPath := r.path.Child("args").Index(i)
+		prefix, result, ok := parseCIDR(argPath, arg)
+		if result != nil {
+			results = append(results, *result)
+		}
+		if !ok {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+
+		var overlaps []string
+		for _, cluster := range clusterRanges {
+			if prefix.Overlaps(cluster.prefix) {
+				overlaps = append(overlaps, cluster.source+" "+cluster.prefix.String())
+			}
+		}
+		if len(overlaps) > 3 {
+			overlaps = append(overlaps[:3], "...")
+		}
+		if len(overlaps) > 0 {
+			results = append(results, Warningf(argPath, CodeCIDROverlapsCluster, "%s overlaps %s; traffic to pods and services is usually matched by their labels, not their addresses", arg, strings.Join(overlaps, ", ")))
+		}
+	}
+
+	for i, except := range r.rule.Except {
+		exceptPath := r.path.Child("except").Index(i)
+		prefix, result, ok := parseCIDR(exceptPath, except)
+		if result != nil {
+			results = append(results, *result)
+		}
+		if !ok {
+			continue
+		}
+
+		inside := false
+		for _, cidr := range prefixes {
+			if cidr.Addr().Is4() == prefix.Addr().Is4() && cidr.Bits() < prefix.Bits() && cidr.Contains(prefix.Addr()) {
+				inside = true
+			}
+		}
+		if !inside {
+			results = append(results, Errorf(exceptPath, CodeExceptInvalid, "%s is not a narrower range inside one of the rule's CIDRs", except))
+		}
+	}
+
+	return results
+}
+
+// parseCIDR parses an IPv4 or IPv6 CIDR. A CIDR with host bits set is accepted
+// with a warning; ok is false when the value cannot be used at all.
+func parseCIDR(path *field.Path, value string) (prefix netip.Prefix, result *Result, ok bool) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+	if err != nil {
+		if addr, addrErr := netip.ParseAddr(strings.TrimSpace(value)); addrErr == nil {
+			r := Errorf(path, CodeCIDRInvalid, "%s is an address, not a CIDR; use %s", value, netip.PrefixFrom(addr, addr.BitLen()))
+			return prefix, &r, false
+		}
+		r := Errorf(path, CodeCIDRInvalid, "invalid CIDR: %s", value)
+		return prefix, &r, false
+	}
+	if prefix.Addr().Zone() != "" {
+		r := Errorf(path, CodeCIDRInvalid, "CIDR %s must not carry a zone", value)
+		return prefix, &r, false
+	}
+	if masked := prefix.Masked(); masked != prefix {
+		r := Warningf(path, CodeCIDRNotCanonical, "%s has host bits set and is read as %s", value, masked)
+		return masked, &r, true
+	}
+	return prefix, nil, true
+}
+
+func validateFQDNRule(r networkRule) ResultList {
+	var results ResultList
+	if r.ingress {
+		results = append(results, Errorf(r.path.Child("kind"), CodeFQDNInvalid, "FQDN rules only apply to egress; move them to rule.to"))
+	}
+	if len(r.rule.Args) == 0 {
+		results = append(results, Errorf(r.path.Child("args"), CodeFQDNInvalid, "fqdn rule lists no names"))
+	}
+
+	for i, name := range r.rule.Args {
+		argPath := r.path.Child("args").Index(i)
+		if _, err := netip.ParsePrefix(name); err == nil {
+			results = append(results, Errorf(argPath, CodeFQDNInvalid, "%s is a CIDR; use a cidr rule", name))
+			continue
+		}
+		if _, err := netip.ParseAddr(name); err == nil {
+			results = append(results, Errorf(argPath, CodeFQDNInvalid, "%s is an address; use a cidr rule", name))
+			continue
+		}
+		if msg := checkFQDN(name); msg != "" {
+			results = append(results, Errorf(argPath, CodeFQDNInvalid, "invalid FQDN %q: %s", name, msg))
+			continue
+		}
+		if strings.TrimSuffix(name, ".") == "*" {
+			results = append(results, Warningf(argPath, CodeFQDNWildcardAll, "%q matches every name", name))
+		}
+	}
+	return results
+}
+
+// checkFQDN returns why name is not a valid FQDN or wildcard pattern, or an
+// empty string.
+func checkFQDN(name string) string {
+	trimmed := strings.TrimSuffix(name, ".")
+	switch {
+	case trimmed == "":
+		return "name is empty"
+	case len(trimmed) > 253:
+		return "name is longer than 253 characters"
+	}
+	for _, label := range strings.Split(trimmed, ".") {
+		switch {
+		case label == "":
+			return "name has an empty label"
+		case len(label) > 63:
+			return "label " + label + " is longer than 63 characters"
+		case !fqdnLabel.MatchString(label):
+			return "only letters, digits, '-', '_', '*' and '.' are allowed"
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return "label " + label + " starts or ends with '-'"
+		}
+	}
+	return ""
+}
+
+// discoverClusterRanges returns the pod CIDRs of the nodes and the service
+// CIDRs of the cluster. Ranges that cannot be listed are left out. Without
+// ServiceCIDR objects, the address of the kubernetes Service stands in for the
+// service range.
+func discoverClusterRanges(ctx context.Context, k8sClient client.Client) []clusterRange {
+	var ranges []clusterRange
+	seen := map[netip.Prefix]bool{}
+	add := func(value, source string) {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil || seen[prefix.Masked()] {
+			return
+		}
+		seen[prefix.Masked()] = true
+		ranges = append(ranges, clusterRange{prefix: prefix.Masked(), source: source})
+	}
+
+	var nodes corev1.NodeList
+	if err := k8sClient.List(ctx, &nodes); err == nil {
+		for _, node := range nodes.Items {
+			for _, podCIDR := range node.Spec.PodCIDRs {
+				add(podCIDR, "pod CIDR of node "+node.Name)
+			}
+		}
+	}
+
+	var serviceCIDRs networkingv1.ServiceCIDRList
+	if err := k8sClient.List(ctx, &serviceCIDRs); err == nil && len(serviceCIDRs.Items) > 0 {
+		for _, serviceCIDR := range serviceCIDRs.Items {
+			for _, cidr := range serviceCIDR.Spec.CIDRs {
+				add(cidr, "service CIDR")
+			}
+		}
+		return ranges
+	}
+
+	var kubernetes corev1.Service
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "kubernetes"}, &kubernetes); err == nil {
+		for _, ip := range kubernetes.Spec.ClusterIPs {
+			if addr, err := netip.ParseAddr(ip); err == nil {
+				add(netip.PrefixFrom(addr, addr.BitLen()).String(), "kubernetes Service address")
+			}
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,222 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newNode(name string, podCIDRs ...string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: corev1.NodeSpec{PodCIDRs: podCIDRs}}
+}
+
+func newServiceCIDR(name string, cidrs ...string) *networkingv1.ServiceCIDR {
+	return &networkingv1.ServiceCIDR{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: networkingv1.ServiceCIDRSpec{CIDRs: cidrs}}
+}
+
+func newKubernetesService(clusterIPs ...string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{ClusterIP: clusterIPs[0], ClusterIPs: clusterIPs},
+	}
+}
+
+// fieldCodes renders results as "<field> <code>".
+func fieldCodes(results ResultList) []string {
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Field+" "+string(result.Code))
+	}
+	return got
+}
+
+func TestValidateNetworkTargets(t *testing.T) {
+	cidr := func(args []string, except ...string) v1.NetPolDetail {
+		return v1.NetPolDetail{Kind: "cidr", Args: args, Except: except}
+	}
+	fqdn := func(args ...string) v1.NetPolDetail {
+		return v1.NetPolDetail{Kind: "fqdn", Args: args}
+	}
+
+	tests := []struct {
+		name    string
+		from    []v1.NetPolDetail
+		to      []v1.NetPolDetail
+		objects []client.Object
+		want    []string
+	}{
+		{
+			name: "IPv4 and IPv6 CIDRs with narrower excepts",
+			to: []v1.NetPolDetail{
+				cidr([]string{"10.0.0.0/8"}, "10.1.0.0/16"),
+				cidr([]string{"2001:db8::/32"}, "2001:db8:1::/48"),
+			},
+		},
+		{
+			name: "except inside a second CIDR of the rule",
+			to:   []v1.NetPolDetail{cidr([]string{"10.0.0.0/8", "192.168.0.0/16"}, "192.168.1.0/24")},
+		},
+		{
+			name: "except outside the CIDRs",
+			to:   []v1.NetPolDetail{cidr([]string{"10.0.0.0/8"}, "192.168.0.0/16")},
+			want: []string{"spec.intentRequest[0].rule.to[0].except[0] ExceptInvalid"},
+		},
+		{
+			name: "except equal to the CIDR",
+			to:   []v1.NetPolDetail{cidr([]string{"10.0.0.0/8"}, "10.0.0.0/8")},
+			want: []string{"spec.intentRequest[0].rule.to[0].except[0] ExceptInvalid"},
+		},
+		{
+			name: "except of the other address family",
+			to:   []v1.NetPolDetail{cidr([]string{"::/0"}, "10.0.0.0/8")},
+			want: []string{"spec.intentRequest[0].rule.to[0].except[0] ExceptInvalid"},
+		},
+		{
+			name: "except on another kind",
+			from: []v1.NetPolDetail{{Kind: "endpoint", Args: []string{"app=api"}, Except: []string{"10.0.0.0/8"}}},
+			want: []string{"spec.intentRequest[0].rule.from[0].except ExceptInvalid"},
+		},
+		{
+			name: "invalid CIDRs",
+			to: []v1.NetPolDetail{
+				cidr([]string{"10.0.0.1", "10.0.0.0/33", "fd00::1/64"}),
+				cidr(nil),
+			},
+			want: []string{
+				"spec.intentRequest[0].rule.to[0].args[0] CIDRInvalid",
+				"spec.intentRequest[0].rule.to[0].args[1] CIDRInvalid",
+				"spec.intentRequest[0].rule.to[0].args[2] CIDRNotCanonical",
+				"spec.intentRequest[0].rule.to[1].args CIDRInvalid",
+			},
+		},
+		{
+			name:    "overlap with pod and service CIDRs",
+			to:      []v1.NetPolDetail{cidr([]string{"10.0.0.0/8", "fd00:10::/56", "192.168.0.0/16"})},
+			objects: []client.Object{newNode("node-1", "10.244.0.0/24", "fd00:10::/64"), newServiceCIDR("kubernetes", "10.96.0.0/12")},
+			want: []string{
+				"spec.intentRequest[0].rule.to[0].args[0] CIDROverlapsCluster",
+				"spec.intentRequest[0].rule.to[0].args[1] CIDROverlapsCluster",
+			},
+		},
+		{
+			name: "FQDNs and Cilium matchPatterns",
+			to:   []v1.NetPolDetail{fqdn("api.example.com", "api.example.com.", "*.example.com", "api-*.example.com", "*", "**.example.com")},
+			want: []string{"spec.intentRequest[0].rule.to[0].args[4] FQDNWildcardAll"},
+		},
+		{
+			name: "invalid FQDNs",
+			to:   []v1.NetPolDetail{fqdn("10.0.0.0/8", "10.0.0.1", "api..example.com", "-api.example.com", "api.example.com/v1", strings.Repeat("a", 64)+".com"), fqdn()},
+			want: []string{
+				"spec.intentRequest[0].rule.to[0].args[0] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[0].args[1] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[0].args[2] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[0].args[3] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[0].args[4] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[0].args[5] FQDNInvalid",
+				"spec.intentRequest[0].rule.to[1].args FQDNInvalid",
+			},
+		},
+		{
+			name: "FQDNs only apply to egress",
+			from: []v1.NetPolDetail{fqdn("api.example.com")},
+			want: []string{"spec.intentRequest[0].rule.from[0].kind FQDNInvalid"},
+		},
+	}
+
+	path := field.NewPath("spec", "intentRequest").Index(0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{Action: "Allow", From: tt.from, To: tt.to}}
+			results := validateNetworkTargets(context.Background(), newFakeClient(t, tt.objects...), path, intentRequest)
+			if got := fieldCodes(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateNetworkTargets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkTargetsOverlapMessage(t *testing.T) {
+	k8sClient := newFakeClient(t, newNode("node-1", "10.244.0.0/24"), newNode("node-2", "10.244.1.0/24"),
+		newNode("node-3", "10.244.2.0/24"), newServiceCIDR("kubernetes", "10.96.0.0/12"))
+	intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{
+		Action: "Allow",
+		To:     []v1.NetPolDetail{{Kind: "cidr", Args: []string{"10.0.0.0/8", "10.96.0.0/16"}}},
+	}}
+
+	results := validateNetworkTargets(context.Background(), k8sClient, field.NewPath("spec"), intentRequest)
+	want := []string{
+		"10.0.0.0/8 overlaps pod CIDR of node node-1 10.244.0.0/24, pod CIDR of node node-2 10.244.1.0/24, pod CIDR of node node-3 10.244.2.0/24, ...",
+		"10.96.0.0/16 overlaps service CIDR 10.96.0.0/12",
+	}
+	if len(results) != len(want) {
+		t.Fatalf("validateNetworkTargets() = %v, want %d results", results, len(want))
+	}
+	for i, result := range results {
+		if !strings.HasPrefix(result.Message, want[i]+";") {
+			t.Errorf("validateNetworkTargets() message = %q, want it to start with %q", result.Message, want[i])
+		}
+	}
+}
+
+func TestDiscoverClusterRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    []string
+	}{
+		{
+			name: "nothing to discover",
+		},
+		{
+			name:    "node pod CIDRs",
+			objects: []client.Object{newNode("node-1", "10.244.0.0/24", "fd00:10::/64"), newNode("node-2", "10.244.1.0/24"), newNode("node-3", "10.244.1.0/24")},
+			want: []string{
+				"pod CIDR of node node-1 10.244.0.0/24",
+				"pod CIDR of node node-1 fd00:10::/64",
+				"pod CIDR of node node-2 10.244.1.0/24",
+			},
+		},
+		{
+			name: "ServiceCIDRs take precedence over the kubernetes Service",
+			objects: []client.Object{
+				newNode("node-1", "10.244.0.0/24"),
+				newServiceCIDR("kubernetes", "10.96.0.0/12", "fd00:96::/108"),
+				newKubernetesService("10.96.0.1"),
+			},
+			want: []string{
+				"pod CIDR of node node-1 10.244.0.0/24",
+				"service CIDR 10.96.0.0/12",
+				"service CIDR fd00:96::/108",
+			},
+		},
+		{
+			name:    "kubernetes Service address without ServiceCIDRs",
+			objects: []client.Object{newKubernetesService("10.96.0.1", "fd00:96::1")},
+			want: []string{
+				"kubernetes Service address 10.96.0.1/32",
+				"kubernetes Service address fd00:96::1/128",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range discoverClusterRanges(context.Background(), newFakeClient(t, tt.objects...)) {
+				got = append(got, r.source+" "+r.prefix.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discoverClusterRanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
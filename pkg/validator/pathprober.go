@@ -0,0 +1,161 @@
+// Path Prober
+// Check the files, directories and patterns of system intents against the
+// filesystems of the containers they apply to.
+package validator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// ErrProbeUnavailable is returned when a container cannot be probed, for
+// example because its image ships no shell. Such containers are skipped.
+var ErrProbeUnavailable = errors.New("container filesystem cannot be probed")
+
+// PathInfo describes an existing path inside a container.
+type PathInfo struct {
+	IsDir bool
+}
+
+// PathProber looks up paths inside the containers of a pod.
+type PathProber interface {
+	// Stat returns the paths that exist in the container. Missing paths are
+	// left out of the result.
+	Stat(ctx context.Context, pod *corev1.Pod, container string, paths []string) (map[string]PathInfo, error)
+
+	// Glob returns the paths in the container that match pattern.
+	Glob(ctx context.Context, pod *corev1.Pod, container string, pattern string) ([]string, error)
+}
+
+var (
+	pathProberMu sync.RWMutex
+	pathProber   PathProber
+)
+
+// SetPathProber sets the prober used to validate system intent paths. With no
+// prober, paths are not checked.
+func SetPathProber(prober PathProber) {
+	pathProberMu.Lock()
+	defer pathProberMu.Unlock()
+	pathProber = prober
+}
+
+func getPathProber() PathProber {
+	pathProberMu.RLock()
+	defer pathProberMu.RUnlock()
+	return pathProber
+}
+
+// statScript prints "d <path>" or "f <path>" for each argument that exists.
+// It only uses shell builtins so that it runs in minimal images.
+const statScript = `for p in "$@"; do if [ -d "$p" ]; then echo "d $p"; elif [ -e "$p" ]; then echo "f $p"; fi; done`
+
+// globScript prints every path matching the pattern in $1. With IFS empty,
+// the pattern is expanded without being split at spaces.
+const globScript = `IFS=''; for p in $1; do if [ -e "$p" ]; then echo "$p"; fi; done`
+
+// probeTimeout bounds a single exec, so that a hung container cannot stall
+// the validation of a policy.
+const probeTimeout = 10 * time.Second
+
+// probeDeadline bounds all the execs of an intent.
+const probeDeadline = 30 * time.Second
+
+// ExecPathProber probes containers by running a shell in them through the
+// pods/exec subresource. It needs create on pods/exec in every namespace a
+// policy can select, which lets the controller run any command in those
+// pods; that grant is kept out of the default role.
+type ExecPathProber struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// NewExecPathProber returns an ExecPathProber for the cluster behind config.
+func NewExecPathProber(config *rest.Config) (*ExecPathProber, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create clientset")
+	}
+	return &ExecPathProber{config: config, clientset: clientset}, nil
+}
+
+func (p *ExecPathProber) Stat(ctx context.Context, pod *corev1.Pod, container string, paths []string) (map[string]PathInfo, error) {
+	out, err := p.exec(ctx, pod, container, append([]string{"sh", "-c", statScript, "sh"}, paths...))
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]PathInfo{}
+	for _, line := range strings.Split(out, "\n") {
+		kind, probed, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		found[probed] = PathInfo{IsDir: kind == "d"}
+	}
+	return found, nil
+}
+
+func (p *ExecPathProber) Glob(ctx context.Context, pod *corev1.Pod, container string, pattern string) ([]string, error) {
+	out, err := p.exec(ctx, pod, container, []string{"sh", "-c", globScript, "sh", pattern})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			matches = append(matches, line)
+		}
+	}
+	return matches, nil
+}
+
+func (p *ExecPathProber) exec(ctx context.Context, pod *corev1.Pod, container string, command []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req := p.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(p.config, "POST", req.URL())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create executor")
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", errors.Wrapf(ErrProbeUnavailable, "%s/%s[%s]: probe timed out after %s", pod.Namespace, pod.Name, container, probeTimeout)
+		}
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitStatus() < 126 {
+			return "", errors.Errorf("probe in %s/%s[%s] failed: %s", pod.Namespace, pod.Name, container, stderr.String())
+		}
+		// 126 and 127 mean there is no usable shell; other errors come from
+		// the runtime refusing to start the command at all.
+		return "", errors.Wrapf(ErrProbeUnavailable, "%s/%s[%s]: %v", pod.Namespace, pod.Name, container, err)
+	}
+	return stdout.String(), nil
+}
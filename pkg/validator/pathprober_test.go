@@ -0,0 +1,286 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+// fakePathProber serves paths from in-memory filesystems keyed by container
+// image.
+type fakePathProber struct {
+	Images map[string]map[string]PathInfo
+}
+
+func (p *fakePathProber) Stat(ctx context.Context, pod *corev1.Pod, container string, paths []string) (map[string]PathInfo, error) {
+	files, err := p.filesystem(pod, container)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]PathInfo{}
+	for _, probed := range paths {
+		if info, ok := files[probed]; ok {
+			found[probed] = info
+		}
+	}
+	return found, nil
+}
+
+func (p *fakePathProber) Glob(ctx context.Context, pod *corev1.Pod, container string, pattern string) ([]string, error) {
+	files, err := p.filesystem(pod, container)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for probed := range files {
+		if ok, _ := path.Match(pattern, probed); ok {
+			matches = append(matches, probed)
+		}
+	}
+	return matches, nil
+}
+
+// countingPathProber counts the containers probed through it.
+type countingPathProber struct {
+	PathProber
+	stats int
+}
+
+func (p *countingPathProber) Stat(ctx context.Context, pod *corev1.Pod, container string, paths []string) (map[string]PathInfo, error) {
+	p.stats++
+	return p.PathProber.Stat(ctx, pod, container, paths)
+}
+
+// hangingPathProber waits for every probe to be canceled.
+type hangingPathProber struct{}
+
+func (hangingPathProber) Stat(ctx context.Context, pod *corev1.Pod, container string, paths []string) (map[string]PathInfo, error) {
+	<-ctx.Done()
+	return nil, errors.Wrap(ErrProbeUnavailable, "timed out")
+}
+
+func (hangingPathProber) Glob(ctx context.Context, pod *corev1.Pod, container string, pattern string) ([]string, error) {
+	<-ctx.Done()
+	return nil, errors.Wrap(ErrProbeUnavailable, "timed out")
+}
+
+func (p *fakePathProber) filesystem(pod *corev1.Pod, container string) (map[string]PathInfo, error) {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != container {
+			continue
+		}
+		files, ok := p.Images[c.Image]
+		if !ok {
+			return nil, errors.Wrapf(ErrProbeUnavailable, "no filesystem for image %s", c.Image)
+		}
+		return files, nil
+	}
+	return nil, errors.Errorf("pod %s/%s has no container %s", pod.Namespace, pod.Name, container)
+}
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func newPod(name, image string, podLabels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: podLabels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func codes(results ResultList) []Code {
+	var got []Code
+	for _, result := range results {
+		got = append(got, result.Code)
+	}
+	return got
+}
+
+func TestValidateExecutableFilesAndDirectories(t *testing.T) {
+	prober := &fakePathProber{Images: map[string]map[string]PathInfo{
+		"nginx": {
+			"/bin/sh":           {},
+			"/etc/nginx":        {IsDir: true},
+			"/etc/nginx/a.conf": {},
+		},
+	}}
+	k8sClient := newFakeClient(t,
+		newPod("web", "nginx", map[string]string{"app": "web"}),
+		newPod("distroless", "distroless", map[string]string{"app": "static"}),
+	)
+	selectWeb := v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "web"}}}}
+
+	tests := []struct {
+		name     string
+		prober   PathProber
+		selector v1.Selector
+		resource v1.EventMatchResource
+		want     []Code
+	}{
+		{
+			name:     "existing path, dir and pattern",
+			prober:   prober,
+			selector: selectWeb,
+			resource: v1.EventMatchResource{Path: []string{"/bin/sh"}, Dir: "/etc/nginx", Pattern: []string{"/etc/nginx/*.conf"}},
+		},
+		{
+			name:     "missing path",
+			prober:   prober,
+			selector: selectWeb,
+			resource: v1.EventMatchResource{Path: []string{"/bin/bash"}},
+			want:     []Code{CodePathNotFound},
+		},
+		{
+			name:     "dir that is a file",
+			prober:   prober,
+			selector: selectWeb,
+			resource: v1.EventMatchResource{Dir: "/bin/sh"},
+			want:     []Code{CodeNotADirectory},
+		},
+		{
+			name:     "pattern without match",
+			prober:   prober,
+			selector: selectWeb,
+			resource: v1.EventMatchResource{Pattern: []string{"/etc/nginx/*.yaml"}},
+			want:     []Code{CodePatternNoMatch},
+		},
+		{
+			name:     "containers that cannot be probed",
+			prober:   prober,
+			selector: v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "static"}}}},
+			resource: v1.EventMatchResource{Path: []string{"/bin/bash"}},
+			want:     []Code{CodePathsNotProbed},
+		},
+		{
+			name:     "no selected pods",
+			prober:   prober,
+			selector: v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "none"}}}},
+			resource: v1.EventMatchResource{Path: []string{"/bin/bash"}},
+		},
+		{
+			name:     "no prober",
+			selector: selectWeb,
+			resource: v1.EventMatchResource{Path: []string{"/bin/bash"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPathProber(tt.prober)
+			defer SetPathProber(nil)
+
+			intentRequest := v1.IntentRequest{
+				Type:     "system",
+				Selector: tt.selector,
+				Rule:     v1.Rule{ActionPoint: []v1.ActionPoint{{SubType: "file", Resource: tt.resource}}},
+			}
+			results := validateExecutableFilesAndDirectories(context.Background(), k8sClient, field.NewPath("spec", "intentRequest").Index(0), "default", intentRequest)
+			if got := codes(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateExecutableFilesAndDirectoriesProbesEachImageOnce(t *testing.T) {
+	prober := &countingPathProber{PathProber: &fakePathProber{Images: map[string]map[string]PathInfo{
+		"nginx": {"/bin/sh": {}},
+		"envoy": {"/bin/sh": {}},
+	}}}
+	labels := map[string]string{"app": "web"}
+	pending := newPod("web-pending", "redis", labels)
+	pending.Status.Phase = corev1.PodPending
+	sidecar := newPod("web-2", "nginx", labels)
+	sidecar.Spec.Containers = append(sidecar.Spec.Containers, corev1.Container{Name: "proxy", Image: "envoy"})
+	k8sClient := newFakeClient(t, newPod("web-0", "nginx", labels), newPod("web-1", "nginx", labels), sidecar, pending)
+
+	SetPathProber(prober)
+	defer SetPathProber(nil)
+
+	intentRequest := v1.IntentRequest{
+		Type:     "system",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: labels}}},
+		Rule:     v1.Rule{ActionPoint: []v1.ActionPoint{{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/bin/sh"}}}}},
+	}
+	results := validateExecutableFilesAndDirectories(context.Background(), k8sClient, field.NewPath("spec", "intentRequest").Index(0), "default", intentRequest)
+	if len(results) > 0 {
+		t.Errorf("got %v, want no results", results)
+	}
+	if prober.stats != 2 {
+		t.Errorf("probed %d containers, want one per running image", prober.stats)
+	}
+}
+
+func TestValidateExecutableFilesAndDirectoriesDeadline(t *testing.T) {
+	k8sClient := newFakeClient(t, newPod("web", "nginx", map[string]string{"app": "web"}))
+	SetPathProber(hangingPathProber{})
+	defer SetPathProber(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	intentRequest := v1.IntentRequest{
+		Type:     "system",
+		Selector: v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "web"}}}},
+		Rule:     v1.Rule{ActionPoint: []v1.ActionPoint{{SubType: "file", Resource: v1.EventMatchResource{Path: []string{"/bin/sh"}}}}},
+	}
+	results := validateExecutableFilesAndDirectories(ctx, k8sClient, field.NewPath("spec", "intentRequest").Index(0), "default", intentRequest)
+	if got := codes(results); !reflect.DeepEqual(got, []Code{CodePathsNotProbed}) {
+		t.Errorf("got %v, want %v", got, []Code{CodePathsNotProbed})
+	}
+}
+
+func TestProbeScripts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app.log", "my app.log", "app.conf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(script string, args ...string) []string {
+		out, err := exec.Command("sh", append([]string{"-c", script, "sh"}, args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+
+	glob := run(globScript, filepath.Join(dir, "*.log"))
+	if want := []string{filepath.Join(dir, "app.log"), filepath.Join(dir, "my app.log")}; !reflect.DeepEqual(glob, want) {
+		t.Errorf("glob = %q, want %q", glob, want)
+	}
+	glob = run(globScript, filepath.Join(dir, "my *.log"))
+	if want := []string{filepath.Join(dir, "my app.log")}; !reflect.DeepEqual(glob, want) {
+		t.Errorf("glob with a space = %q, want %q", glob, want)
+	}
+
+	stat := run(statScript, dir, filepath.Join(dir, "my app.log"), filepath.Join(dir, "missing"))
+	if want := []string{"d " + dir, "f " + filepath.Join(dir, "my app.log")}; !reflect.DeepEqual(stat, want) {
+		t.Errorf("stat = %q, want %q", stat, want)
+	}
+}
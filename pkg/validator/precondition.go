@@ -5,178 +5,219 @@ package validator
 import (
 	"context"
 	"fmt"
-	"net"
-	"net/http"
-	"os"
 	"os/exec"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	v1 "github.com/cclab-inu/KubeAegis/api/v1"
 	processor "github.com/cclab-inu/KubeAegis/pkg/adapter/processor"
+	"github.com/cclab-inu/KubeAegis/pkg/validator/catalog"
+	"github.com/cclab-inu/KubeAegis/pkg/validator/registry"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func ValidatePrecondition(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy) []error {
-	var errs []error
+func ValidatePrecondition(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy) ResultList {
+	var results ResultList
 
-	for _, intentRequest := range kap.Spec.IntentRequest {
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		path := field.NewPath("spec", "intentRequest").Index(i)
 		switch intentRequest.Type {
 		case "network":
-			if err := validateNetworkIntentRequest(ctx, k8sClient, intentRequest); err != nil {
-				errs = append(errs, err)
-			}
+			results = append(results, validateNetworkIntentRequest(ctx, k8sClient, path, kap.Namespace, selectsClusterWide(kap), intentRequest)...)
 		case "system":
-			if err := validateSystemIntentRequest(ctx, k8sClient, kap); err != nil {
-				errs = append(errs, err)
-			}
+			results = append(results, validateSystemIntentRequest(ctx, k8sClient, path, kap.Namespace, intentRequest)...)
 		case "cluster":
-			if err := validateClusterIntentRequest(ctx, k8sClient, intentRequest); err != nil {
-				errs = append(errs, err)
-			}
+			results = append(results, validateClusterIntentRequest(ctx, k8sClient, path, kap.Namespace, intentRequest)...)
 		}
 	}
 
-	return errs
+	return results
 }
 
-func validateNetworkIntentRequest(ctx context.Context, k8sClient client.Client, intentRequest v1.IntentRequest) error {
-	var matchLabels map[string]string
-	var err error
-	if len(intentRequest.Selector.CEL) > 0 {
-		namespace := ""
-		matchLabels, err = processor.ProcessCEL(ctx, k8sClient, namespace, intentRequest.Selector.CEL)
-		if err != nil {
-			return err
-		}
-	} else if len(intentRequest.Selector.Match) > 0 {
-		matchLabels, err = processor.ProcessMatchLabels(intentRequest.Selector.Match)
-		if err != nil {
-			return err
-		}
+func validateNetworkIntentRequest(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string, clusterWide bool, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	selector := intentRequest.Selector
+	selectorPath := path.Child("selector")
+	nodes, err := processor.SelectsNodes(selector)
+	if err != nil {
+		results = append(results, Errorf(selectorPath, CodeSelectorInvalid, "%v", err))
+	} else if len(selector.CEL) == 0 && len(selector.Match) == 0 && (!clusterWide || selector.NamespaceSelector == nil) {
+		// Cluster-wide policies may select whole namespaces.
+		results = append(results, Errorf(selectorPath, CodeSelectorMissing, "no matches found in the selector"))
 	}
 
-	if err := validatePortListening(ctx, k8sClient, intentRequest, matchLabels); err != nil {
-		return err
+	if len(results) == 0 {
+		// Nodes declare no ports, so only the rules themselves are checked.
+		var pods []corev1.Pod
+		if !nodes {
+			// Cluster-wide policies look for the pods in every namespace.
+			if clusterWide {
+				namespace = corev1.NamespaceAll
+				pods, err = selectedPods(ctx, k8sClient, namespace, selector)
+			} else {
+				var scopeResults ResultList
+				pods, scopeResults, err = namespacedPods(ctx, k8sClient, selectorPath, namespace, selector)
+				results = append(results, scopeResults...)
+			}
+			if err != nil {
+				results = append(results, Errorf(selectorPath, CodeSelectorInvalid, "%v", err))
+			} else if len(pods) == 0 {
+				results = append(results, Errorf(selectorPath, CodeSelectorNoMatch, "no pods found matching the selector"))
+			}
+		}
+		if err == nil {
+			results = append(results, validatePortListening(ctx, k8sClient, path, intentRequest, pods)...)
+		}
 	}
+	results = append(results, validateNetworkTargets(ctx, k8sClient, path, intentRequest)...)
+	results = append(results, validateHTTPPoints(path, intentRequest)...)
 
-	if err := validateCIDR(intentRequest); err != nil {
-		return err
-	}
+	return results
+}
 
-	return nil
+func validateSystemIntentRequest(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	results = append(results, validateExecutableScriptsAndCommands(path, intentRequest)...)
+	results = append(results, validateExecutableFilesAndDirectories(ctx, k8sClient, path, namespace, intentRequest)...)
+	results = append(results, validateSystemCalls(ctx, k8sClient, path, intentRequest)...)
+	return results
 }
 
-func validateSystemIntentRequest(ctx context.Context, k8sClient client.Client, kap *v1.KubeAegisPolicy) error {
-	for _, intent := range kap.Spec.IntentRequest {
-		if err := validateExecutableScriptsAndCommands(intent); err != nil {
-			return err
-		}
+func validateClusterIntentRequest(ctx context.Context, k8sClient client.Client, path *field.Path, kapNamespace string, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
 
-		if err := validateExecutableFilesAndDirectories(intent); err != nil {
-			return err
+	if len(intentRequest.Selector.Match) > 0 && hasPodDetails(intentRequest) {
+		pods, err := selectedPods(ctx, k8sClient, kapNamespace, intentRequest.Selector)
+		if err != nil {
+			return ResultList{Errorf(path.Child("selector"), CodeSelectorInvalid, "%v", err)}
 		}
-
-		if err := validateSystemCalls(intent); err != nil {
-			return err
+		for p, point := range intentRequest.Rule.ActionPoint {
+			detailsPath := path.Child("rule", "actionPoint").Index(p).Child("resource", "details")
+			for d, detailMap := range point.Resource.Details {
+				switch point.Resource.Kind {
+				case "annotations":
+					results = append(results, validatePodAnnotations(detailsPath.Index(d), pods, detailMap)...)
+				case "label":
+					results = append(results, validatePodLabels(detailsPath.Index(d), pods, detailMap)...)
+				}
+			}
 		}
-
 	}
+	results = append(results, validateImages(ctx, k8sClient, path, kapNamespace, intentRequest)...)
 
-	return nil
-
+	return results
 }
 
-func validateClusterIntentRequest(ctx context.Context, k8sClient client.Client, intentRequest v1.IntentRequest) error {
-	var errs []error
-
+// hasPodDetails reports whether the intent checks the annotations or labels of
+// the pods it selects.
+func hasPodDetails(intentRequest v1.IntentRequest) bool {
 	for _, point := range intentRequest.Rule.ActionPoint {
-		matchLabels := intentRequest.Selector.Match[0].MatchLabels
-		namespace := intentRequest.Selector.Match[0].Namespace
-		for _, detailMap := range point.Resource.Details {
-			switch point.Resource.Kind {
-			case "annotations":
-				errs = append(errs, validatePodAnnotations(ctx, k8sClient, matchLabels, namespace, detailMap)...)
-			case "label":
-				errs = append(errs, validatePodLabels(ctx, k8sClient, matchLabels, namespace, detailMap)...)
-			}
+		if len(point.Resource.Details) > 0 && (point.Resource.Kind == "annotations" || point.Resource.Kind == "label") {
+			return true
 		}
 	}
-	if err := validateImages(intentRequest); err != nil {
-		errs = append(errs, err)
-	}
-	if len(errs) > 0 {
-		return fmt.Errorf("found errors: %v", errs)
-	}
+	return false
+}
 
-	return nil
+// networkRule is a from or to rule together with its field path.
+type networkRule struct {
+	path    *field.Path
+	rule    v1.NetPolDetail
+	ingress bool
 }
 
-func validatePortListening(ctx context.Context, k8sClient client.Client, intentRequest v1.IntentRequest, matchLabels map[string]string) error {
-	var namespace string
-	if len(intentRequest.Selector.Match) > 0 {
-		namespace = intentRequest.Selector.Match[0].Namespace
-	} else {
-		namespace = "default"
+func networkRules(path *field.Path, intentRequest v1.IntentRequest) []networkRule {
+	var rules []networkRule
+	for i, rule := range intentRequest.Rule.To {
+		rules = append(rules, networkRule{path: path.Child("rule", "to").Index(i), rule: rule})
 	}
-
-	if len(intentRequest.Selector.Match) == 0 && len(intentRequest.Selector.CEL) == 0 {
-		return errors.New("no matches found in the selector")
+	for i, rule := range intentRequest.Rule.From {
+		rules = append(rules, networkRule{path: path.Child("rule", "from").Index(i), rule: rule, ingress: true})
 	}
-	rules := append(intentRequest.Rule.To, intentRequest.Rule.From...)
+	return rules
+}
 
-	// Check if netPolDetails slice is empty
+// validatePortListening checks the ports and protocols of the rules, and that
+// the selected pods listen on the ports.
+func validatePortListening(ctx context.Context, k8sClient client.Client, path *field.Path, intentRequest v1.IntentRequest, pods []corev1.Pod) ResultList {
+	rules := networkRules(path, intentRequest)
 	if len(rules) == 0 {
-		return errors.New("rules slice is empty")
+		return ResultList{Errorf(path.Child("rule"), CodeRuleMissing, "rule has neither from nor to entries")}
 	}
 
-	for _, rule := range rules {
-		if rule.Port == "" {
-			return errors.New("port is empty")
+	// Declaring container ports is optional, so pods that declare none cannot
+	// be checked.
+	var results ResultList
+	declared, hasPorts := false, false
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			declared = declared || len(container.Ports) > 0
 		}
+	}
+	for _, r := range rules {
+		hasPorts = hasPorts || r.rule.Port != ""
+	}
+	if len(pods) > 0 && hasPorts && !declared {
+		results = append(results, Warningf(path.Child("selector"), CodePortsNotDeclared, "the selected pods declare no container ports; ports were not checked"))
+	}
 
-		expectedPort, err := strconv.ParseInt(rule.Port, 10, 32)
-		if err != nil {
-			return errors.Errorf("error parsing expected port: %v", err)
+	for _, r := range rules {
+		rule := r.rule
+		if rule.Kind == "icmp" {
+			if rule.Protocol != "" && rule.Protocol != "ICMP" && rule.Protocol != "ICMPv6" {
+				results = append(results, Errorf(r.path.Child("protocol"), CodeProtocolUnsupported, "invalid protocol: %s. Must be one of [ICMP ICMPv6]", rule.Protocol))
+			}
+		} else if rule.Kind == "protocol" || rule.Port != "" || rule.Protocol != "" {
+			if err := validateProtocol(string(rule.Protocol)); err != nil {
+				results = append(results, Errorf(r.path.Child("protocol"), CodeProtocolUnsupported, "%v", err))
+			}
 		}
 
-		var pods corev1.PodList
-		listOpts := []client.ListOption{
-			client.InNamespace(namespace),
-			client.MatchingLabels(matchLabels),
+		// icmp and protocol rules match traffic by protocol alone.
+		if rule.Kind == "icmp" || rule.Kind == "protocol" {
+			if rule.Port != "" {
+				results = append(results, Errorf(r.path.Child("port"), CodePortInvalid, "%s rules cannot have a port", rule.Kind))
+			}
+			continue
+		}
+		// Peer rules without a port apply to every port of the peer; only a
+		// port rule needs one.
+		if rule.Port == "" {
+			if rule.Kind == "port" {
+				results = append(results, Errorf(r.path.Child("port"), CodePortMissing, "port is empty"))
+			}
+			continue
 		}
-		if err := k8sClient.List(ctx, &pods, listOpts...); err != nil {
-			return errors.Errorf("error fetching pods: %v", err)
+		specs, err := processor.ParsePorts(rule.Port)
+		if err != nil {
+			results = append(results, Errorf(r.path.Child("port"), CodePortInvalid, "%v", err))
+			continue
 		}
-		if len(pods.Items) == 0 {
-			return errors.Errorf("no pods found matching the selector in namespace %s", namespace)
+		if !declared {
+			continue
 		}
 
-		portFound := false
-		for _, pod := range pods.Items {
-			for _, container := range pod.Spec.Containers {
-				for _, port := range container.Ports {
-					if port.ContainerPort == int32(expectedPort) && strings.EqualFold(string(port.Protocol), string(rule.Protocol)) {
-						portFound = true
-						break
-					}
-				}
-				if portFound {
-					break
-				}
+		for _, spec := range specs {
+			ports, err := processor.ContainerPortsFor(ctx, k8sClient, pods, spec, string(rule.Protocol))
+			if err != nil {
+				results = append(results, Errorf(r.path.Child("port"), CodeLookupFailed, "%v", err))
+				continue
 			}
-			if !portFound {
-				return errors.Errorf("no containers found in namespace %s with labels %v listening on the expected port %d with protocol %s", namespace, matchLabels, expectedPort, string(rule.Protocol))
+			if len(ports) == 0 {
+				results = append(results, Errorf(r.path.Child("port"), CodePortNotListening, "none of the selected pods %v listens on port %s with protocol %s", podNames(pods), spec, string(rule.Protocol)))
 			}
 		}
-		if err := validateProtocol(string(rule.Protocol)); err != nil {
-			return err
-		}
 	}
 
-	return nil
+	return results
 }
 
 func validateProtocol(protocol string) error {
@@ -189,72 +230,304 @@ func validateProtocol(protocol string) error {
 	return errors.Errorf("invalid protocol: %s. Must be one of %v", protocol, validProtocols)
 }
 
-func validateCIDR(intentRequest v1.IntentRequest) error {
-	for _, rule := range append(intentRequest.Rule.To, intentRequest.Rule.From...) {
-		for _, cidr := range rule.Labels {
-			if _, _, err := net.ParseCIDR(cidr); err != nil {
-				return errors.New("invalid CIDR: " + cidr)
+// validateSystemCalls checks the syscalls, capabilities and protocols of a
+// system intent against the catalog. Syscalls have to exist on every node
+// architecture in the cluster.
+func validateSystemCalls(ctx context.Context, k8sClient client.Client, path *field.Path, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	for p, point := range intentRequest.Rule.ActionPoint {
+		resourcePath := path.Child("rule", "actionPoint").Index(p).Child("resource")
+		switch point.SubType {
+		case "kprobe", "tracepoint":
+			if point.Resource.Syscall == "" {
+				continue
+			}
+			for _, err := range catalog.CheckSyscalls(splitList(point.Resource.Syscall), nodeArchitectures(ctx, k8sClient)) {
+				results = append(results, syscallResult(resourcePath.Child("syscall"), err))
+			}
+		case "syscalls":
+			for _, err := range catalog.CheckSyscalls(point.Resource.Args, nodeArchitectures(ctx, k8sClient)) {
+				argPath := resourcePath.Child("args")
+				var catalogErr *catalog.Error
+				if errors.As(err, &catalogErr) {
+					argPath = argPath.Index(catalogErr.Index)
+				}
+				results = append(results, syscallResult(argPath, err))
+			}
+		case "capabilities":
+			for i, capability := range point.Resource.Args {
+				if err := catalog.CheckCapability(capability); err != nil {
+					results = append(results, Errorf(resourcePath.Child("args").Index(i), CodeCapabilityUnknown, "%v", err))
+				}
+			}
+		case "network":
+			for _, protocol := range splitList(point.Resource.Protocol) {
+				if err := catalog.CheckProtocol(protocol); err != nil {
+					results = append(results, Errorf(resourcePath.Child("protocol"), CodeProtocolUnknown, "%v", err))
+				}
 			}
 		}
 	}
 
-	return nil
+	return results
 }
 
-func validateSystemCalls(intentRequest v1.IntentRequest) error {
-	knownSystemCalls := []string{"open", "read", "write", "close", "unlink", "rmdir"}
-	for _, point := range intentRequest.Rule.ActionPoint {
-		if point.SubType == "kprobe" || point.SubType == "tracepoint" {
-			syscalls := strings.Split(point.Resource.Syscall, ",")
-			for _, call := range syscalls {
-				if !contains(knownSystemCalls, strings.TrimSpace(call)) {
-					return errors.Errorf("invalid system call: %s", call)
-				}
-			}
+func syscallResult(path *field.Path, err error) Result {
+	var catalogErr *catalog.Error
+	if errors.As(err, &catalogErr) && !catalogErr.Unknown {
+		return Errorf(path, CodeSyscallUnavailable, "%v", err)
+	}
+	return Errorf(path, CodeSyscallUnknown, "%v", err)
+}
+
+// nodeArchitectures returns the architectures of the cluster's nodes, or nil
+// when they cannot be listed.
+func nodeArchitectures(ctx context.Context, k8sClient client.Client) []string {
+	var nodes corev1.NodeList
+	if err := k8sClient.List(ctx, &nodes); err != nil {
+		return nil
+	}
+
+	var arches []string
+	for _, node := range nodes.Items {
+		if arch := node.Labels[corev1.LabelArchStable]; arch != "" && !contains(arches, arch) {
+			arches = append(arches, arch)
 		}
 	}
+	return arches
+}
 
-	return nil
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-func validateExecutableFilesAndDirectories(intentRequest v1.IntentRequest) error {
-	for _, point := range intentRequest.Rule.ActionPoint {
-		for _, path := range point.Resource.Path {
-			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
-				return errors.Errorf("file or directory does not exist: %s", path)
+// probeTarget is a path, dir or pattern of a system intent together with its
+// field path.
+type probeTarget struct {
+	path  *field.Path
+	value string
+}
+
+// probeContainer is a container to probe for the paths of an intent.
+type probeContainer struct {
+	pod       *corev1.Pod
+	container string
+}
+
+// probeTargets picks one running container for every image of the pods, since
+// the replicas of an image share its filesystem.
+func probeTargets(pods []corev1.Pod) []probeContainer {
+	seen := map[string]bool{}
+	var targets []probeContainer
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if !seen[container.Image] {
+				seen[container.Image] = true
+				targets = append(targets, probeContainer{pod: pod, container: container.Name})
 			}
 		}
 	}
-	return nil
+	return targets
 }
 
-func validateExecutableScriptsAndCommands(intentRequest v1.IntentRequest) error {
-	for _, point := range intentRequest.Rule.ActionPoint {
-		if point.SubType == "uprobes" {
-			if point.Resource.Symbol == "" {
-				return errors.Errorf("missing required symbol for uprobes point")
+// validateExecutableFilesAndDirectories checks the paths, directories and
+// patterns of a system intent against the images of the pods it selects,
+// probing one running container per image. A path must exist in at least one
+// of them. Containers that cannot be probed are skipped, and nothing is
+// checked when no PathProber is set or probing takes too long.
+func validateExecutableFilesAndDirectories(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string, intentRequest v1.IntentRequest) ResultList {
+	prober := getPathProber()
+	if prober == nil {
+		return nil
+	}
+
+	var paths, dirs, patterns []probeTarget
+	var statPaths []string
+	for p, point := range intentRequest.Rule.ActionPoint {
+		resourcePath := path.Child("rule", "actionPoint").Index(p).Child("resource")
+		for i, value := range point.Resource.Path {
+			paths = append(paths, probeTarget{path: resourcePath.Child("path").Index(i), value: value})
+			statPaths = append(statPaths, value)
+		}
+		if point.Resource.Dir != "" {
+			dirs = append(dirs, probeTarget{path: resourcePath.Child("dir"), value: point.Resource.Dir})
+			statPaths = append(statPaths, point.Resource.Dir)
+		}
+		for i, value := range point.Resource.Pattern {
+			patterns = append(patterns, probeTarget{path: resourcePath.Child("pattern").Index(i), value: value})
+		}
+	}
+	if len(paths) == 0 && len(dirs) == 0 && len(patterns) == 0 {
+		return nil
+	}
+
+	pods, err := selectedPods(ctx, k8sClient, namespace, intentRequest.Selector)
+	if err != nil {
+		return ResultList{Errorf(path.Child("selector"), CodeLookupFailed, "%v", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeDeadline)
+	defer cancel()
+	notProbed := Warningf(path.Child("rule", "actionPoint"), CodePathsNotProbed, "probing the selected containers did not finish in %s; paths were not checked", probeDeadline)
+
+	probed := false
+	found := map[string]PathInfo{}
+	matched := map[string]bool{}
+	for _, target := range probeTargets(pods) {
+		stats, err := prober.Stat(ctx, target.pod, target.container, statPaths)
+		if ctx.Err() != nil {
+			return ResultList{notProbed}
+		}
+		if errors.Is(err, ErrProbeUnavailable) {
+			continue
+		}
+		if err != nil {
+			return ResultList{Errorf(path.Child("rule", "actionPoint"), CodeLookupFailed, "%v", err)}
+		}
+		probed = true
+		for p, info := range stats {
+			found[p] = info
+		}
+
+		for _, pattern := range patterns {
+			if matched[pattern.value] {
+				continue
+			}
+			matches, err := prober.Glob(ctx, target.pod, target.container, pattern.value)
+			if ctx.Err() != nil {
+				return ResultList{notProbed}
+			}
+			if err != nil {
+				return ResultList{Errorf(pattern.path, CodeLookupFailed, "%v", err)}
 			}
+			matched[pattern.value] = len(matches) > 0
 		}
 	}
-	return nil
+	if !probed {
+		if len(pods) > 0 {
+			return ResultList{Warningf(path.Child("rule", "actionPoint"), CodePathsNotProbed, "none of the selected containers can be probed; paths were not checked")}
+		}
+		return nil
+	}
+
+	var results ResultList
+	for _, p := range paths {
+		if _, ok := found[p.value]; !ok {
+			results = append(results, Errorf(p.path, CodePathNotFound, "file or directory does not exist in the selected pods: %s", p.value))
+		}
+	}
+	for _, dir := range dirs {
+		info, ok := found[dir.value]
+		if !ok {
+			results = append(results, Errorf(dir.path, CodePathNotFound, "directory does not exist in the selected pods: %s", dir.value))
+		} else if !info.IsDir {
+			results = append(results, Errorf(dir.path, CodeNotADirectory, "not a directory: %s", dir.value))
+		}
+	}
+	for _, pattern := range patterns {
+		if !matched[pattern.value] {
+			results = append(results, Errorf(pattern.path, CodePatternNoMatch, "pattern matches no file in the selected pods: %s", pattern.value))
+		}
+	}
+	return results
 }
 
-// validateFileExistenceAndReadOnly checks if the specified files exist and are set to read-only.
-func validateFileExistenceAndReadOnly(intentRequest v1.IntentRequest) error {
-	for _, point := range intentRequest.Rule.ActionPoint {
-		for _, filePath := range point.Resource.Path {
-			// 파일 존재 여부 확인
-			fileInfo, err := os.Stat(filePath)
-			if errors.Is(err, os.ErrNotExist) {
-				return errors.Errorf("file does not exist: %s", filePath)
+// namespacedPods returns the pods the network engines select for a
+// Namespace scope policy. Their policies are namespaced, so a namespaceSelector
+// only narrows the policy's own namespace, and other namespaces it matches
+// are reported.
+func namespacedPods(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string, selector v1.Selector) ([]corev1.Pod, ResultList, error) {
+	if selector.NamespaceSelector == nil {
+		pods, err := selectedPods(ctx, k8sClient, namespace, selector)
+		return pods, nil, err
+	}
+
+	namespaces, err := processor.SelectNamespaces(ctx, k8sClient, namespace, selector)
+	if processor.IsNoMatch(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var results ResultList
+	var others []string
+	ownSelected := false
+	for _, ns := range namespaces {
+		if ns == namespace {
+			ownSelected = true
+		} else {
+			others = append(others, ns)
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		results = append(results, Warningf(path.Child("namespaceSelector"), CodeNamespaceNotEnforced,
+			"namespaces %s match the namespaceSelector, but network policies of scope %s only apply to namespace %s; use scope %s to select them",
+			strings.Join(others, ", "), v1.ScopeNamespace, namespace, v1.ScopeCluster))
+	}
+	if !ownSelected {
+		return nil, results, nil
+	}
+
+	selector.NamespaceSelector = nil
+	pods, err := selectedPods(ctx, k8sClient, namespace, selector)
+	return pods, results, err
+}
+
+// selectedPods returns the pods a Selector applies to. A selector that
+// matches nothing in the cluster selects no pods; ValidateExistence reports
+// why.
+func selectedPods(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) ([]corev1.Pod, error) {
+	compiled, err := processor.CompileSelector(ctx, k8sClient, namespace, selector)
+	if processor.IsNoMatch(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := processor.SelectNamespaces(ctx, k8sClient, namespace, selector)
+	if processor.IsNoMatch(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for _, ns := range namespaces {
+		var podList corev1.PodList
+		if err := k8sClient.List(ctx, &podList, client.InNamespace(ns)); err != nil {
+			return nil, fmt.Errorf("error listing pods: %v", err)
+		}
+		for _, pod := range podList.Items {
+			if compiled.Matches(pod.Labels) {
+				pods = append(pods, pod)
 			}
+		}
+	}
+	return pods, nil
+}
 
-			if !fileInfo.Mode().IsRegular() || (fileInfo.Mode().Perm()&0222) != 0 {
-				return errors.Errorf("file is not read-only: %s", filePath)
+func validateExecutableScriptsAndCommands(path *field.Path, intentRequest v1.IntentRequest) ResultList {
+	var results ResultList
+	for p, point := range intentRequest.Rule.ActionPoint {
+		if point.SubType == "uprobes" {
+			if point.Resource.Symbol == "" {
+				results = append(results, Errorf(path.Child("rule", "actionPoint").Index(p).Child("resource", "symbol"), CodeSymbolMissing, "missing required symbol for uprobes point"))
 			}
 		}
 	}
-	return nil
+	return results
 }
 
 // RunCMD executes a given command and returns its output.
@@ -268,81 +541,212 @@ func RunCMD(cmd *exec.Cmd) error {
 	return nil
 }
 
-func validateImages(intentRequest v1.IntentRequest) error {
-	for _, point := range intentRequest.Rule.ActionPoint {
+// skipRegistryLookups is set when the images of verifyImage points must not be
+// looked up in their registries.
+var skipRegistryLookups atomic.Bool
+
+// SetRegistryLookups turns the registry lookups of verifyImage points on or
+// off. They are on by default.
+func SetRegistryLookups(enabled bool) {
+	skipRegistryLookups.Store(!enabled)
+}
+
+var (
+	insecureRegistriesMu sync.RWMutex
+	insecureRegistries   []string
+)
+
+// SetInsecureRegistries sets the registry hosts, such as a local registry,
+// that verifyImage points reach over plain HTTP.
+func SetInsecureRegistries(hosts []string) {
+	insecureRegistriesMu.Lock()
+	defer insecureRegistriesMu.Unlock()
+	insecureRegistries = hosts
+}
+
+func getInsecureRegistries() []string {
+	insecureRegistriesMu.RLock()
+	defer insecureRegistriesMu.RUnlock()
+	return insecureRegistries
+}
+
+func validateImages(ctx context.Context, k8sClient client.Client, path *field.Path, namespace string, intentRequest v1.IntentRequest) ResultList {
+	var points []int
+	for p, point := range intentRequest.Rule.ActionPoint {
 		if point.SubType == "verifyImage" {
-			if err := verifyImage(point.Resource); err != nil {
-				return err
-			}
+			points = append(points, p)
 		}
 	}
-	return nil
+	if len(points) == 0 {
+		return nil
+	}
+
+	// Without registry access only the references themselves are checked.
+	var registryClient *registry.Client
+	if !skipRegistryLookups.Load() {
+		keychain, err := pullSecretKeychain(ctx, k8sClient, namespace, intentRequest.Selector)
+		if err != nil {
+			return ResultList{Errorf(path.Child("selector"), CodeLookupFailed, "%v", err)}
+		}
+		registryClient = &registry.Client{Keychain: keychain, PlainHTTP: getInsecureRegistries()}
+	}
+
+	var results ResultList
+	for _, p := range points {
+		resourcePath := path.Child("rule", "actionPoint").Index(p).Child("resource")
+		results = append(results, verifyImage(ctx, registryClient, resourcePath, intentRequest.Rule.ActionPoint[p].Resource)...)
+	}
+	return results
 }
 
-func verifyImage(resource v1.EventMatchResource) error {
-	for _, detailMap := range resource.Details {
-		for image, _ := range detailMap {
-			resp, err := http.Get("https://index.docker.io/v2/" + image)
+// verifyImage checks that the images of a verifyImage point exist and carry a
+// signature for each of its keys.
+func verifyImage(ctx context.Context, registryClient *registry.Client, path *field.Path, resource v1.EventMatchResource) ResultList {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	keys := resource.Keys
+	keysPath := path.Child("keys")
+	if len(keys) == 0 && len(resource.Keyless) > 0 {
+		// Keyless identities are checked by the engine; only require a signature.
+		keys = []string{""}
+		keysPath = path.Child("keyless")
+	}
+
+	var results ResultList
+	for d, detailMap := range resource.Details {
+		for image := range detailMap {
+			imagePath := path.Child("details").Index(d).Key(image)
+			ref, err := registry.ParseReference(image)
 			if err != nil {
-				return errors.Errorf("error checking image existence: %v", err)
+				results = append(results, Errorf(imagePath, CodeImageInvalid, "%v", err))
+				continue
+			}
+
+			if registryClient == nil {
+				continue
+			}
+
+			if ref.IsPattern() {
+				// Only a literal repository with a tag pattern can be checked.
+				if strings.ContainsAny(ref.Repository, "*?") {
+					results = append(results, Warningf(imagePath, CodeImageNotChecked, "image %s names a repository pattern and cannot be looked up", image))
+					continue
+				}
+				ref.Tag = ""
+				if err := registryClient.RepositoryExists(ctx, ref); err != nil {
+					results = append(results, imageResult(imagePath, image, err))
+				}
+				continue
 			}
-			defer resp.Body.Close()
 
-			if resp.StatusCode != http.StatusOK {
-				return errors.Errorf("image %s does not exist", image)
+			digest, err := registryClient.Resolve(ctx, ref)
+			if err != nil {
+				results = append(results, imageResult(imagePath, image, err))
+				continue
+			}
+			for k, key := range keys {
+				keyPath := keysPath
+				if key != "" {
+					keyPath = keysPath.Index(k)
+				}
+				if err := registryClient.VerifySignature(ctx, ref, digest, key); err != nil {
+					results = append(results, imageResult(keyPath, image, err))
+				}
 			}
 		}
 	}
-	return nil
+	return results
+}
+
+// imageResult classifies a registry error. Registries that cannot be reached
+// only produce a warning, so that an outage does not block policies.
+func imageResult(path *field.Path, image string, err error) Result {
+	switch {
+	case errors.Is(err, registry.ErrNotFound):
+		return Errorf(path, CodeImageNotFound, "image %s does not exist: %v", image, err)
+	case errors.Is(err, registry.ErrNoSignature):
+		return Errorf(path, CodeImageNotSigned, "image %s: %v", image, err)
+	}
+	return Warningf(path, CodeRegistryError, "image %s could not be checked: %v", image, err)
 }
 
-func validatePodAnnotations(ctx context.Context, k8sClient client.Client, matchLabels map[string]string, namespace string, details map[string]string) []error {
-	var errs []error
-	var pods corev1.PodList
-	listOpts := []client.ListOption{
-		client.InNamespace(namespace),
-		client.MatchingLabels(matchLabels),
+// pullSecretKeychain collects the registry credentials of the imagePullSecrets
+// used by the selected pods, or by the default ServiceAccount when no pod is
+// selected yet.
+func pullSecretKeychain(ctx context.Context, k8sClient client.Client, namespace string, selector v1.Selector) (registry.Keychain, error) {
+	refs := map[types.NamespacedName]bool{}
+	pods, err := selectedPods(ctx, k8sClient, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		for _, secret := range pod.Spec.ImagePullSecrets {
+			refs[types.NamespacedName{Namespace: pod.Namespace, Name: secret.Name}] = true
+		}
+	}
+	if len(pods) == 0 && namespace != "" {
+		var serviceAccount corev1.ServiceAccount
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "default"}, &serviceAccount)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error getting default service account: %v", err)
+		}
+		for _, secret := range serviceAccount.ImagePullSecrets {
+			refs[types.NamespacedName{Namespace: namespace, Name: secret.Name}] = true
+		}
 	}
-	if err := k8sClient.List(ctx, &pods, listOpts...); err != nil {
-		errs = append(errs, fmt.Errorf("error fetching pods: %v", err))
-		return errs
+
+	var secrets []corev1.Secret
+	for ref := range refs {
+		var secret corev1.Secret
+		if err := k8sClient.Get(ctx, ref, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting pull secret %s: %v", ref, err)
+		}
+		secrets = append(secrets, secret)
 	}
-	for _, pod := range pods.Items {
+	return registry.KeychainFromSecrets(secrets)
+}
+
+func validatePodAnnotations(path *field.Path, pods []corev1.Pod, details map[string]string) ResultList {
+	var results ResultList
+	for _, pod := range pods {
 		for key, expectedValue := range details {
 			if value, ok := pod.Annotations[key]; ok {
 				if value != expectedValue {
-					errs = append(errs, fmt.Errorf("pod %s in namespace %s does not comply with the annotation %s: expected %s, got %s", pod.Name, pod.Namespace, key, expectedValue, value))
+					results = append(results, Errorf(path.Key(key), CodeAnnotationMismatch, "pod %s in namespace %s does not comply with the annotation %s: expected %s, got %s", pod.Name, pod.Namespace, key, expectedValue, value))
 				}
 			}
 		}
 	}
-	return errs
+	return results
 }
 
-func validatePodLabels(ctx context.Context, k8sClient client.Client, matchLabels map[string]string, namespace string, labels map[string]string) []error {
-	var errs []error
-	var pods corev1.PodList
-	listOpts := []client.ListOption{
-		client.InNamespace(namespace),
-		client.MatchingLabels(matchLabels),
-	}
-	if err := k8sClient.List(ctx, &pods, listOpts...); err != nil {
-		errs = append(errs, fmt.Errorf("error fetching pods: %v", err))
-		return errs
-	}
-
-	for _, pod := range pods.Items {
+func validatePodLabels(path *field.Path, pods []corev1.Pod, labels map[string]string) ResultList {
+	var results ResultList
+	for _, pod := range pods {
 		for key, expectedValue := range labels {
 			if value, ok := pod.Labels[key]; ok {
 				if value != expectedValue {
-					errs = append(errs, fmt.Errorf("pod %s in namespace %s does not comply with the label %s: expected %s, got %s", pod.Name, pod.Namespace, key, expectedValue, value))
+					results = append(results, Errorf(path.Key(key), CodeLabelMismatch, "pod %s in namespace %s does not comply with the label %s: expected %s, got %s", pod.Name, pod.Namespace, key, expectedValue, value))
 				}
 			} else {
-				errs = append(errs, fmt.Errorf("pod %s in namespace %s is missing required label %s", pod.Name, pod.Namespace, key))
+				results = append(results, Errorf(path.Key(key), CodeLabelMissing, "pod %s in namespace %s is missing required label %s", pod.Name, pod.Namespace, key))
 			}
 		}
 	}
-	return errs
+	return results
+}
+
+// podNames returns the namespace/name of each pod, for messages.
+func podNames(pods []corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return names
 }
 
 func contains(slice []string, str string) bool {
@@ -0,0 +1,182 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func newListeningPod(name, namespace string, port int32) *corev1.Pod {
+	pod := newPod(name, "nginx", map[string]string{"app": "web"})
+	pod.Namespace = namespace
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: port, Protocol: corev1.ProtocolTCP}}
+	return pod
+}
+
+func TestValidatePortListening(t *testing.T) {
+	pods := []corev1.Pod{*newListeningPod("web", "default", 80)}
+	k8sClient := newFakeClient(t)
+
+	tests := []struct {
+		name  string
+		rules []v1.NetPolDetail
+		pods  []corev1.Pod
+		want  []Code
+	}{
+		{
+			name: "no rules",
+			pods: pods,
+			want: []Code{CodeRuleMissing},
+		},
+		{
+			name:  "port rule on a listening port",
+			rules: []v1.NetPolDetail{{Kind: "port", Port: "80", Protocol: "TCP"}},
+			pods:  pods,
+		},
+		{
+			name:  "named port",
+			rules: []v1.NetPolDetail{{Kind: "port", Port: "http", Protocol: "TCP"}},
+			pods:  pods,
+		},
+		{
+			name:  "port rule without a port",
+			rules: []v1.NetPolDetail{{Kind: "port", Protocol: "TCP"}},
+			pods:  pods,
+			want:  []Code{CodePortMissing},
+		},
+		{
+			name: "peer rules without a port",
+			rules: []v1.NetPolDetail{
+				{Kind: "endpoint", Labels: map[string]string{"app": "api"}},
+				{Kind: "cidr", Args: []string{"10.0.0.0/8"}},
+				{Kind: "fqdns", Args: []string{"example.com"}},
+				{Kind: "service", Args: []string{"db"}},
+				{Kind: "entities", Args: []string{"world"}},
+				{Kind: "node"},
+			},
+			pods: pods,
+		},
+		{
+			name:  "peer rule with a port nobody listens on",
+			rules: []v1.NetPolDetail{{Kind: "endpoint", Labels: map[string]string{"app": "api"}, Port: "22", Protocol: "TCP"}},
+			pods:  pods,
+			want:  []Code{CodePortNotListening},
+		},
+		{
+			name:  "invalid port",
+			rules: []v1.NetPolDetail{{Kind: "port", Port: "http-", Protocol: "TCP"}},
+			pods:  pods,
+			want:  []Code{CodePortInvalid},
+		},
+		{
+			name:  "invalid protocol",
+			rules: []v1.NetPolDetail{{Kind: "port", Port: "80", Protocol: "SCTP"}},
+			pods:  pods,
+			want:  []Code{CodeProtocolUnsupported, CodePortNotListening},
+		},
+		{
+			name:  "icmp rule with a port",
+			rules: []v1.NetPolDetail{{Kind: "icmp", Port: "80"}},
+			pods:  pods,
+			want:  []Code{CodePortInvalid},
+		},
+		{
+			name:  "protocol rule",
+			rules: []v1.NetPolDetail{{Kind: "protocol", Protocol: "UDP"}},
+			pods:  pods,
+		},
+		{
+			name:  "pods without declared ports",
+			rules: []v1.NetPolDetail{{Kind: "port", Port: "22", Protocol: "TCP"}},
+			pods:  []corev1.Pod{*newPod("web", "nginx", nil)},
+			want:  []Code{CodePortsNotDeclared},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intentRequest := v1.IntentRequest{Type: "network", Rule: v1.Rule{To: tt.rules}}
+			results := validatePortListening(context.Background(), k8sClient, field.NewPath("spec", "intentRequest").Index(0), intentRequest, tt.pods)
+			if got := codes(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v (%v), want %v", got, results, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkIntentRequest(t *testing.T) {
+	namespace := func(name, team string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}}
+	}
+	k8sClient := newFakeClient(t, newListeningPod("web", "shop", 80), newListeningPod("web", "cart", 80),
+		namespace("shop", "store"), namespace("cart", "store"), namespace("default", "platform"))
+	selectWeb := v1.Selector{Match: []v1.Match{{MatchLabels: map[string]string{"app": "web"}}}}
+	selectStore := selectWeb
+	selectStore.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "store"}}
+	rules := []v1.NetPolDetail{{Kind: "port", Port: "80", Protocol: "TCP"}}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		clusterWide bool
+		selector    v1.Selector
+		want        []Code
+	}{
+		{
+			name:      "pods in the policy's namespace",
+			namespace: "shop",
+			selector:  selectWeb,
+		},
+		{
+			name:      "no pods in the policy's namespace",
+			namespace: "default",
+			selector:  selectWeb,
+			want:      []Code{CodeSelectorNoMatch},
+		},
+		{
+			name:        "cluster-wide policies look in every namespace",
+			namespace:   "default",
+			clusterWide: true,
+			selector:    selectWeb,
+		},
+		{
+			name:      "namespaceSelector matching other namespaces",
+			namespace: "shop",
+			selector:  selectStore,
+			want:      []Code{CodeNamespaceNotEnforced},
+		},
+		{
+			name:      "namespaceSelector not matching the policy's namespace",
+			namespace: "default",
+			selector:  selectStore,
+			want:      []Code{CodeNamespaceNotEnforced, CodeSelectorNoMatch},
+		},
+		{
+			name:        "cluster-wide policies select pods in other namespaces",
+			namespace:   "default",
+			clusterWide: true,
+			selector:    selectStore,
+		},
+		{
+			name:      "empty selector",
+			namespace: "shop",
+			want:      []Code{CodeSelectorMissing},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intentRequest := v1.IntentRequest{Type: "network", Selector: tt.selector, Rule: v1.Rule{To: rules}}
+			results := validateNetworkIntentRequest(context.Background(), k8sClient, field.NewPath("spec", "intentRequest").Index(0), tt.namespace, tt.clusterWide, intentRequest)
+			if got := codes(results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v (%v), want %v", got, results, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Credential is a username and password for a registry.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Keychain maps registry hosts to credentials.
+type Keychain map[string]Credential
+
+// dockerConfig is the content of a .dockerconfigjson pull secret. A .dockercfg
+// pull secret holds the auths map alone.
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// KeychainFromSecrets reads the credentials of imagePullSecrets. Secrets of
+// other types are ignored.
+func KeychainFromSecrets(secrets []corev1.Secret) (Keychain, error) {
+	keychain := Keychain{}
+	for _, secret := range secrets {
+		var auths map[string]dockerAuth
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var config dockerConfig
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse pull secret %s/%s", secret.Namespace, secret.Name)
+			}
+			auths = config.Auths
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse pull secret %s/%s", secret.Namespace, secret.Name)
+			}
+		default:
+			continue
+		}
+
+		for server, auth := range auths {
+			credential := Credential{Username: auth.Username, Password: auth.Password}
+			if auth.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid auth for %s in pull secret %s/%s", server, secret.Namespace, secret.Name)
+				}
+				credential.Username, credential.Password, _ = strings.Cut(string(decoded), ":")
+			}
+			keychain[normalizeServer(server)] = credential
+		}
+	}
+	return keychain, nil
+}
+
+// normalizeServer turns the server keys of docker config files, which may be
+// URLs, into registry hosts.
+func normalizeServer(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		server = u.Host
+	}
+	server = strings.TrimSuffix(server, "/")
+	switch server {
+	case "index.docker.io", dockerHubAPI:
+		return DockerHub
+	}
+	return server
+}
+
+// challenge is a parsed WWW-Authenticate header.
+type challenge struct {
+	scheme string
+	params map[string]string
+}
+
+func parseChallenge(header string) challenge {
+	scheme, rest, _ := strings.Cut(header, " ")
+	c := challenge{scheme: strings.ToLower(scheme), params: map[string]string{}}
+	for _, part := range splitParams(rest) {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		c.params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return c
+}
+
+// splitParams splits challenge parameters on commas outside quotes.
+func splitParams(s string) []string {
+	var parts []string
+	var quoted bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// authorize answers a registry challenge and returns the Authorization header
+// value to retry with.
+func (c *Client) authorize(ctx context.Context, ref Reference, header string) (string, error) {
+	credential, hasCredential := c.Keychain[ref.Registry]
+	ch := parseChallenge(header)
+
+	switch ch.scheme {
+	case "basic":
+		if !hasCredential {
+			return "", errors.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credential.Username+":"+credential.Password)), nil
+	case "bearer":
+		realm := ch.params["realm"]
+		if realm == "" {
+			return "", errors.Errorf("registry %s sent a bearer challenge without realm", ref.Registry)
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid token realm %q", realm)
+		}
+		query := tokenURL.Query()
+		if service := ch.params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		scope := ch.params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+		}
+		query.Set("scope", scope)
+		tokenURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCredential {
+			req.SetBasicAuth(credential.Username, credential.Password)
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to fetch token from %s", tokenURL.Host)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", errors.Errorf("token request to %s failed: %s", tokenURL.Host, resp.Status)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", errors.Wrap(err, "failed to decode token response")
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	}
+	return "", errors.Errorf("unsupported authentication scheme %q from %s", ch.scheme, ref.Registry)
+}
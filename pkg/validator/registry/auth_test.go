@@ -0,0 +1,199 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestKeychainFromSecrets(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("bob:secret:with:colons"))
+	secrets := []corev1.Secret{
+		{
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{
+				"https://index.docker.io/v1/": {"username": "alice", "password": "hunter2"},
+				"ghcr.io": {"auth": "` + encoded + `"}
+			}}`)},
+		},
+		{
+			Type: corev1.SecretTypeDockercfg,
+			Data: map[string][]byte{corev1.DockerConfigKey: []byte(`{"localhost:5000/": {"username": "dev", "password": "dev"}}`)},
+		},
+		{
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{"token": []byte("ignored")},
+		},
+	}
+
+	got, err := KeychainFromSecrets(secrets)
+	if err != nil {
+		t.Fatalf("KeychainFromSecrets() error = %v", err)
+	}
+	want := Keychain{
+		DockerHub:        {Username: "alice", Password: "hunter2"},
+		"ghcr.io":        {Username: "bob", Password: "secret:with:colons"},
+		"localhost:5000": {Username: "dev", Password: "dev"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("KeychainFromSecrets() = %v, want %v", got, want)
+	}
+
+	invalid := []corev1.Secret{{Type: corev1.SecretTypeDockerConfigJson, Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte("{")}}}
+	if _, err := KeychainFromSecrets(invalid); err == nil {
+		t.Errorf("KeychainFromSecrets() of an invalid secret succeeded")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		header string
+		want   challenge
+	}{
+		{
+			header: `Basic realm="registry"`,
+			want:   challenge{scheme: "basic", params: map[string]string{"realm": "registry"}},
+		},
+		{
+			header: `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:app:pull,push"`,
+			want: challenge{scheme: "bearer", params: map[string]string{
+				"realm":   "https://auth.example.com/token",
+				"service": "registry.example.com",
+				"scope":   "repository:app:pull,push",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		if got := parseChallenge(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChallenge(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// requireAuthorization accepts requests with the given Authorization header
+// and answers the others with challenge.
+func requireAuthorization(authorization, challenge string) func(http.ResponseWriter, *http.Request) bool {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") == authorization {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	manifests := map[string][]byte{"v1": []byte(`{}`)}
+	credential := Credential{Username: "alice", Password: "hunter2"}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:hunter2"))
+
+	tests := []struct {
+		name     string
+		keychain bool
+		registry func(realm string) *fakeRegistry
+		wantErr  string
+	}{
+		{
+			name:     "basic",
+			keychain: true,
+			registry: func(string) *fakeRegistry {
+				return &fakeRegistry{Manifests: manifests, Authorize: requireAuthorization(basic, `Basic realm="registry"`)}
+			},
+		},
+		{
+			name: "basic without credentials",
+			registry: func(string) *fakeRegistry {
+				return &fakeRegistry{Manifests: manifests, Authorize: requireAuthorization(basic, `Basic realm="registry"`)}
+			},
+			wantErr: "requires credentials",
+		},
+		{
+			name:     "bearer",
+			keychain: true,
+			registry: func(realm string) *fakeRegistry {
+				return &fakeRegistry{
+					Manifests: manifests,
+					Authorize: requireAuthorization("Bearer t0ken", `Bearer realm="`+realm+`",service="test"`),
+					Token: func(w http.ResponseWriter, r *http.Request) {
+						query := r.URL.Query()
+						if r.Header.Get("Authorization") != basic || query.Get("service") != "test" || query.Get("scope") != "repository:app:pull" {
+							http.Error(w, "denied", http.StatusForbidden)
+							return
+						}
+						_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0ken"})
+					},
+				}
+			},
+		},
+		{
+			name: "anonymous bearer token",
+			registry: func(realm string) *fakeRegistry {
+				return &fakeRegistry{
+					Manifests: manifests,
+					Authorize: requireAuthorization("Bearer anonymous", `Bearer realm="`+realm+`"`),
+					Token: func(w http.ResponseWriter, r *http.Request) {
+						_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "anonymous"})
+					},
+				}
+			},
+		},
+		{
+			name:     "token request rejected",
+			keychain: true,
+			registry: func(realm string) *fakeRegistry {
+				return &fakeRegistry{
+					Manifests: manifests,
+					Authorize: requireAuthorization("Bearer t0ken", `Bearer realm="`+realm+`"`),
+					Token: func(w http.ResponseWriter, r *http.Request) {
+						http.Error(w, "denied", http.StatusForbidden)
+					},
+				}
+			},
+			wantErr: "403",
+		},
+		{
+			name:     "credentials rejected",
+			keychain: true,
+			registry: func(string) *fakeRegistry {
+				return &fakeRegistry{Manifests: manifests, Authorize: requireAuthorization("Basic other", `Basic realm="registry"`)}
+			},
+			wantErr: "rejected the credentials",
+		},
+		{
+			name: "unsupported scheme",
+			registry: func(string) *fakeRegistry {
+				return &fakeRegistry{Manifests: manifests, Authorize: requireAuthorization("none", `Negotiate`)}
+			},
+			wantErr: `unsupported authentication scheme "negotiate"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The token realm is only known once the server runs.
+			var registry *fakeRegistry
+			client, ref := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				registry.ServeHTTP(w, r)
+			}))
+			registry = tt.registry("http://" + ref.Registry + "/token")
+			if tt.keychain {
+				client.Keychain = Keychain{ref.Registry: credential}
+			}
+
+			_, err := client.Resolve(context.Background(), ref)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
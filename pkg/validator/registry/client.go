@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Manifest media types accepted when resolving a reference.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ErrNotFound is returned when a manifest or blob does not exist.
+var ErrNotFound = errors.New("not found")
+
+// maxManifestSize bounds the manifests and signature payloads read into memory.
+const maxManifestSize = 4 << 20
+
+// Client talks to OCI Distribution v2 registries.
+type Client struct {
+	// HTTPClient is used for every request. A client with a 10 second
+	// timeout is used when it is nil.
+	HTTPClient *http.Client
+
+	// Keychain holds the credentials used when a registry asks for them.
+	Keychain Keychain
+
+	// PlainHTTP lists registry hosts reached over plain HTTP, such as a
+	// local registry.
+	PlainHTTP []string
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return defaultHTTPClient
+}
+
+// Resolve returns the digest of the manifest a reference points to.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (string, error) {
+	resp, err := c.do(ctx, http.MethodHead, ref, "manifests/"+ref.Identifier(), manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// The header is optional; hash the manifest instead.
+	_, digest, err := c.manifest(ctx, ref, ref.Identifier())
+	return digest, err
+}
+
+// RepositoryExists reports whether the repository can be listed, for
+// references whose tag is a pattern.
+func (c *Client) RepositoryExists(ctx context.Context, ref Reference) error {
+	resp, err := c.do(ctx, http.MethodGet, ref, "tags/list", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// manifest fetches a manifest and returns it along with its digest.
+func (c *Client) manifest(ctx context.Context, ref Reference, identifier string) ([]byte, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, "manifests/"+identifier, manifestMediaTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to read manifest of %s", ref)
+	}
+	sum := sha256.Sum256(body)
+	return body, "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// blob fetches a blob and checks it against its digest.
+func (c *Client) blob(ctx context.Context, ref Reference, digest string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blob %s", digest)
+	}
+	sum := sha256.Sum256(body)
+	if "sha256:"+hex.EncodeToString(sum[:]) != digest {
+		return nil, errors.Errorf("blob %s of %s does not match its digest", digest, ref)
+	}
+	return body, nil
+}
+
+// do sends a request to the repository API, answering one authentication
+// challenge if the registry asks for it. The caller closes the body of a
+// successful response.
+func (c *Client) do(ctx context.Context, method string, ref Reference, path string, accept []string) (*http.Response, error) {
+	scheme := "https"
+	for _, host := range c.PlainHTTP {
+		if host == ref.Registry {
+			scheme = "http"
+		}
+	}
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.apiHost(), ref.Repository, path)
+
+	var authorization string
+	for {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "request to %s failed", ref.Registry)
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusUnauthorized && authorization == "":
+			header := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if authorization, err = c.authorize(ctx, ref, header); err != nil {
+				return nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusUnauthorized:
+			resp.Body.Close()
+			return nil, errors.Errorf("registry %s rejected the credentials for %s", ref.Registry, ref.Repository)
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, errors.Wrapf(ErrNotFound, "%s %s", ref.Repository, path)
+		default:
+			resp.Body.Close()
+			return nil, errors.Errorf("%s %s on %s: %s", method, path, ref.Registry, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeRegistry serves the manifests and blobs of the "app" repository.
+type fakeRegistry struct {
+	// Manifests are keyed by tag or digest.
+	Manifests map[string][]byte
+	Blobs     map[string][]byte
+
+	// OmitDigest leaves out the Docker-Content-Digest header.
+	OmitDigest bool
+
+	// Authorize, when set, decides whether a repository request may be served.
+	// It writes the challenge itself when it returns false.
+	Authorize func(w http.ResponseWriter, r *http.Request) bool
+
+	// Token, when set, serves /token.
+	Token http.HandlerFunc
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" && f.Token != nil {
+		f.Token(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.mu.Unlock()
+
+	if f.Authorize != nil && !f.Authorize(w, r) {
+		return
+	}
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/v2/app/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	kind, name, _ := strings.Cut(path, "/")
+	var body []byte
+	switch kind {
+	case "manifests":
+		body, ok = f.Manifests[name]
+	case "blobs":
+		body, ok = f.Blobs[name]
+	case "tags":
+		body, ok = []byte(`{"name":"app","tags":[]}`), name == "list"
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if kind == "manifests" && !f.OmitDigest {
+		w.Header().Set("Docker-Content-Digest", digestOf(body))
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+func (f *fakeRegistry) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+// newTestClient starts a registry server and returns a client that reaches it
+// over plain HTTP, along with a reference to app:v1 on it.
+func newTestClient(t *testing.T, registry http.Handler) (*Client, Reference) {
+	t.Helper()
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := &Client{HTTPClient: server.Client(), PlainHTTP: []string{host}}
+	return client, Reference{Registry: host, Repository: "app", Tag: "v1"}
+}
+
+func digestOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestResolve(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	digest := digestOf(manifest)
+
+	tests := []struct {
+		name         string
+		registry     *fakeRegistry
+		ref          func(Reference) Reference
+		want         string
+		wantRequests []string
+		wantErr      error
+	}{
+		{
+			name:         "digest header",
+			registry:     &fakeRegistry{Manifests: map[string][]byte{"v1": manifest}},
+			want:         digest,
+			wantRequests: []string{"HEAD /v2/app/manifests/v1"},
+		},
+		{
+			name:         "manifest is hashed without the digest header",
+			registry:     &fakeRegistry{Manifests: map[string][]byte{"v1": manifest}, OmitDigest: true},
+			want:         digest,
+			wantRequests: []string{"HEAD /v2/app/manifests/v1", "GET /v2/app/manifests/v1"},
+		},
+		{
+			name:     "digest reference",
+			registry: &fakeRegistry{Manifests: map[string][]byte{digest: manifest}},
+			ref: func(ref Reference) Reference {
+				ref.Tag, ref.Digest = "", digest
+				return ref
+			},
+			want:         digest,
+			wantRequests: []string{"HEAD /v2/app/manifests/" + digest},
+		},
+		{
+			name:         "missing tag",
+			registry:     &fakeRegistry{Manifests: map[string][]byte{"v2": manifest}},
+			wantRequests: []string{"HEAD /v2/app/manifests/v1"},
+			wantErr:      ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ref := newTestClient(t, tt.registry)
+			if tt.ref != nil {
+				ref = tt.ref(ref)
+			}
+			got, err := client.Resolve(context.Background(), ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+			if requests := tt.registry.Requests(); !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("Resolve() sent %q, want %q", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRepositoryExists(t *testing.T) {
+	client, ref := newTestClient(t, &fakeRegistry{})
+	if err := client.RepositoryExists(context.Background(), ref); err != nil {
+		t.Errorf("RepositoryExists() error = %v", err)
+	}
+
+	ref.Repository = "other"
+	if err := client.RepositoryExists(context.Background(), ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RepositoryExists() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestBlobDigestMismatch(t *testing.T) {
+	digest := digestOf([]byte("payload"))
+	client, ref := newTestClient(t, &fakeRegistry{Blobs: map[string][]byte{digest: []byte("tampered")}})
+	if _, err := client.blob(context.Background(), ref, digest); err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+		t.Errorf("blob() error = %v, want a digest mismatch", err)
+	}
+}
+
+func TestPlainHTTP(t *testing.T) {
+	registry := &fakeRegistry{Manifests: map[string][]byte{"v1": []byte(`{}`)}}
+	client, ref := newTestClient(t, registry)
+
+	// The test server only speaks HTTP, so a client that does not list its
+	// host fails to reach it.
+	client.PlainHTTP = []string{"registry.example.com"}
+	_, err := client.Resolve(context.Background(), ref)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() error = %v, want a failed HTTPS request", err)
+	}
+	if requests := registry.Requests(); len(requests) != 0 {
+		t.Errorf("Resolve() reached the registry over HTTPS: %q", requests)
+	}
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	client, ref := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	_, err := client.Resolve(context.Background(), ref)
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Resolve() error = %v, want the 503 status", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Package registry is a small OCI Distribution v2 client used to check that the
+// images named in verifyImage intents exist and are signed.
+package registry
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DockerHub is the registry used for references without a host.
+	DockerHub = "docker.io"
+
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// Reference is a parsed image reference.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference such as "nginx",
+// "ghcr.io/org/app:v1" or "localhost:5000/app@sha256:...". References without
+// a registry host point to Docker Hub, and references without a tag or
+// digest to the "latest" tag.
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	if image == "" {
+		return ref, errors.New("empty image reference")
+	}
+
+	rest := image
+	if i := strings.Index(rest, "@"); i >= 0 {
+		ref.Digest = rest[i+1:]
+		rest = rest[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, errors.Errorf("invalid digest in image reference %q", image)
+		}
+	}
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.Contains(rest[i+1:], "/") {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	if i := strings.Index(rest, "/"); i >= 0 && isHost(rest[:i]) {
+		ref.Registry = rest[:i]
+		ref.Repository = rest[i+1:]
+	} else {
+		ref.Registry = DockerHub
+		ref.Repository = rest
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = DockerHub
+	}
+	if ref.Registry == DockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Repository == "" {
+		return ref, errors.Errorf("missing repository in image reference %q", image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// IsPattern reports whether the reference holds wildcards, as Kyverno image
+// references may.
+func (r Reference) IsPattern() bool {
+	return strings.ContainsAny(r.Repository+r.Tag, "*?")
+}
+
+// Identifier returns the digest if set, and the tag otherwise.
+func (r Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// apiHost returns the host serving the registry API.
+func (r Reference) apiHost() string {
+	if r.Registry == DockerHub {
+		return dockerHubAPI
+	}
+	return r.Registry
+}
+
+func isHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
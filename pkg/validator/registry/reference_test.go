@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{
+			image: "nginx",
+			want:  Reference{Registry: DockerHub, Repository: "library/nginx", Tag: "latest"},
+		},
+		{
+			image: "index.docker.io/org/app:v1",
+			want:  Reference{Registry: DockerHub, Repository: "org/app", Tag: "v1"},
+		},
+		{
+			image: "ghcr.io/org/app:v1",
+			want:  Reference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1"},
+		},
+		{
+			image: "localhost:5000/app@sha256:abc",
+			want:  Reference{Registry: "localhost:5000", Repository: "app", Digest: "sha256:abc"},
+		},
+		{
+			image: "localhost/app:v1@sha256:abc",
+			want:  Reference{Registry: "localhost", Repository: "app", Tag: "v1", Digest: "sha256:abc"},
+		},
+		{image: "", wantErr: true},
+		{image: "app@abc", wantErr: true},
+		{image: "ghcr.io/:v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := ParseReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReference(t *testing.T) {
+	ref := Reference{Registry: DockerHub, Repository: "org/app", Tag: "v*"}
+	if !ref.IsPattern() || ref.Identifier() != "v*" || ref.apiHost() != dockerHubAPI {
+		t.Errorf("Reference %+v: IsPattern() = %v, Identifier() = %q, apiHost() = %q", ref, ref.IsPattern(), ref.Identifier(), ref.apiHost())
+	}
+
+	ref = Reference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: "sha256:abc"}
+	if ref.IsPattern() || ref.Identifier() != "sha256:abc" || ref.apiHost() != "ghcr.io" {
+		t.Errorf("Reference %+v: IsPattern() = %v, Identifier() = %q, apiHost() = %q", ref, ref.IsPattern(), ref.Identifier(), ref.apiHost())
+	}
+	if got := ref.String(); got != "ghcr.io/org/app:v1@sha256:abc" {
+		t.Errorf("String() = %q", got)
+	}
+}
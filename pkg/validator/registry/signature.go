@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// signatureAnnotation holds the base64 signature of a cosign signature layer.
+const signatureAnnotation = "dev.cosignproject.cosign/signature"
+
+// ErrNoSignature is returned when an image has no signature, or none that the
+// given key verifies.
+var ErrNoSignature = errors.New("no matching signature")
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// simpleSigning is the part of a cosign signature payload that names the
+// signed image.
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// SignatureTag returns the tag cosign stores the signatures of digest under.
+func SignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// VerifySignature checks that the image at digest carries a cosign signature.
+// A PEM public key must verify one of the signatures. Other keys, such as KMS
+// URIs, templates or keyless identities, cannot be checked offline, so only
+// the presence of a signature is checked for them.
+func (c *Client) VerifySignature(ctx context.Context, ref Reference, digest, key string) error {
+	body, _, err := c.manifest(ctx, ref, SignatureTag(digest))
+	if errors.Is(err, ErrNotFound) {
+		return errors.Wrapf(ErrNoSignature, "%s@%s is not signed", ref.Repository, digest)
+	}
+	if err != nil {
+		return err
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return errors.Wrapf(err, "failed to parse signature manifest of %s", ref)
+	}
+	if len(manifest.Layers) == 0 {
+		return errors.Wrapf(ErrNoSignature, "%s@%s is not signed", ref.Repository, digest)
+	}
+
+	publicKey, err := parsePublicKey(key)
+	if err != nil {
+		return err
+	}
+	if publicKey == nil {
+		return nil
+	}
+
+	for _, layer := range manifest.Layers {
+		signature, err := base64.StdEncoding.DecodeString(layer.Annotations[signatureAnnotation])
+		if err != nil || len(signature) == 0 {
+			continue
+		}
+		payload, err := c.blob(ctx, ref, layer.Digest)
+		if err != nil {
+			return err
+		}
+		if !verify(publicKey, payload, signature) {
+			continue
+		}
+
+		var signed simpleSigning
+		if err := json.Unmarshal(payload, &signed); err != nil {
+			continue
+		}
+		if signed.Critical.Image.DockerManifestDigest == digest {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrNoSignature, "no signature of %s@%s matches the key", ref.Repository, digest)
+}
+
+// parsePublicKey returns the public key of a PEM key, and nil for keys that
+// are not PEM encoded.
+func parsePublicKey(key string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(key)))
+	if block == nil {
+		return nil, nil
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse public key")
+	}
+	return publicKey, nil
+}
+
+func verify(publicKey crypto.PublicKey, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil {
+			return true
+		}
+		return rsa.VerifyPSS(k, crypto.SHA256, digest[:], signature, nil) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	}
+	return false
+}
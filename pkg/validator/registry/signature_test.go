@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSignatureTag(t *testing.T) {
+	if got := SignatureTag("sha256:abc"); got != "sha256-abc.sig" {
+		t.Errorf("SignatureTag() = %q, want %q", got, "sha256-abc.sig")
+	}
+}
+
+// signer produces cosign signatures with an ECDSA key.
+type signer struct {
+	key       *ecdsa.PrivateKey
+	publicKey string
+}
+
+func newSigner(t *testing.T) signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer{key: key, publicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+}
+
+// sign stores a signature of digest in the registry, as cosign does.
+func (s signer) sign(t *testing.T, registry *fakeRegistry, digest string) {
+	t.Helper()
+	var payload simpleSigning
+	payload.Critical.Image.DockerManifestDigest = digest
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(body)
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := json.Marshal(ociManifest{Layers: []ociDescriptor{{
+		Digest:      digestOf(body),
+		Annotations: map[string]string{signatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.Manifests[SignatureTag(digest)] = manifest
+	registry.Blobs[digestOf(body)] = body
+}
+
+func TestVerifySignature(t *testing.T) {
+	digest := digestOf([]byte(`{"schemaVersion":2}`))
+	owner, other := newSigner(t), newSigner(t)
+
+	tests := []struct {
+		name    string
+		sign    func(t *testing.T, registry *fakeRegistry)
+		key     string
+		wantErr error
+	}{
+		{
+			name: "signed with the key",
+			sign: func(t *testing.T, registry *fakeRegistry) { owner.sign(t, registry, digest) },
+			key:  owner.publicKey,
+		},
+		{
+			name:    "signed with another key",
+			sign:    func(t *testing.T, registry *fakeRegistry) { other.sign(t, registry, digest) },
+			key:     owner.publicKey,
+			wantErr: ErrNoSignature,
+		},
+		{
+			name:    "signature of another image",
+			sign:    func(t *testing.T, registry *fakeRegistry) { owner.sign(t, registry, digestOf([]byte("other"))) },
+			key:     owner.publicKey,
+			wantErr: ErrNoSignature,
+		},
+		{
+			name:    "not signed",
+			sign:    func(t *testing.T, registry *fakeRegistry) {},
+			key:     owner.publicKey,
+			wantErr: ErrNoSignature,
+		},
+		{
+			name: "empty signature manifest",
+			sign: func(t *testing.T, registry *fakeRegistry) {
+				registry.Manifests[SignatureTag(digest)] = []byte(`{"layers":[]}`)
+			},
+			wantErr: ErrNoSignature,
+		},
+		{
+			name: "keys that are not PEM only need a signature",
+			sign: func(t *testing.T, registry *fakeRegistry) { other.sign(t, registry, digest) },
+			key:  "awskms:///alias/cosign",
+		},
+		{
+			name: "keyless only needs a signature",
+			sign: func(t *testing.T, registry *fakeRegistry) { other.sign(t, registry, digest) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &fakeRegistry{Manifests: map[string][]byte{}, Blobs: map[string][]byte{}}
+			tt.sign(t, registry)
+			client, ref := newTestClient(t, registry)
+
+			err := client.VerifySignature(context.Background(), ref, digest, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Validation Results
+// Findings of the validators, each tied to the field of the KubeAegisPolicy it
+// is about. The controller status, the logs and the command line tools all
+// render the same ResultList.
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Severity tells whether a Result blocks the policy.
+type Severity string
+
+const (
+	// SeverityError keeps the policy from being dispatched to the adapters.
+	SeverityError Severity = "Error"
+
+	// SeverityWarning is reported but does not block the policy.
+	SeverityWarning Severity = "Warning"
+)
+
+// Code is a stable identifier of a problem. Codes are part of the API and are
+// never renamed.
+type Code string
+
+const (
+	CodeLookupFailed Code = "LookupFailed"
+
+	// Spec
+	CodeFieldUnknown      Code = "FieldUnknown"
+	CodeFieldInvalid      Code = "FieldInvalid"
+	CodeFieldRequired     Code = "FieldRequired"
+	CodeIntentTypeUnknown Code = "IntentTypeUnknown"
+
+	// Selectors
+	CodeIntentTypeMismatch   Code = "IntentTypeMismatch"
+	CodeSelectorMissing      Code = "SelectorMissing"
+	CodeSelectorInvalid      Code = "SelectorInvalid"
+	CodeSelectorNoMatch      Code = "SelectorNoMatch"
+	CodeNamespaceNotFound    Code = "NamespaceNotFound"
+	CodeNamespaceInactive    Code = "NamespaceInactive"
+	CodeNamespaceNotEnforced Code = "NamespaceNotEnforced"
+	CodeWorkloadNotFound     Code = "WorkloadNotFound"
+
+	// Network intents
+	CodeRuleMissing         Code = "RuleMissing"
+	CodePortMissing         Code = "PortMissing"
+	CodePortInvalid         Code = "PortInvalid"
+	CodePortNotListening    Code = "PortNotListening"
+	CodePortsNotDeclared    Code = "PortsNotDeclared"
+	CodeProtocolUnsupported Code = "ProtocolUnsupported"
+	CodeCIDRInvalid         Code = "CIDRInvalid"
+	CodeCIDRNotCanonical    Code = "CIDRNotCanonical"
+	CodeCIDROverlapsCluster Code = "CIDROverlapsCluster"
+	CodeExceptInvalid       Code = "ExceptInvalid"
+	CodeFQDNInvalid         Code = "FQDNInvalid"
+	CodeFQDNWildcardAll     Code = "FQDNWildcardAll"
+	CodeHTTPPortMissing     Code = "HTTPPortMissing"
+	CodeHTTPRuleInvalid     Code = "HTTPRuleInvalid"
+
+	// System intents
+	CodeSymbolMissing      Code = "SymbolMissing"
+	CodePathNotFound       Code = "PathNotFound"
+	CodeNotADirectory      Code = "NotADirectory"
+	CodePatternNoMatch     Code = "PatternNoMatch"
+	CodePathsNotProbed     Code = "PathsNotProbed"
+	CodeSyscallUnknown     Code = "SyscallUnknown"
+	CodeSyscallUnavailable Code = "SyscallUnavailable"
+	CodeCapabilityUnknown  Code = "CapabilityUnknown"
+	CodeProtocolUnknown    Code = "ProtocolUnknown"
+
+	// Cluster intents
+	CodeLabelMissing       Code = "LabelMissing"
+	CodeLabelMismatch      Code = "LabelMismatch"
+	CodeAnnotationMismatch Code = "AnnotationMismatch"
+	CodeImageInvalid       Code = "ImageInvalid"
+	CodeImageNotFound      Code = "ImageNotFound"
+	CodeImageNotSigned     Code = "ImageNotSigned"
+	CodeImageNotChecked    Code = "ImageNotChecked"
+	CodeRegistryError      Code = "RegistryError"
+
+	// Import
+	CodeFieldUnsupported Code = "FieldUnsupported"
+
+	// Conflicts between policies
+	CodePriorityConflict Code = "PriorityConflict"
+	CodePriorityIgnored  Code = "PriorityIgnored"
+)
+
+// Result is one problem found in a KubeAegisPolicy.
+type Result struct {
+	Field    string
+	Severity Severity
+	Code     Code
+	Message  string
+}
+
+// Errorf returns an error Result for the field at path.
+func Errorf(path *field.Path, code Code, format string, args ...interface{}) Result {
+	return Result{Field: path.String(), Severity: SeverityError, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warningf returns a warning Result for the field at path.
+func Warningf(path *field.Path, code Code, format string, args ...interface{}) Result {
+	return Result{Field: path.String(), Severity: SeverityWarning, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s: %s [%s] %s", r.Severity, r.Field, r.Code, r.Message)
+}
+
+// ResultList is the outcome of validating a KubeAegisPolicy.
+type ResultList []Result
+
+// HasErrors reports whether the list holds a Result of SeverityError.
+func (l ResultList) HasErrors() bool {
+	for _, r := range l {
+		if r.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of Results of the given severity.
+func (l ResultList) Count(severity Severity) int {
+	n := 0
+	for _, r := range l {
+		if r.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Sorted returns the list ordered by field, with errors before warnings on
+// the same field.
+func (l ResultList) Sorted() ResultList {
+	sorted := append(ResultList(nil), l...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Field != sorted[j].Field {
+			return sorted[i].Field < sorted[j].Field
+		}
+		return sorted[i].Severity == SeverityError && sorted[j].Severity != SeverityError
+	})
+	return sorted
+}
+
+// String renders one Result per line.
+func (l ResultList) String() string {
+	var b strings.Builder
+	for _, r := range l.Sorted() {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Summary returns a one-line count of errors and warnings.
+func (l ResultList) Summary() string {
+	return fmt.Sprintf("%d error(s), %d warning(s)", l.Count(SeverityError), l.Count(SeverityWarning))
+}
+
+// Status converts the list to the form stored in the KubeAegisPolicy status.
+func (l ResultList) Status() []v1.ValidationResult {
+	if len(l) == 0 {
+		return nil
+	}
+	results := make([]v1.ValidationResult, 0, len(l))
+	for _, r := range l.Sorted() {
+		results = append(results, v1.ValidationResult{
+			Field:    r.Field,
+			Severity: string(r.Severity),
+			Code:     string(r.Code),
+			Message:  r.Message,
+		})
+	}
+	return results
+}
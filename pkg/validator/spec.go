@@ -0,0 +1,111 @@
+// Spec Validator
+// Check the fields of a KubeAegisPolicy that can be judged without looking at
+// the cluster.
+package validator
+
+import (
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// intentTypes are the values of the type of an intent request.
+var intentTypes = []string{"network", "system", "cluster"}
+
+// scopes are the values of the scope of a policy.
+var scopes = []string{v1.ScopeNamespace, v1.ScopeCluster, v1.ScopeBaseline}
+
+// maxClusterPriority is the highest priority of an AdminNetworkPolicy, which
+// Cluster scope policies are rendered as.
+const maxClusterPriority = 1000
+
+// ValidateSpec checks the required fields and the intent types of the policy.
+func ValidateSpec(kap *v1.KubeAegisPolicy) ResultList {
+	intentsPath := field.NewPath("spec", "intentRequest")
+	if len(kap.Spec.IntentRequest) == 0 {
+		return ResultList{Errorf(intentsPath, CodeFieldRequired, "policy has no intent requests")}
+	}
+
+	results := validateScope(kap)
+	for i, intentRequest := range kap.Spec.IntentRequest {
+		path := intentsPath.Index(i)
+		switch {
+		case intentRequest.Type == "":
+			results = append(results, Errorf(path.Child("type"), CodeFieldRequired, "type is empty; must be one of %v", intentTypes))
+		case !contains(intentTypes, intentRequest.Type):
+			results = append(results, Errorf(path.Child("type"), CodeIntentTypeUnknown, "unknown intent type %q; must be one of %v", intentRequest.Type, intentTypes))
+		}
+
+		for _, r := range networkRules(path, intentRequest) {
+			if r.rule.Kind == "" {
+				results = append(results, Errorf(r.path.Child("kind"), CodeFieldRequired, "kind is empty"))
+			}
+		}
+		for p, point := range intentRequest.Rule.ActionPoint {
+			pointPath := path.Child("rule", "actionPoint").Index(p)
+			if point.SubType == "" {
+				results = append(results, Errorf(pointPath.Child("subType"), CodeFieldRequired, "subType is empty"))
+			}
+			results = append(results, validateResourceOptions(pointPath.Child("resource"), point)...)
+		}
+	}
+	return results
+}
+
+// validateResourceOptions checks that the readOnly, ownerOnly, recursive and
+// fromSource options of an action point are set where KubeArmor accepts them.
+func validateResourceOptions(path *field.Path, point v1.ActionPoint) ResultList {
+	var results ResultList
+	resource := point.Resource
+	if resource.ReadOnly && point.SubType != "file" {
+		results = append(results, Errorf(path.Child("readOnly"), CodeFieldInvalid, "readOnly only applies to file action points"))
+	}
+	if resource.OwnerOnly && point.SubType != "file" && point.SubType != "process" {
+		results = append(results, Errorf(path.Child("ownerOnly"), CodeFieldInvalid, "ownerOnly only applies to file and process action points"))
+	}
+	if resource.Recursive && resource.Dir == "" {
+		results = append(results, Errorf(path.Child("recursive"), CodeFieldInvalid, "recursive needs a dir"))
+	}
+	if len(resource.FromSource) == 0 {
+		return results
+	}
+
+	fromSourcePath := path.Child("fromSource")
+	switch {
+	case point.SubType != "file" && point.SubType != "process" && point.SubType != "network":
+		results = append(results, Errorf(fromSourcePath, CodeFieldInvalid, "fromSource only applies to file, process and network action points"))
+	case len(resource.Pattern) > 0:
+		results = append(results, Errorf(fromSourcePath, CodeFieldInvalid, "fromSource cannot be combined with patterns"))
+	}
+	for i, source := range resource.FromSource {
+		if (source.Path == "") == (source.Dir == "") {
+			results = append(results, Errorf(fromSourcePath.Index(i), CodeFieldInvalid, "fromSource needs exactly one of path or dir"))
+		}
+		if source.Recursive && source.Dir == "" {
+			results = append(results, Errorf(fromSourcePath.Index(i).Child("recursive"), CodeFieldInvalid, "recursive needs a dir"))
+		}
+	}
+	return results
+}
+
+// validateScope checks the scope of the policy, and that Cluster scope
+// policies have a priority in the range AdminNetworkPolicy accepts.
+func validateScope(kap *v1.KubeAegisPolicy) ResultList {
+	specPath := field.NewPath("spec")
+	switch kap.Spec.Scope {
+	case "", v1.ScopeNamespace, v1.ScopeBaseline:
+		return nil
+	case v1.ScopeCluster:
+		if kap.Spec.Priority != nil && *kap.Spec.Priority > maxClusterPriority {
+			return ResultList{Errorf(specPath.Child("priority"), CodeFieldInvalid, "priority %d is above %d", *kap.Spec.Priority, maxClusterPriority)}
+		}
+		return nil
+	default:
+		return ResultList{Errorf(specPath.Child("scope"), CodeFieldInvalid, "unknown scope %q; must be one of %v", kap.Spec.Scope, scopes)}
+	}
+}
+
+// selectsClusterWide reports whether the network intents of the policy select
+// pods in every namespace instead of its own.
+func selectsClusterWide(kap *v1.KubeAegisPolicy) bool {
+	return kap.Spec.Scope == v1.ScopeCluster || kap.Spec.Scope == v1.ScopeBaseline
+}
@@ -0,0 +1,35 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/cclab-inu/KubeAegis/api/v1"
+)
+
+func TestValidateScope(t *testing.T) {
+	priority := func(p int32) *int32 { return &p }
+
+	tests := []struct {
+		name     string
+		scope    string
+		priority *int32
+		want     []Code
+	}{
+		{name: "default scope"},
+		{name: "cluster scope without a priority", scope: v1.ScopeCluster},
+		{name: "cluster scope with a priority", scope: v1.ScopeCluster, priority: priority(1000)},
+		{name: "cluster priority out of range", scope: v1.ScopeCluster, priority: priority(1001), want: []Code{CodeFieldInvalid}},
+		{name: "baseline scope", scope: v1.ScopeBaseline, priority: priority(5000)},
+		{name: "unknown scope", scope: "Global", want: []Code{CodeFieldInvalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kap := &v1.KubeAegisPolicy{Spec: v1.KubeAegisPolicySpec{Scope: tt.scope, Priority: tt.priority}}
+			if got := codes(validateScope(kap)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}